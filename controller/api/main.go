@@ -3,11 +3,17 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"movePoint/internal/database"
+	"movePoint/internal/events"
 	"movePoint/internal/handlers"
+	"movePoint/internal/models"
+	"movePoint/internal/reports"
 	"movePoint/internal/services"
 	"movePoint/pkg/middleware"
+	"movePoint/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -20,10 +26,16 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// 默认语言区域为中文，PDF 报告需要 REPORT_PDF_FONT 指定的中文字体；
+	// 未配置时只生成 HTML 报告，下载 PDF 返回 503
+	if err := reports.CheckPDFFont(utils.DefaultLocale); err != nil {
+		log.Println("Report PDF disabled:", err)
+	}
+
 	// 初始化数据库
 	dsn := os.Getenv("DB_DSN")
 	if dsn == "" {
-		dsn = "root:123456@tcp(127.0.0.1:3306)/movepoint?charset=utf8mb4&parseTime=True&loc=Local"
+		dsn = "root:123456@tcp(127.0.0.1:3306)/movepoint?charset=utf8mb4&parseTime=True&loc=UTC"
 	}
 
 	err = database.InitDB(dsn)
@@ -36,12 +48,90 @@ func main() {
 	analysisService := services.NewAnalysisService(database.DB)
 	userService := services.NewUserService(database.DB)
 	authService := services.NewAuthService(database.DB)
+	importService := services.NewImportService(database.DB, climbingService)
+	heartRateService := services.NewHeartRateService(database.DB)
+	personalRecordService := services.NewPersonalRecordService(database.DB)
+	rollupService := services.NewRollupService(database.DB)
+	shareService := services.NewShareService(database.DB)
+	reportService := services.NewReportService(database.DB, analysisService, userService)
+	goalService := services.NewGoalService(database.DB)
+	trainingPlanService := services.NewTrainingPlanService(database.DB)
+	strengthService := services.NewStrengthService(database.DB)
+	activityService := services.NewActivityService(database.DB)
+	liveSessionService := services.NewLiveSessionService(database.DB, climbingService, events.Live)
+	followService := services.NewFollowService(database.DB, events.Live)
+	syncService := services.NewSyncService(database.DB, climbingService)
+	historyService := services.NewHistoryService(database.DB, climbingService, userService)
+	roleService := services.NewRoleService(database.DB)
+	adminService := services.NewAdminService(database.DB)
+	leaderboardService := services.NewLeaderboardService(database.DB)
+	teamService := services.NewTeamService(database.DB, leaderboardService)
+	coachService := services.NewCoachService(database.DB, analysisService, climbingService, trainingPlanService)
+
+	// 创建内置角色，ADMIN_EMAILS (逗号分隔) 中已注册的用户授予管理员角色
+	if err := roleService.EnsureBuiltinRoles(); err != nil {
+		log.Fatal("Failed to create builtin roles:", err)
+	}
+	if emails := os.Getenv("ADMIN_EMAILS"); emails != "" {
+		if err := roleService.GrantAdmins(strings.Split(emails, ",")); err != nil {
+			log.Println("Failed to grant admin role:", err)
+		}
+	}
+
+	// 订阅攀岩记录变更事件 (预聚合统计先于分析缓存失效更新)
+	rollupService.Subscribe(events.Default)
+	analysisService.Subscribe(events.Default)
+	personalRecordService.Subscribe(events.Default)
+	goalService.Subscribe(events.Default)
+	trainingPlanService.Subscribe(events.Default)
+	syncService.Subscribe(events.Default)
+	historyService.Subscribe(events.Default)
+	coachService.Subscribe(events.Default)
+	leaderboardService.Subscribe(events.Default)
+
+	// 补齐历史数据的预聚合统计、同步元数据、排行榜成绩和单周时长纪录，定期清理过期的分析缓存、生成上月和去年的报告、检查目标进度、
+	// 结束无操作超时的实时攀岩、清理回收站
+	go func() {
+		if err := rollupService.Backfill(); err != nil {
+			log.Println("Failed to backfill rollups:", err)
+		}
+		if err := syncService.Backfill(); err != nil {
+			log.Println("Failed to backfill sync state:", err)
+		}
+		if err := leaderboardService.Backfill(); err != nil {
+			log.Println("Failed to backfill leaderboards:", err)
+		}
+		if err := personalRecordService.Backfill(); err != nil {
+			log.Println("Failed to backfill personal records:", err)
+		}
+	}()
+	go analysisService.RunCacheCleanup(time.Hour)
+	go reportService.RunScheduler(time.Hour)
+	go goalService.RunChecker(time.Hour)
+	go liveSessionService.RunAutoClose(time.Minute)
+	go climbingService.RunTrashPurge(time.Hour)
 
 	// 初始化处理器
 	climbingHandler := handlers.NewClimbingHandler(climbingService)
 	analysisHandler := handlers.NewAnalysisHandler(analysisService)
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, personalRecordService)
 	authHandler := handlers.NewAuthHandler(authService)
+	importHandler := handlers.NewImportHandler(importService)
+	heartRateHandler := handlers.NewHeartRateHandler(heartRateService)
+	chartHandler := handlers.NewChartHandler(analysisService, userService, strengthService, shareService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	goalHandler := handlers.NewGoalHandler(goalService)
+	trainingPlanHandler := handlers.NewTrainingPlanHandler(trainingPlanService)
+	strengthHandler := handlers.NewStrengthHandler(strengthService)
+	activityHandler := handlers.NewActivityHandler(activityService)
+	liveSessionHandler := handlers.NewLiveSessionHandler(liveSessionService, events.Live)
+	followHandler := handlers.NewFollowHandler(followService)
+	syncHandler := handlers.NewSyncHandler(syncService)
+	historyHandler := handlers.NewHistoryHandler(historyService)
+	coachHandler := handlers.NewCoachHandler(coachService)
+	teamHandler := handlers.NewTeamHandler(teamService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	adminHandler := handlers.NewAdminHandler(adminService, roleService, climbingService, userService)
 
 	// 设置路由
 	router := gin.Default()
@@ -51,28 +141,212 @@ func main() {
 	{
 		public.POST("/register", authHandler.Register)
 		public.POST("/login", authHandler.Login)
+
+		// 图表分享链接
+		public.GET("/share/charts/:code", chartHandler.GetSharedChart)
 	}
 
+	// 自己的训练数据及派生的分析、图表和报告，按角色的 records:read:own / records:write:own 权限访问
+	readRecords := middleware.RequirePermission(models.PermRecordsReadOwn)
+	writeRecords := middleware.RequirePermission(models.PermRecordsWriteOwn)
+
 	// 需要认证的路由组
 	auth := router.Group("/api")
-	auth.Use(middleware.AuthMiddleware())
+	auth.Use(
+		middleware.AuthMiddleware(),
+		middleware.AccessMiddleware(roleService.UserAccess),
+		middleware.TimeSettingsMiddleware(userService.GetTimeSettings),
+	)
 	{
-		// 攀岩记录路由
-		auth.POST("/records", climbingHandler.CreateRecord)
-		auth.GET("/records", climbingHandler.GetRecords)
-		auth.GET("/records/:id", climbingHandler.GetRecord)
-		auth.PUT("/records/:id", climbingHandler.UpdateRecord)
-		auth.DELETE("/records/:id", climbingHandler.DeleteRecord)
+		// 长连接 (SSE) 的短期令牌
+		auth.POST("/auth/stream-token", authHandler.StreamToken)
+
+		// 攀岩记录
+		auth.POST("/records", writeRecords, climbingHandler.CreateRecord)
+		auth.GET("/records", readRecords, climbingHandler.GetRecords)
+		auth.GET("/records/:id", readRecords, climbingHandler.GetRecord)
+		auth.PUT("/records/:id", writeRecords, climbingHandler.UpdateRecord)
+		auth.PATCH("/records/:id", writeRecords, climbingHandler.PatchRecord)
+		auth.GET("/records/:id/history", readRecords, historyHandler.GetRecordHistory)
+		auth.POST("/records/:id/history/:entry_id/restore", writeRecords, historyHandler.RestoreRecord)
+		auth.DELETE("/records/:id", writeRecords, climbingHandler.DeleteRecord)
+		auth.PUT("/records/:id/heart-rate", writeRecords, heartRateHandler.SaveHeartRate)
+		auth.GET("/records/:id/heart-rate", readRecords, heartRateHandler.GetHeartRate)
+		auth.GET("/records/:id/comments", readRecords, coachHandler.GetComments)
+		auth.POST("/records/:id/comments", readRecords, coachHandler.AddComment)
+		auth.DELETE("/records/:id/comments/:comment_id", readRecords, coachHandler.DeleteComment)
+
+		// 回收站
+		auth.GET("/trash/records", readRecords, climbingHandler.GetTrash)
+		auth.POST("/trash/records/:id/restore", writeRecords, climbingHandler.UndeleteRecord)
+
+		// 实时攀岩
+		auth.POST("/sessions/start", writeRecords, liveSessionHandler.StartSession)
+		auth.POST("/sessions/stop", writeRecords, liveSessionHandler.StopSession)
+		auth.POST("/sessions/pause", writeRecords, liveSessionHandler.PauseSession)
+		auth.POST("/sessions/resume", writeRecords, liveSessionHandler.ResumeSession)
+		auth.POST("/sessions/ascents", writeRecords, liveSessionHandler.AddAscent)
+		auth.GET("/sessions/current", readRecords, liveSessionHandler.GetCurrentSession)
+
+		// 指力板/力量训练和力量测试
+		auth.POST("/strength/sessions", writeRecords, strengthHandler.CreateSession)
+		auth.GET("/strength/sessions", readRecords, strengthHandler.GetSessions)
+		auth.GET("/strength/sessions/:id", readRecords, strengthHandler.GetSession)
+		auth.PUT("/strength/sessions/:id", writeRecords, strengthHandler.UpdateSession)
+		auth.DELETE("/strength/sessions/:id", writeRecords, strengthHandler.DeleteSession)
+		auth.POST("/strength/benchmarks", writeRecords, strengthHandler.CreateBenchmark)
+		auth.GET("/strength/benchmarks", readRecords, strengthHandler.GetBenchmarks)
+		auth.DELETE("/strength/benchmarks/:id", writeRecords, strengthHandler.DeleteBenchmark)
+
+		// 跑步、瑜伽、举重等其他训练，以及全部类型活动的统一列表
+		auth.POST("/workouts", writeRecords, activityHandler.CreateWorkout)
+		auth.GET("/workouts", readRecords, activityHandler.GetWorkouts)
+		auth.GET("/workouts/:id", readRecords, activityHandler.GetWorkout)
+		auth.PUT("/workouts/:id", writeRecords, activityHandler.UpdateWorkout)
+		auth.DELETE("/workouts/:id", writeRecords, activityHandler.DeleteWorkout)
+		auth.GET("/activities", readRecords, activityHandler.GetActivities)
+
+		// 移动端离线同步
+		auth.GET("/sync/changes", readRecords, syncHandler.GetChanges)
+		auth.POST("/sync/push", writeRecords, syncHandler.PushChanges)
+
+		// 穿戴设备数据导入
+		auth.POST("/imports/wearable", writeRecords, importHandler.ImportWearable)
 
 		// 分析路由
-		auth.GET("/analysis/climbing", analysisHandler.GetClimbingAnalysis)
+		auth.GET("/analysis/climbing", readRecords, analysisHandler.GetClimbingAnalysis)
+		auth.GET("/analysis/compare", readRecords, analysisHandler.CompareAnalysis)
+		auth.GET("/analysis/series", readRecords, analysisHandler.GetAnalysisSeries)
+		auth.GET("/analysis/sessions/:id/intensity", readRecords, analysisHandler.GetSessionIntensity)
+		auth.GET("/analysis/load", readRecords, analysisHandler.GetTrainingLoad)
+		auth.GET("/analysis/pyramid", readRecords, analysisHandler.GetGradePyramid)
+		auth.GET("/analysis/progression", readRecords, analysisHandler.GetGradeProgression)
+		auth.GET("/analysis/streaks", readRecords, analysisHandler.GetStreaks)
+		auth.GET("/analysis/heatmap", readRecords, analysisHandler.GetCalendarHeatmap)
+		auth.GET("/analysis/distribution", readRecords, analysisHandler.GetActivityDistribution)
+		auth.GET("/analysis/activities", readRecords, activityHandler.GetActivityStats)
+		auth.GET("/analysis/strength/benchmarks", readRecords, strengthHandler.GetBenchmarkTrend)
+		auth.GET("/analysis/strength/volume", readRecords, strengthHandler.GetVolume)
+
+		// 图表图片
+		auth.GET("/charts/:chart", readRecords, chartHandler.GetChart)
+
+		// 月度/年度报告
+		auth.POST("/reports", readRecords, reportHandler.GenerateReport)
+		auth.GET("/reports", readRecords, reportHandler.GetReports)
+		auth.GET("/reports/:id", readRecords, reportHandler.GetReport)
+		auth.GET("/reports/:id/:format", readRecords, reportHandler.DownloadReport)
+
+		// 目标
+		auth.POST("/goals", writeRecords, goalHandler.CreateGoal)
+		auth.GET("/goals", readRecords, goalHandler.GetGoals)
+		auth.GET("/goals/:id", readRecords, goalHandler.GetGoal)
+		auth.PUT("/goals/:id", writeRecords, goalHandler.UpdateGoal)
+		auth.DELETE("/goals/:id", writeRecords, goalHandler.DeleteGoal)
+
+		// 训练计划和模板
+		auth.POST("/plans", writeRecords, trainingPlanHandler.CreatePlan)
+		auth.GET("/plans", readRecords, trainingPlanHandler.GetPlans)
+		auth.GET("/plans/templates", readRecords, trainingPlanHandler.GetTemplates)
+		auth.GET("/plans/:id", readRecords, trainingPlanHandler.GetPlan)
+		auth.PUT("/plans/:id", writeRecords, trainingPlanHandler.UpdatePlan)
+		auth.DELETE("/plans/:id", writeRecords, trainingPlanHandler.DeletePlan)
+		auth.POST("/plans/:id/copy", writeRecords, trainingPlanHandler.CopyPlan)
+		auth.GET("/plans/:id/adherence", readRecords, trainingPlanHandler.GetAdherence)
+		auth.PUT("/plans/:id/sessions/:session_id/complete", writeRecords, trainingPlanHandler.CompleteSession)
 
 		// 用户路由 (个人主页)
 		auth.GET("/profile", userHandler.GetProfile)
 		auth.PUT("/profile", userHandler.UpdateProfile)
-		auth.GET("/profile/stats", userHandler.GetStats)
-		auth.GET("/profile/achievements", userHandler.GetAchievements)
+		auth.GET("/profile/history", historyHandler.GetProfileHistory)
+		auth.POST("/profile/history/:entry_id/restore", historyHandler.RestoreProfile)
+		auth.GET("/profile/stats", readRecords, userHandler.GetStats)
+		auth.GET("/profile/achievements", readRecords, userHandler.GetAchievements)
 		auth.POST("/profile/check-achievements", userHandler.CheckAchievements)
+		auth.GET("/profile/personal-records", readRecords, userHandler.GetPersonalRecords)
+		auth.POST("/profile/personal-records/recalculate", writeRecords, userHandler.RecalculatePersonalRecords)
+
+		// 关注，关注后可以观看对方的实时攀岩
+		auth.POST("/users/:id/follow", followHandler.Follow)
+		auth.DELETE("/users/:id/follow", followHandler.Unfollow)
+		auth.GET("/users/:id/live", readRecords, liveSessionHandler.GetUserLive)
+		auth.GET("/following", followHandler.GetFollowing)
+		auth.GET("/followers", followHandler.GetFollowers)
+		auth.DELETE("/followers/:id", followHandler.RemoveFollower)
+
+		// 我的教练: 接受/拒绝邀请，决定教练可以访问的范围
+		auth.GET("/coaches", coachHandler.GetCoaches)
+		auth.POST("/coaches/:id/accept", coachHandler.AcceptCoach)
+		auth.POST("/coaches/:id/decline", coachHandler.DeclineCoach)
+		auth.PUT("/coaches/:id/scopes", coachHandler.UpdateCoachScopes)
+		auth.DELETE("/coaches/:id", coachHandler.RemoveCoach)
+
+		// 团队/俱乐部: 邀请码加入、团队动态、汇总统计和排行榜
+		auth.POST("/teams", teamHandler.CreateTeam)
+		auth.GET("/teams", teamHandler.GetTeams)
+		auth.POST("/teams/join", teamHandler.JoinTeam)
+		auth.GET("/teams/:id", teamHandler.GetTeam)
+		auth.PUT("/teams/:id", teamHandler.UpdateTeam)
+		auth.DELETE("/teams/:id", teamHandler.DeleteTeam)
+		auth.POST("/teams/:id/invite-code", teamHandler.RegenerateInviteCode)
+		auth.POST("/teams/:id/leave", teamHandler.LeaveTeam)
+		auth.GET("/teams/:id/members", teamHandler.GetMembers)
+		auth.PUT("/teams/:id/members/:user_id/role", teamHandler.SetMemberRole)
+		auth.DELETE("/teams/:id/members/:user_id", teamHandler.RemoveMember)
+		auth.PUT("/teams/:id/leaderboard/opt-out", teamHandler.SetLeaderboardOptOut)
+		auth.PUT("/teams/:id/feed/opt-out", teamHandler.SetFeedOptOut)
+		auth.GET("/teams/:id/feed", readRecords, teamHandler.GetFeed)
+		auth.GET("/teams/:id/stats", readRecords, teamHandler.GetStats)
+		auth.GET("/teams/:id/leaderboard", readRecords, teamHandler.GetLeaderboard)
+
+		auth.GET("/leaderboards", readRecords, leaderboardHandler.GetLeaderboard)
+		auth.GET("/leaderboards/me", readRecords, leaderboardHandler.GetMyRank)
+
+		// 教练: 邀请攀岩者，按攀岩者授予的范围查看数据、布置训练计划
+		coach := auth.Group("/coach", middleware.RequirePermission(models.PermAthletesRead))
+		coach.POST("/athletes", coachHandler.InviteAthlete)
+		coach.GET("/athletes", coachHandler.GetAthletes)
+		coach.DELETE("/athletes/:id", coachHandler.RemoveAthlete)
+		coach.GET("/athletes/:id/records", coachHandler.GetAthleteRecords)
+		coach.GET("/athletes/:id/analysis", coachHandler.GetAthleteAnalysis)
+		coach.POST("/athletes/:id/plans", coachHandler.AssignPlan)
+		coach.GET("/dashboard", coachHandler.GetDashboard)
+	}
+
+	// 实时推送 (SSE)，EventSource 不能设置请求头，允许通过 access_token 查询参数认证
+	stream := router.Group("/api")
+	stream.Use(
+		middleware.StreamAuthMiddleware(),
+		middleware.AccessMiddleware(roleService.UserAccess),
+		middleware.TimeSettingsMiddleware(userService.GetTimeSettings),
+		readRecords,
+	)
+	{
+		stream.GET("/sessions/stream", liveSessionHandler.StreamSession)
+		stream.GET("/users/:id/live/stream", liveSessionHandler.StreamUserLive)
+	}
+
+	// 管理路由，按权限访问，每个请求都记录审计日志
+	admin := router.Group("/api/admin")
+	admin.Use(
+		middleware.AuthMiddleware(),
+		middleware.AccessMiddleware(roleService.UserAccess),
+		middleware.TimeSettingsMiddleware(userService.GetTimeSettings),
+		middleware.AuditMiddleware(adminService.RecordAudit),
+	)
+	{
+		admin.GET("/users", middleware.RequirePermission(models.PermUsersReadAny), adminHandler.ListUsers)
+		admin.GET("/users/:id", middleware.RequirePermission(models.PermUsersReadAny), adminHandler.GetUser)
+		admin.PUT("/users/:id/roles", middleware.RequirePermission(models.PermRolesAssign), adminHandler.SetUserRoles)
+		admin.GET("/users/:id/records", middleware.RequirePermission(models.PermRecordsReadAny), adminHandler.GetUserRecords)
+		admin.GET("/users/:id/stats", middleware.RequirePermission(models.PermRecordsReadAny), adminHandler.GetUserStats)
+
+		admin.GET("/roles", middleware.RequirePermission(models.PermRolesRead), adminHandler.GetRoles)
+		admin.POST("/roles", middleware.RequirePermission(models.PermRolesWrite), adminHandler.CreateRole)
+		admin.PUT("/roles/:id", middleware.RequirePermission(models.PermRolesWrite), adminHandler.UpdateRole)
+		admin.DELETE("/roles/:id", middleware.RequirePermission(models.PermRolesWrite), adminHandler.DeleteRole)
+
+		admin.GET("/audit-logs", middleware.RequirePermission(models.PermAuditRead), adminHandler.GetAuditLogs)
 	}
 
 	// 启动服务器
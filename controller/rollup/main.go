@@ -0,0 +1,53 @@
+// rollup 从攀岩记录重建每日和每月预聚合统计
+//
+// 用法:
+//
+//	go run ./controller/rollup            # 重建全部用户
+//	go run ./controller/rollup -user 42   # 只重建指定用户
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"movePoint/internal/database"
+	"movePoint/internal/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.Uint("user", 0, "只重建指定用户，0 表示全部用户")
+	flag.Parse()
+
+	// 加载环境变量
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	// 初始化数据库
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = "root:123456@tcp(127.0.0.1:3306)/movepoint?charset=utf8mb4&parseTime=True&loc=UTC"
+	}
+	if err := database.InitDB(dsn); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	rollupService := services.NewRollupService(database.DB)
+
+	if *userID != 0 {
+		if err := rollupService.RebuildUser(*userID); err != nil {
+			log.Fatal("Failed to rebuild rollups:", err)
+		}
+		log.Printf("Rebuilt rollups for user %d", *userID)
+		return
+	}
+
+	count, err := rollupService.RebuildAll()
+	log.Printf("Rebuilt rollups for %d users", count)
+	if err != nil {
+		log.Fatal("Failed to rebuild rollups:", err)
+	}
+}
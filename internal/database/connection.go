@@ -7,6 +7,7 @@ import (
 
 	"movePoint/internal/models"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -14,8 +15,19 @@ import (
 var DB *gorm.DB
 
 func InitDB(connectionString string) error {
-	var err error
-	DB, err = gorm.Open(mysql.Open(connectionString), &gorm.Config{})
+	// 时间统一以 UTC 存储，按用户时区的转换在服务层完成；旧配置中的 loc=Local 改为 UTC
+	cfg, err := mysqldriver.ParseDSN(connectionString)
+	if err != nil {
+		return fmt.Errorf("invalid database DSN: %v", err)
+	}
+	if cfg.Loc != time.UTC {
+		log.Printf("Database DSN uses loc=%s, using loc=UTC instead", cfg.Loc)
+		cfg.Loc = time.UTC
+	}
+
+	DB, err = gorm.Open(mysql.Open(cfg.FormatDSN()), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
@@ -35,11 +47,42 @@ func InitDB(connectionString string) error {
 		&models.User{},
 		&models.ClimbingRecord{},
 		&models.ClimbingAnalysis{},
+		&models.HeartRateSeries{},
+		&models.PersonalRecord{},
+		&models.Milestone{},
+		&models.DailyRollup{},
+		&models.MonthlyRollup{},
+		&models.ShareLink{},
+		&models.Report{},
+		&models.Goal{},
+		&models.TrainingPlan{},
+		&models.PlannedSession{},
+		&models.StrengthSession{},
+		&models.StrengthBenchmark{},
+		&models.Workout{},
+		&models.LiveSession{},
+		&models.LiveAscent{},
+		&models.Follow{},
+		&models.SyncState{},
+		&models.SyncCursor{},
+		&models.HistoryEntry{},
+		&models.Role{},
+		&models.AuditLog{},
+		&models.CoachLink{},
+		&models.RecordComment{},
+		&models.Team{},
+		&models.TeamMembership{},
+		&models.LeaderboardScore{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	// 旧版本按服务器本地时区写入的时间转换为 UTC
+	if err := migrateLegacyTimes(DB); err != nil {
+		return fmt.Errorf("failed to convert legacy times: %v", err)
+	}
+
 	log.Println("Database connection established and models migrated")
 	return nil
 }
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"movePoint/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyTimesMigration 已执行的一次性迁移名称
+const legacyTimesMigration = "legacy_local_times_to_utc"
+
+// legacyTimeModels 启用 UTC 存储之前就已存在的表，其中的时间按服务器本地时区 (loc=Local) 写入
+var legacyTimeModels = []interface{}{
+	&models.User{},
+	&models.ClimbingRecord{},
+	&models.ClimbingAnalysis{},
+	&models.HeartRateSeries{},
+	&models.PersonalRecord{},
+	&models.Milestone{},
+}
+
+// legacySkipColumns 表示日历日期而不是时刻的列，不做时区转换
+var legacySkipColumns = map[string]bool{
+	"users.birth_date": true,
+}
+
+// schemaMigration 已执行的一次性数据迁移
+type schemaMigration struct {
+	Name      string `gorm:"primaryKey;type:varchar(64)"`
+	AppliedAt time.Time
+}
+
+// migrateLegacyTimes 将旧版本按服务器本地时区写入的时间转换为 UTC，只执行一次
+//
+// 旧数据的时区由环境变量 DB_LEGACY_TIMEZONE (IANA 名称，如 Asia/Shanghai) 指定，未设置时使用本进程的本地时区，
+// 即旧版本 loc=Local 使用的时区。转换在一个事务中完成，多个实例同时启动时只有一个会执行。
+func migrateLegacyTimes(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	loc := time.Local
+	if name := os.Getenv("DB_LEGACY_TIMEZONE"); name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("invalid DB_LEGACY_TIMEZONE: %v", err)
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 先插入迁移记录: 已执行过或其他实例正在执行 (等待其提交) 时不插入
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&schemaMigration{Name: legacyTimesMigration, AppliedAt: time.Now().UTC()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		for _, model := range legacyTimeModels {
+			table, rows, err := convertLegacyTimes(tx, model, loc)
+			if err != nil {
+				return fmt.Errorf("convert legacy times in %s: %w", table, err)
+			}
+			if rows > 0 {
+				log.Printf("Converted %d rows in %s from %s to UTC", rows, table, loc)
+			}
+		}
+		return nil
+	})
+}
+
+// convertLegacyTimes 将表中 DATETIME 列的时间从 loc 转换为 UTC，返回表名和转换的行数
+//
+// 以字符串读写，不受 DSN 中 loc 参数的影响；夏令时按每个时间各自的偏移转换。
+func convertLegacyTimes(tx *gorm.DB, model interface{}, loc *time.Location) (string, int, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return "", 0, err
+	}
+	table := stmt.Schema.Table
+	primaryKey := stmt.Schema.PrioritizedPrimaryField.DBName
+
+	columnTypes, err := tx.Migrator().ColumnTypes(model)
+	if err != nil {
+		return table, 0, err
+	}
+	var columns []string
+	for _, c := range columnTypes {
+		if strings.EqualFold(c.DatabaseTypeName(), "datetime") && !legacySkipColumns[table+"."+c.Name()] {
+			columns = append(columns, c.Name())
+		}
+	}
+	if len(columns) == 0 || loc == time.UTC {
+		return table, 0, nil
+	}
+
+	selects := []string{primaryKey}
+	for _, c := range columns {
+		selects = append(selects, fmt.Sprintf("CAST(%s AS CHAR) AS %s", c, c))
+	}
+
+	converted := 0
+	var lastID uint
+	for {
+		var batch []map[string]interface{}
+		if err := tx.Table(table).Select(selects).
+			Where(primaryKey+" > ?", lastID).
+			Order(primaryKey).
+			Limit(500).
+			Find(&batch).Error; err != nil {
+			return table, converted, err
+		}
+		if len(batch) == 0 {
+			return table, converted, nil
+		}
+
+		for _, row := range batch {
+			lastID = toUint(row[primaryKey])
+			updates := make(map[string]interface{})
+			for _, c := range columns {
+				value, ok := legacyToUTC(row[c], loc)
+				if ok {
+					updates[c] = value
+				}
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := tx.Table(table).Where(primaryKey+" = ?", lastID).UpdateColumns(updates).Error; err != nil {
+				return table, converted, err
+			}
+			converted++
+		}
+	}
+}
+
+// legacyToUTC 将 loc 时区的 DATETIME 字符串转换为 UTC 字符串，NULL 和零值不转换
+func legacyToUTC(value interface{}, loc *time.Location) (string, bool) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return "", false
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", s, loc)
+	if err != nil || t.Year() <= 1 {
+		return "", false
+	}
+	return t.UTC().Format("2006-01-02 15:04:05.000000"), true
+}
+
+func toUint(value interface{}) uint {
+	switch v := value.(type) {
+	case int64:
+		return uint(v)
+	case uint64:
+		return uint(v)
+	case int32:
+		return uint(v)
+	case uint32:
+		return uint(v)
+	case int:
+		return uint(v)
+	case uint:
+		return v
+	case []byte:
+		var id uint
+		fmt.Sscan(string(v), &id)
+		return id
+	}
+	return 0
+}
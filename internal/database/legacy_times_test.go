@@ -0,0 +1,39 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLegacyToUTC(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		loc    *time.Location
+		want   string
+		wantOK bool
+	}{
+		{"string", "2024-05-01 08:00:00.000", shanghai, "2024-05-01 00:00:00.000000", true},
+		{"bytes without fraction", []byte("2024-05-01 07:30:00"), shanghai, "2024-04-30 23:30:00.000000", true},
+		{"daylight saving time", "2024-07-01 12:00:00.123", newYork, "2024-07-01 16:00:00.123000", true},
+		{"standard time", "2024-01-01 12:00:00", newYork, "2024-01-01 17:00:00.000000", true},
+		{"null", nil, shanghai, "", false},
+		{"zero date", "0000-00-00 00:00:00", shanghai, "", false},
+		{"garbage", "yesterday", shanghai, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := legacyToUTC(tt.value, tt.loc)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("legacyToUTC(%v) = %q, %v; want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"movePoint/internal/models"
+)
+
+// Topic 事件主题
+type Topic string
+
+const (
+	RecordCreated Topic = "record.created" // 攀岩记录创建
+	RecordUpdated Topic = "record.updated" // 攀岩记录更新
+	RecordDeleted Topic = "record.deleted" // 攀岩记录删除
+	RecordPurged  Topic = "record.purged"  // 回收站中的攀岩记录被彻底删除
+
+	PersonalRecordAchieved Topic = "personal_record.achieved" // 刷新个人纪录
+	MilestoneReached       Topic = "milestone.reached"        // 达成里程碑
+	GoalMet                Topic = "goal.met"                 // 完成目标 (重复目标每个周期一次)
+	GoalAtRisk             Topic = "goal.at_risk"             // 目标按当前进度无法按期完成
+
+	ProfileUpdated      Topic = "user.profile_updated"       // 用户修改个人信息
+	TimeSettingsChanged Topic = "user.time_settings_changed" // 用户修改时区或每周起始日
+	RollupsUpdated      Topic = "rollups.updated"            // 用户的预聚合统计已更新
+)
+
+// Event 事件
+type Event struct {
+	Topic      Topic       `json:"topic"`
+	UserID     uint        `json:"user_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Payload    interface{} `json:"payload"`
+}
+
+// RecordChange 攀岩记录变更，创建时 Before 为空，删除时 After 为空
+type RecordChange struct {
+	Before *models.ClimbingRecord `json:"before,omitempty"`
+	After  *models.ClimbingRecord `json:"after,omitempty"`
+	Device string                 `json:"device,omitempty"` // 通过离线同步推送变更的设备，其他途径修改时为空
+	Action string                 `json:"action,omitempty"` // 特殊操作，见 RecordActionUndelete、RecordActionRestore
+}
+
+// 攀岩记录的特殊操作，随 RecordCreated / RecordUpdated 发布
+const (
+	RecordActionUndelete = "undelete" // 从回收站恢复 (RecordCreated)
+	RecordActionRestore  = "restore"  // 恢复到历史版本 (RecordUpdated)
+)
+
+// ProfileChange 个人信息变更
+type ProfileChange struct {
+	Before *models.User `json:"before"`
+	After  *models.User `json:"after"`
+}
+
+// Handler 事件处理函数
+type Handler func(Event) error
+
+// Bus 进程内事件总线，事件按订阅顺序同步分发
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Topic][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Topic][]Handler)}
+}
+
+// Subscribe 订阅事件主题
+func (b *Bus) Subscribe(topic Topic, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish 发布事件，处理函数的错误只记录日志，不影响发布方
+func (b *Bus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[event.Topic]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(event); err != nil {
+			log.Printf("event %s handler failed: %v", event.Topic, err)
+		}
+	}
+}
+
+// Default 全局事件总线
+var Default = NewBus()
+
+// Subscribe 在全局事件总线上订阅
+func Subscribe(topic Topic, handler Handler) {
+	Default.Subscribe(topic, handler)
+}
+
+// Publish 在全局事件总线上发布
+func Publish(event Event) {
+	Default.Publish(event)
+}
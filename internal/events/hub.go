@@ -0,0 +1,77 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	LiveStarted   Topic = "live.started"   // 开始实时攀岩
+	LivePaused    Topic = "live.paused"    // 暂停
+	LiveResumed   Topic = "live.resumed"   // 继续
+	LiveAscent    Topic = "live.ascent"    // 新增一条线路
+	LiveStopped   Topic = "live.stopped"   // 结束
+	LiveAbandoned Topic = "live.abandoned" // 长时间无操作被自动结束
+
+	FollowRemoved Topic = "follow.removed" // 关注关系被删除 (取消关注或被移除)，Payload 为关注者ID
+)
+
+// hubBuffer 每个订阅者的缓冲事件数，消费过慢时丢弃新事件
+const hubBuffer = 16
+
+// Hub 按用户分发实时事件，供 SSE 等长连接推送给用户的其他设备和关注者
+//
+// 与 Bus 不同，Hub 的订阅者是临时的连接，发布不阻塞。
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uint]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uint]map[chan Event]struct{})}
+}
+
+// Subscribe 订阅用户的实时事件，连接断开时必须调用 cancel
+func (h *Hub) Subscribe(userID uint) (<-chan Event, func()) {
+	ch := make(chan Event, hubBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Publish 向订阅了 event.UserID 的连接推送事件
+func (h *Hub) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Live 全局实时事件 Hub
+var Live = NewHub()
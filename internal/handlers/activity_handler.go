@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ActivityHandler struct {
+	service *services.ActivityService
+}
+
+func NewActivityHandler(service *services.ActivityService) *ActivityHandler {
+	return &ActivityHandler{service: service}
+}
+
+// workoutError 将服务层错误转换为响应
+func workoutError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "训练记录不存在"})
+	case errors.Is(err, services.ErrInvalidWorkout):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// CreateWorkout 创建跑步、瑜伽、举重等训练记录
+func (h *ActivityHandler) CreateWorkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var workout models.Workout
+	if err := c.ShouldBindJSON(&workout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.CreateWorkout(userID.(uint), &workout); err != nil {
+		workoutError(c, err, "创建训练记录失败")
+		return
+	}
+	workout.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, workout)
+}
+
+// GetWorkouts 获取训练记录列表，可按 kind 筛选
+func (h *ActivityHandler) GetWorkouts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	from, to, err := parseDateRange(c, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	workouts, total, err := h.service.GetWorkouts(userID.(uint), models.ActivityKind(c.Query("kind")), page, limit, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取训练记录失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range workouts {
+		workouts[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  workouts,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetWorkout 获取单条训练记录
+func (h *ActivityHandler) GetWorkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	workoutID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	workout, err := h.service.GetWorkout(userID.(uint), uint(workoutID))
+	if err != nil {
+		workoutError(c, err, "获取训练记录失败")
+		return
+	}
+	workout.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, workout)
+}
+
+// UpdateWorkout 修改训练记录
+func (h *ActivityHandler) UpdateWorkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	workoutID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	var workout models.Workout
+	if err := c.ShouldBindJSON(&workout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	workout.ID = uint(workoutID)
+	if err := h.service.UpdateWorkout(userID.(uint), &workout); err != nil {
+		workoutError(c, err, "更新训练记录失败")
+		return
+	}
+	workout.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, workout)
+}
+
+// DeleteWorkout 删除训练记录
+func (h *ActivityHandler) DeleteWorkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	workoutID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	if err := h.service.DeleteWorkout(userID.(uint), uint(workoutID)); err != nil {
+		workoutError(c, err, "删除训练记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "训练记录删除成功"})
+}
+
+// GetActivities 获取全部类型活动的统一列表，默认最近三个月，kind 可传多个 (逗号分隔)
+func (h *ActivityHandler) GetActivities(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	var kinds []models.ActivityKind
+	for _, k := range splitQueryList(c.Query("kind")) {
+		kind := models.ActivityKind(k)
+		if !services.ValidActivityKind(kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的活动类型: " + k})
+			return
+		}
+		kinds = append(kinds, kind)
+	}
+
+	list, err := h.service.GetActivities(userID.(uint), from, to, kinds, requestLocation(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活动失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetActivityStats 跨活动类型的时长、热量和负荷统计，group_by=week|month，默认按周
+func (h *ActivityHandler) GetActivityStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", services.ActivityGroupWeek)
+	stats, err := h.service.GetActivityStats(userID.(uint), from, to, requestTimeSettings(c), groupBy)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分组"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活动统计失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
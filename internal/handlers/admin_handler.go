@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService    *services.AdminService
+	roleService     *services.RoleService
+	climbingService *services.ClimbingService
+	userService     *services.UserService
+}
+
+func NewAdminHandler(adminService *services.AdminService, roleService *services.RoleService, climbingService *services.ClimbingService, userService *services.UserService) *AdminHandler {
+	return &AdminHandler{
+		adminService:    adminService,
+		roleService:     roleService,
+		climbingService: climbingService,
+		userService:     userService,
+	}
+}
+
+// setUserRolesRequest 设置用户角色的请求
+type setUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
+
+// ListUsers 分页获取用户，q 按用户名或邮箱搜索
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := h.adminService.ListUsers(page, limit, c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range users {
+		users[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetUser 获取用户信息及其角色
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+	user.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserRoles 替换用户的角色，下一次请求起生效
+func (h *AdminHandler) SetUserRoles(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req setUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	user, err := h.roleService.SetUserRoles(uint(targetID), req.Roles)
+	if err != nil {
+		roleError(c, err, "用户不存在", "设置用户角色失败")
+		return
+	}
+	user.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUserRecords 查看任意用户的攀岩记录
+func (h *AdminHandler) GetUserRecords(c *gin.Context) {
+	user, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	from, to, err := parseDateRange(c, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	records, total, err := h.climbingService.GetUserRecords(user.ID, page, limit, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取记录失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range records {
+		records[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetUserStats 查看任意用户的统计数据，按该用户自己的时间设置统计
+func (h *AdminHandler) GetUserStats(c *gin.Context) {
+	user, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.userService.GetTimeSettings(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户统计数据失败"})
+		return
+	}
+	stats, err := h.userService.GetUserStats(user.ID, settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户统计数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetRoles 获取全部角色
+func (h *AdminHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleService.GetRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取角色失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range roles {
+		roles[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole 创建自定义角色
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	var role models.Role
+	if err := c.ShouldBindJSON(&role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.roleService.CreateRole(&role); err != nil {
+		roleError(c, err, "角色不存在", "创建角色失败")
+		return
+	}
+	role.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole 修改角色，拥有该角色的用户下一次请求起生效
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的角色ID"})
+		return
+	}
+
+	var update models.Role
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(uint(roleID), &update)
+	if err != nil {
+		roleError(c, err, "角色不存在", "更新角色失败")
+		return
+	}
+	role.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole 删除自定义角色
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的角色ID"})
+		return
+	}
+
+	if err := h.roleService.DeleteRole(uint(roleID)); err != nil {
+		roleError(c, err, "角色不存在", "删除角色失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "角色删除成功"})
+}
+
+// GetAuditLogs 分页获取审计日志，可按 actor_id、target_user_id 和 from/to 筛选
+func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	var filter services.AuditLogFilter
+	if actor := c.Query("actor_id"); actor != "" {
+		id, err := strconv.ParseUint(actor, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+			return
+		}
+		filter.ActorID = uint(id)
+	}
+	if target := c.Query("target_user_id"); target != "" {
+		id, err := strconv.ParseUint(target, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+			return
+		}
+		filter.TargetUserID = uint(id)
+	}
+	var err error
+	if filter.From, filter.To, err = parseDateRange(c, time.Time{}, time.Time{}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	logs, total, err := h.adminService.GetAuditLogs(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计日志失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range logs {
+		logs[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  logs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// targetUser 解析路径中的用户ID并读取用户，失败时已写入响应
+func (h *AdminHandler) targetUser(c *gin.Context) (*models.User, bool) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return nil, false
+	}
+
+	user, err := h.adminService.GetUser(uint(targetID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return nil, false
+	}
+	return user, true
+}
+
+// roleError 将角色相关的服务层错误转换为响应
+func roleError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"movePoint/internal/models"
 	"movePoint/internal/services"
+	"movePoint/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,29 +30,343 @@ func (h *AnalysisHandler) GetClimbingAnalysis(c *gin.Context) {
 		return
 	}
 
-	// 解析时间范围参数 (默认最近90天)
-	fromStr := c.DefaultQuery("from", "")
-	toStr := c.DefaultQuery("to", "")
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	filter, err := parseAnalysisFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	var from, to time.Time
+	analysis, err := h.service.GetClimbingAnalysis(userID.(uint), from, to, requestLocation(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分析数据失败"})
+		return
+	}
 
-	if fromStr == "" {
-		from = time.Now().AddDate(0, -3, 0) // 默认3个月前
-	} else {
-		from, _ = time.Parse("2006-01-02", fromStr)
+	c.JSON(http.StatusOK, analysis)
+}
+
+// GetSessionIntensity 获取单次攀岩的心率强度分析
+func (h *AnalysisHandler) GetSessionIntensity(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	// 降采样分辨率 (秒)，默认15秒
+	resolution, err := strconv.Atoi(c.DefaultQuery("resolution", "15"))
+	if err != nil || resolution <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分辨率"})
+		return
+	}
+
+	intensity, err := h.service.GetSessionIntensity(userID.(uint), uint(recordID), time.Duration(resolution)*time.Second)
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "心率数据不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取强度分析失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, intensity)
+}
+
+// maxLoadRangeYears 训练负荷查询的最长范围
+const maxLoadRangeYears = 2
+
+// GetTrainingLoad 获取训练负荷、急慢性负荷比和每日准备度
+func (h *AnalysisHandler) GetTrainingLoad(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+	// 负荷按天展开，限制范围长度避免生成过长的序列
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "开始日期不能晚于结束日期"})
+		return
+	}
+	if to.After(from.AddDate(maxLoadRangeYears, 0, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("时间范围不能超过 %d 年", maxLoadRangeYears)})
+		return
+	}
+
+	// 预警阈值可通过查询参数覆盖
+	thresholds := services.DefaultLoadThresholds()
+	overrides := map[string]*float64{
+		"acwr_high":     &thresholds.ACWRHigh,
+		"acwr_low":      &thresholds.ACWRLow,
+		"monotony_max":  &thresholds.MonotonyMax,
+		"strain_max":    &thresholds.StrainMax,
+		"spike_percent": &thresholds.SpikePercent,
+	}
+	for key, target := range overrides {
+		if v := c.Query(key); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的阈值: " + key})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	loc := requestLocation(c)
+
+	report, err := h.service.GetTrainingLoad(userID.(uint), from, to, loc, thresholds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取训练负荷失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetGradePyramid 获取难度金字塔
+func (h *AnalysisHandler) GetGradePyramid(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	pyramids, err := h.service.GetGradePyramids(userID.(uint), models.ClimbingType(c.Query("type")), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取难度金字塔失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pyramids)
+}
+
+// GetGradeProgression 获取难度进阶分析
+func (h *AnalysisHandler) GetGradeProgression(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
 	}
 
-	if toStr == "" {
-		to = time.Now() // 默认到现在
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	loc := requestLocation(c)
+
+	progressions, err := h.service.GetGradeProgressions(userID.(uint), models.ClimbingType(c.Query("type")), from, to, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取难度进阶失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progressions)
+}
+
+// GetStreaks 获取按天/按周的连续打卡统计
+func (h *AnalysisHandler) GetStreaks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	loc := requestLocation(c)
+
+	var err error
+	opts := services.StreakOptions{WeekStart: requestTimeSettings(c).WeekStart}
+	if opts.RestDays, err = strconv.Atoi(c.DefaultQuery("rest_days", "1")); err != nil || opts.RestDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的休息天数"})
+		return
+	}
+	if opts.MinWeeklySessions, err = strconv.Atoi(c.DefaultQuery("min_weekly_sessions", "1")); err != nil || opts.MinWeeklySessions < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的每周次数"})
+		return
+	}
+
+	streaks, err := h.service.GetStreaks(userID.(uint), loc, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取连续打卡失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, streaks)
+}
+
+// GetCalendarHeatmap 获取年度日历热力图
+func (h *AnalysisHandler) GetCalendarHeatmap(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	loc := requestLocation(c)
+
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().In(loc).Year())))
+	if err != nil || year < 1970 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的年份"})
+		return
+	}
+
+	metric := c.DefaultQuery("metric", services.HeatmapSessions)
+	if metric != services.HeatmapSessions && metric != services.HeatmapMinutes && metric != services.HeatmapVolume {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的指标"})
+		return
+	}
+
+	heatmap, err := h.service.GetCalendarHeatmap(userID.(uint), year, metric, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取热力图失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// GetActivityDistribution 获取星期和时段分布
+func (h *AnalysisHandler) GetActivityDistribution(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	loc := requestLocation(c)
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	distribution, err := h.service.GetActivityDistribution(userID.(uint), from, to, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活动分布失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, distribution)
+}
+
+// GetAnalysisSeries 按维度分组的统计序列
+//
+// group_by 为逗号分隔的维度 (day/week/month/location/type/grade)，最多一个时间维度，默认 month；
+// 支持与 /analysis/climbing 相同的筛选参数。
+func (h *AnalysisHandler) GetAnalysisSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	filter, err := parseAnalysisFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupBy := splitQueryList(c.DefaultQuery("group_by", services.GroupByMonth))
+	if !services.ValidGroupBy(groupBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分组维度"})
+		return
+	}
+
+	series, err := h.service.GetAnalysisSeries(userID.(uint), from, to, requestTimeSettings(c), groupBy, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计序列失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// CompareAnalysis 对比两个时间范围的分析结果
+//
+// preset=week|month|year 对比本周期和上一周期；否则对比 from/to 与 compare_from/compare_to，
+// 未指定对比范围时使用紧邻 from 之前、天数相同的范围。两个范围使用相同的筛选参数。
+func (h *AnalysisHandler) CompareAnalysis(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	filter, err := parseAnalysisFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := requestTimeSettings(c)
+	var current, previous services.AnalysisRange
+	if preset := c.Query("preset"); preset != "" {
+		if current, previous, err = services.PresetRanges(preset, time.Now(), settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的对比预设"})
+			return
+		}
 	} else {
-		to, _ = time.Parse("2006-01-02", toStr)
+		if current.From, current.To, err = parseAnalysisRange(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+			return
+		}
+		previous = services.PrecedingRange(current, settings.Location)
+		if previous.From, previous.To, err = parseDateParams(c, "compare_from", "compare_to", previous.From, previous.To); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+			return
+		}
+	}
+	if current.To.Before(current.From) || previous.To.Before(previous.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "结束日期不能早于开始日期"})
+		return
 	}
 
-	analysis, err := h.service.GetClimbingAnalysis(userID.(uint), from, to)
+	comparison, err := h.service.CompareAnalysis(userID.(uint), current, previous, settings.Location, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分析数据失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取对比分析失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, analysis)
+	c.JSON(http.StatusOK, comparison)
+}
+
+// parseAnalysisRange 在用户时区内解析 from/to 查询参数
+//
+// 默认最近3个月，按整天对齐 (截至今天结束)，以便命中缓存和每日预聚合数据。
+func parseAnalysisRange(c *gin.Context) (time.Time, time.Time, error) {
+	today := utils.StartOfDay(time.Now(), requestLocation(c))
+	return parseDateRange(c, today.AddDate(0, -3, 0), today.AddDate(0, 0, 1).Add(-time.Microsecond))
 }
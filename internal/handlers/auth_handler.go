@@ -5,6 +5,7 @@ import (
 
 	"movePoint/internal/models"
 	"movePoint/internal/services"
+	"movePoint/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -56,3 +57,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// StreamToken 签发短期长连接令牌，供不能设置请求头的 EventSource 通过 access_token 查询参数使用
+func (h *AuthHandler) StreamToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	token, err := utils.GenerateStreamToken(userID.(uint), c.GetString("username"), c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_in": int(utils.StreamTokenTTL.Seconds())})
+}
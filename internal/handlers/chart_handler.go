@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/chart"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 图表类型
+const (
+	chartMonthlyTrend = "monthly-trend"
+	chartGradePyramid = "grade-pyramid"
+	chartHeatmap      = "heatmap"
+	chartAchievement  = "achievement"
+	chartBenchmark    = "benchmark"
+)
+
+// 分享链接默认有效期
+const defaultChartShareTTL = 30 * 24 * time.Hour
+
+// chartTitles 图表标题，PNG 使用的位图字体不支持中文，统一使用英文
+var chartTitles = map[string]map[string]string{
+	chartMonthlyTrend: {"zh": "月度趋势", "en": "Monthly Trend"},
+	chartGradePyramid: {"zh": "难度金字塔", "en": "Grade Pyramid"},
+	chartHeatmap:      {"zh": "攀岩日历", "en": "Climbing Calendar"},
+	chartAchievement:  {"zh": "成就", "en": "Achievement"},
+	chartBenchmark:    {"zh": "力量测试", "en": "Strength Benchmark"},
+}
+
+// benchmarkLabels 力量测试项目标签
+var benchmarkLabels = map[models.BenchmarkKind]map[string]string{
+	models.BenchmarkMaxHang:      {"zh": "最大悬挂 (% 体重)", "en": "Max hang (% BW)"},
+	models.BenchmarkPullUpMax:    {"zh": "引体向上极限 (% 体重)", "en": "Pull-up max (% BW)"},
+	models.BenchmarkCampusLadder: {"zh": "campus 板最高横档", "en": "Campus ladder (top rung)"},
+}
+
+// trendMetrics 月度趋势可选指标及其标签
+var trendMetrics = map[string]map[string]string{
+	"sessions": {"zh": "攀岩次数", "en": "Sessions"},
+	"duration": {"zh": "时长 (分钟)", "en": "Minutes"},
+	"sends":    {"zh": "完成次数", "en": "Sends"},
+	"calories": {"zh": "热量 (千卡)", "en": "Calories (kcal)"},
+}
+
+type ChartHandler struct {
+	analysisService *services.AnalysisService
+	userService     *services.UserService
+	strengthService *services.StrengthService
+	shareService    *services.ShareService
+}
+
+func NewChartHandler(analysisService *services.AnalysisService, userService *services.UserService, strengthService *services.StrengthService, shareService *services.ShareService) *ChartHandler {
+	return &ChartHandler{analysisService: analysisService, userService: userService, strengthService: strengthService, shareService: shareService}
+}
+
+// GetChart 渲染图表图片
+//
+// chart 为 monthly-trend/grade-pyramid/heatmap/achievement/benchmark；format=svg|png，默认 svg；
+// brand=true 显示品牌，share=true 生成分享链接 (显示在图片页脚并通过 X-Share-URL 返回)。
+// 其余参数与对应的分析接口一致，achievement 需要 id 参数，benchmark 需要 kind 参数。
+func (h *ChartHandler) GetChart(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	h.render(c, userID.(uint), c.Param("chart"), "")
+}
+
+// GetSharedChart 通过分享链接访问图表，无需登录
+//
+// 使用生成链接时保存的查询参数和时间设置渲染，数据为访问时的最新数据。
+func (h *ChartHandler) GetSharedChart(c *gin.Context) {
+	link, err := h.shareService.GetShareLink(c.Param("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrShareLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "分享链接无效或已过期"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分享链接失败"})
+		return
+	}
+	if !strings.HasPrefix(link.Resource, "chart:") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分享链接无效或已过期"})
+		return
+	}
+
+	c.Request.URL.RawQuery = link.Query
+	weekStart, _ := strconv.Atoi(c.Query("week_start"))
+	c.Set("timeSettings", utils.NewTimeSettings(c.Query("tz"), weekStart, c.Query("locale")))
+
+	h.render(c, link.UserID, strings.TrimPrefix(link.Resource, "chart:"), publicURL(c, "/api/share/charts/"+link.Code))
+}
+
+// render 生成并输出图表，shareURL 非空表示通过分享链接访问
+func (h *ChartHandler) render(c *gin.Context, userID uint, name, shareURL string) {
+	if _, ok := chartTitles[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "图表不存在"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "svg")
+	if format != "svg" && format != "png" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的图片格式"})
+		return
+	}
+
+	settings := requestTimeSettings(c)
+	lang := "en"
+	if format == "svg" && strings.HasPrefix(settings.Locale, "zh") {
+		lang = "zh"
+	}
+
+	opts := chart.Options{Title: chartTitles[name][lang], ShareURL: shareURL}
+	if brand, _ := strconv.ParseBool(c.Query("brand")); brand {
+		opts.Brand = chartBrand()
+	}
+	if share, _ := strconv.ParseBool(c.Query("share")); share && shareURL == "" {
+		query := c.Request.URL.Query()
+		query.Del("share")
+		query.Set("tz", settings.Location.String())
+		query.Set("week_start", strconv.Itoa(int(settings.WeekStart)))
+		query.Set("locale", settings.Locale)
+
+		link, err := h.shareService.CreateShareLink(userID, "chart:"+name, query.Encode(), chartShareTTL())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成分享链接失败"})
+			return
+		}
+		opts.ShareURL = publicURL(c, "/api/share/charts/"+link.Code)
+		c.Header("X-Share-URL", opts.ShareURL)
+	}
+
+	var canvas *chart.Canvas
+	switch name {
+	case chartMonthlyTrend:
+		canvas = h.monthlyTrend(c, userID, settings, lang, opts)
+	case chartGradePyramid:
+		canvas = h.gradePyramid(c, userID, lang, opts)
+	case chartHeatmap:
+		canvas = h.heatmap(c, userID, settings, opts)
+	case chartAchievement:
+		canvas = h.achievement(c, userID, settings, lang, format, opts)
+	case chartBenchmark:
+		canvas = h.benchmark(c, userID, settings, lang, opts)
+	}
+	if canvas == nil {
+		return
+	}
+
+	body, contentType := canvas.SVG(), "image/svg+xml"
+	if format == "png" {
+		var err error
+		if body, err = canvas.PNG(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成图片失败"})
+			return
+		}
+		contentType = "image/png"
+	}
+
+	// 相同数据生成的图片字节一致，按内容摘要作为 ETag
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if shareURL != "" {
+		c.Header("Cache-Control", "public, max-age=3600")
+	} else {
+		c.Header("Cache-Control", "private, max-age=300")
+	}
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// monthlyTrend 月度趋势柱状图，默认最近 12 个月
+func (h *ChartHandler) monthlyTrend(c *gin.Context, userID uint, settings utils.TimeSettings, lang string, opts chart.Options) *chart.Canvas {
+	metric := c.DefaultQuery("metric", "sessions")
+	if _, ok := trendMetrics[metric]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的指标"})
+		return nil
+	}
+
+	month := utils.StartOfMonth(time.Now(), settings.Location)
+	from, to, err := parseDateRange(c, month.AddDate(0, -11, 0), month.AddDate(0, 1, 0).Add(-time.Microsecond))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return nil
+	}
+
+	filter, err := parseAnalysisFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	series, err := h.analysisService.GetAnalysisSeries(userID, from, to, settings, []string{services.GroupByMonth}, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计序列失败"})
+		return nil
+	}
+
+	bars := make([]chart.Bar, len(series.Points))
+	for i, p := range series.Points {
+		bar := chart.Bar{Label: p.Keys[services.GroupByMonth]}
+		switch metric {
+		case "duration":
+			bar.Value = float64(p.Duration)
+		case "sends":
+			bar.Value = float64(p.Sends)
+		case "calories":
+			bar.Value = p.Calories
+		default:
+			bar.Value = float64(p.Sessions)
+		}
+		bars[i] = bar
+	}
+
+	opts.Subtitle = fmt.Sprintf("%s | %s ~ %s", trendMetrics[metric][lang],
+		series.From.Format("2006-01-02"), series.To.Format("2006-01-02"))
+	return chart.BarChart(bars, opts)
+}
+
+// gradePyramid 难度金字塔，默认抱石
+func (h *ChartHandler) gradePyramid(c *gin.Context, userID uint, lang string, opts chart.Options) *chart.Canvas {
+	climbingType := models.ClimbingType(c.DefaultQuery("type", string(models.Bouldering)))
+	if climbingType != models.Bouldering && climbingType != models.SportClimbing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的攀岩类型"})
+		return nil
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return nil
+	}
+
+	pyramids, err := h.analysisService.GetGradePyramids(userID, climbingType, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取难度金字塔失败"})
+		return nil
+	}
+
+	var levels []chart.PyramidBar
+	if len(pyramids) > 0 {
+		pyramid := pyramids[0]
+		for _, l := range pyramid.Levels {
+			levels = append(levels, chart.PyramidBar{Label: l.Grade, Value: float64(l.Sends), Ideal: float64(l.Ideal)})
+		}
+		if pyramid.TopGrade != "" {
+			label := map[string]string{"zh": "最高难度", "en": "Top grade"}[lang]
+			opts.Subtitle = fmt.Sprintf("%s %s | %s", pyramid.System, label, pyramid.TopGrade)
+		}
+	}
+	return chart.Pyramid(levels, opts)
+}
+
+// heatmap 年度日历热力图
+func (h *ChartHandler) heatmap(c *gin.Context, userID uint, settings utils.TimeSettings, opts chart.Options) *chart.Canvas {
+	loc := settings.Location
+
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().In(loc).Year())))
+	if err != nil || year < 1970 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的年份"})
+		return nil
+	}
+
+	metric := c.DefaultQuery("metric", services.HeatmapSessions)
+	if metric != services.HeatmapSessions && metric != services.HeatmapMinutes && metric != services.HeatmapVolume {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的指标"})
+		return nil
+	}
+
+	heatmap, err := h.analysisService.GetCalendarHeatmap(userID, year, metric, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取热力图失败"})
+		return nil
+	}
+
+	cells := make([]chart.HeatCell, 0, len(heatmap.Days))
+	for _, day := range heatmap.Days {
+		date, err := time.ParseInLocation("2006-01-02", day.Date, loc)
+		if err != nil {
+			continue
+		}
+		cells = append(cells, chart.HeatCell{Date: date, Level: day.Level})
+	}
+
+	opts.Title += " " + strconv.Itoa(year)
+	return chart.Heatmap(cells, settings.WeekStart, opts)
+}
+
+// benchmark 力量测试成绩折线图，默认最近一年
+func (h *ChartHandler) benchmark(c *gin.Context, userID uint, settings utils.TimeSettings, lang string, opts chart.Options) *chart.Canvas {
+	kind := models.BenchmarkKind(c.Query("kind"))
+	if _, ok := benchmarkLabels[kind]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的测试项目"})
+		return nil
+	}
+
+	from, to, filter, err := parseBenchmarkTrendParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	trend, err := h.strengthService.GetBenchmarkTrend(userID, kind, from, to, filter, settings.Location)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取成绩趋势失败"})
+		return nil
+	}
+
+	points := make([]chart.Point, len(trend.Points))
+	for i, p := range trend.Points {
+		points[i] = chart.Point{Label: p.TestedAt.Format("01-02"), Value: p.Score}
+	}
+
+	opts.Subtitle = fmt.Sprintf("%s | %s ~ %s", benchmarkLabels[kind][lang],
+		from.In(settings.Location).Format("2006-01-02"), to.In(settings.Location).Format("2006-01-02"))
+	return chart.LineChart(points, opts)
+}
+
+// achievement 成就徽章卡片，PNG 中使用成就 ID 作为英文标题
+func (h *ChartHandler) achievement(c *gin.Context, userID uint, settings utils.TimeSettings, lang, format string, opts chart.Options) *chart.Canvas {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少成就ID"})
+		return nil
+	}
+
+	achievements, err := h.userService.GetUserAchievements(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户成就失败"})
+		return nil
+	}
+
+	for _, a := range achievements {
+		if a.ID != id {
+			continue
+		}
+
+		badge := chart.Badge{Mark: a.Icon, Title: a.Name, Description: a.Description, Progress: a.Progress, Completed: a.Completed}
+		if format == "png" {
+			badge.Mark = strings.ToUpper(a.ID[:1])
+		}
+		if lang == "en" {
+			words := strings.Fields(strings.ReplaceAll(a.ID, "_", " "))
+			for i, w := range words {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+			badge.Title = strings.Join(words, " ")
+			badge.Description = ""
+		}
+		switch {
+		case a.Completed && lang == "zh":
+			badge.Status = "已解锁 " + a.UnlockedAt.In(settings.Location).Format("2006-01-02")
+		case a.Completed:
+			badge.Status = "Unlocked " + a.UnlockedAt.In(settings.Location).Format("2006-01-02")
+		case lang == "zh":
+			badge.Status = fmt.Sprintf("进度 %.0f%%", a.Progress)
+		default:
+			badge.Status = fmt.Sprintf("Progress %.0f%%", a.Progress)
+		}
+		return chart.BadgeCard(badge, opts)
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "成就不存在"})
+	return nil
+}
+
+// chartBrand 图表品牌名称，可通过环境变量 CHART_BRAND 覆盖
+func chartBrand() string {
+	if brand := os.Getenv("CHART_BRAND"); brand != "" {
+		return brand
+	}
+	return "movePoint"
+}
+
+// chartShareTTL 分享链接有效期，可通过环境变量 CHART_SHARE_TTL 覆盖 (如 "168h")
+func chartShareTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("CHART_SHARE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultChartShareTTL
+}
+
+// publicURL 对外访问地址，优先使用环境变量 PUBLIC_BASE_URL
+func publicURL(c *gin.Context, path string) string {
+	if base := os.Getenv("PUBLIC_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/") + path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
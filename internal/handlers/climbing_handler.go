@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"movePoint/internal/models"
 	"movePoint/internal/services"
+	"movePoint/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,9 +38,10 @@ func (h *ClimbingHandler) CreateRecord(c *gin.Context) {
 	}
 
 	if err := h.service.CreateRecord(userID.(uint), &record); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建记录失败"})
+		recordError(c, err, "创建记录失败")
 		return
 	}
+	record.LocalizeTimes(requestLocation(c))
 
 	c.JSON(http.StatusCreated, record)
 }
@@ -53,13 +58,11 @@ func (h *ClimbingHandler) GetRecords(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	// 解析时间范围参数
-	var from, to time.Time
-	if fromStr := c.Query("from"); fromStr != "" {
-		from, _ = time.Parse("2006-01-02", fromStr)
-	}
-	if toStr := c.Query("to"); toStr != "" {
-		to, _ = time.Parse("2006-01-02", toStr)
+	// 解析时间范围参数 (按用户时区)
+	from, to, err := parseDateRange(c, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
 	}
 
 	records, total, err := h.service.GetUserRecords(userID.(uint), page, limit, from, to)
@@ -67,6 +70,10 @@ func (h *ClimbingHandler) GetRecords(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取记录失败"})
 		return
 	}
+	loc := requestLocation(c)
+	for i := range records {
+		records[i].LocalizeTimes(loc)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":  records,
@@ -96,10 +103,18 @@ func (h *ClimbingHandler) GetRecord(c *gin.Context) {
 		return
 	}
 
+	etag := recordETag(record)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	record.LocalizeTimes(requestLocation(c))
+
 	c.JSON(http.StatusOK, record)
 }
 
-// UpdateRecord 更新记录
+// UpdateRecord 整体更新记录，未提供的可编辑字段置为零值；提供 If-Match 时版本不一致返回 412
 func (h *ClimbingHandler) UpdateRecord(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -113,6 +128,12 @@ func (h *ClimbingHandler) UpdateRecord(c *gin.Context) {
 		return
 	}
 
+	expected, ok := ifMatchVersion(c, uint(recordID))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "记录已被修改，请获取最新版本后重试"})
+		return
+	}
+
 	var record models.ClimbingRecord
 	if err := c.ShouldBindJSON(&record); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
@@ -120,15 +141,64 @@ func (h *ClimbingHandler) UpdateRecord(c *gin.Context) {
 	}
 
 	record.ID = uint(recordID)
-	if err := h.service.UpdateRecord(userID.(uint), &record); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新记录失败"})
+	if err := h.service.UpdateRecord(userID.(uint), &record, expected); err != nil {
+		recordError(c, err, "更新记录失败")
+		return
+	}
+	record.LocalizeTimes(requestLocation(c))
+
+	c.Header("ETag", recordETag(&record))
+	c.JSON(http.StatusOK, record)
+}
+
+// PatchRecord 按 JSON Merge Patch (RFC 7396) 修改记录，null 将字段恢复为零值；提供 If-Match 时版本不一致返回 412
+func (h *ClimbingHandler) PatchRecord(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
 		return
 	}
 
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "请使用 application/merge-patch+json"})
+		return
+	}
+
+	expected, ok := ifMatchVersion(c, uint(recordID))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "记录已被修改，请获取最新版本后重试"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	patch, err := utils.ParseMergePatch(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	record, err := h.service.PatchRecord(userID.(uint), uint(recordID), patch, expected)
+	if err != nil {
+		recordError(c, err, "更新记录失败")
+		return
+	}
+	record.LocalizeTimes(requestLocation(c))
+
+	c.Header("ETag", recordETag(record))
 	c.JSON(http.StatusOK, record)
 }
 
-// DeleteRecord 删除记录
+// DeleteRecord 删除记录，提供 If-Match 时版本不一致返回 412
 func (h *ClimbingHandler) DeleteRecord(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -142,10 +212,116 @@ func (h *ClimbingHandler) DeleteRecord(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteRecord(userID.(uint), uint(recordID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除记录失败"})
+	expected, ok := ifMatchVersion(c, uint(recordID))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "记录已被修改，请获取最新版本后重试"})
+		return
+	}
+
+	if err := h.service.DeleteRecord(userID.(uint), uint(recordID), expected); err != nil {
+		recordError(c, err, "删除记录失败")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "记录删除成功"})
 }
+
+// GetTrash 获取回收站中的记录，超过保留期 (purge_at) 后将被彻底删除
+func (h *ClimbingHandler) GetTrash(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	records, total, err := h.service.GetTrash(userID.(uint), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取回收站失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range records {
+		records[i].LocalizeTimes(loc)
+		records[i].PurgeAt = records[i].PurgeAt.In(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// UndeleteRecord 从回收站恢复记录
+func (h *ClimbingHandler) UndeleteRecord(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	record, err := h.service.UndeleteRecord(userID.(uint), uint(recordID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "回收站中没有该记录"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复记录失败"})
+		return
+	}
+	record.LocalizeTimes(requestLocation(c))
+
+	c.Header("ETag", recordETag(record))
+	c.JSON(http.StatusOK, record)
+}
+
+// recordError 将服务层错误转换为响应
+func recordError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+	case errors.Is(err, services.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "记录已被修改，请获取最新版本后重试"})
+	case errors.Is(err, services.ErrInvalidRecord):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// recordETag 记录的实体标签，格式为 "<记录ID>-<版本>"
+func recordETag(record *models.ClimbingRecord) string {
+	return fmt.Sprintf(`"%d-%d"`, record.ID, record.Version)
+}
+
+// ifMatchVersion 解析 If-Match 请求头中该记录的版本，未提供或为 * 时返回 nil；
+// 没有属于该记录的有效标签时 ok 为 false，应返回 412
+func ifMatchVersion(c *gin.Context, recordID uint) (*uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), `"`)
+		id, version, found := strings.Cut(tag, "-")
+		if !found || id != strconv.FormatUint(uint64(recordID), 10) {
+			continue
+		}
+		if v, err := strconv.ParseUint(version, 10, 0); err == nil {
+			expected := uint(v)
+			return &expected, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoachHandler struct {
+	service *services.CoachService
+}
+
+func NewCoachHandler(service *services.CoachService) *CoachHandler {
+	return &CoachHandler{service: service}
+}
+
+// InviteRequest 教练邀请攀岩者的请求
+type InviteRequest struct {
+	AthleteID uint     `json:"athlete_id" binding:"required"`
+	Scopes    []string `json:"scopes"`
+	Message   string   `json:"message"`
+}
+
+// ScopesRequest 接受邀请或修改访问范围的请求
+type ScopesRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+// CommentRequest 评论攀岩记录的请求
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// coachError 将服务层错误转换为响应
+func coachError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrScopeDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "攀岩者未授予该访问权限"})
+	case errors.Is(err, services.ErrInvalidCoachLink), errors.Is(err, services.ErrInvalidPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// InviteAthlete 教练邀请攀岩者，攀岩者接受后按其授予的范围访问数据
+func (h *CoachHandler) InviteAthlete(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	link, err := h.service.Invite(userID.(uint), req.AthleteID, req.Scopes, req.Message)
+	if err != nil {
+		coachError(c, err, "用户不存在", "邀请失败")
+		return
+	}
+	link.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// GetAthletes 获取我的攀岩者和尚未接受的邀请
+func (h *CoachHandler) GetAthletes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	links, err := h.service.GetAthletes(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取攀岩者列表失败"})
+		return
+	}
+	localizeCoachLinks(links, c)
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RemoveAthlete 教练解除与攀岩者的关系或撤回邀请
+func (h *CoachHandler) RemoveAthlete(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	athleteID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.EndLink(userID.(uint), athleteID); err != nil {
+		coachError(c, err, "没有与该用户的教练关系", "解除教练关系失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除教练关系"})
+}
+
+// GetDashboard 教练看板，汇总攀岩者在 from/to 范围内 (默认最近三个月) 的分析数据
+func (h *CoachHandler) GetDashboard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	loc := requestLocation(c)
+	dashboard, err := h.service.GetDashboard(userID.(uint), from, to, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取教练看板失败"})
+		return
+	}
+	dashboard.From = dashboard.From.In(loc)
+	dashboard.To = dashboard.To.In(loc)
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// GetAthleteRecords 查看攀岩者的攀岩记录，需要 records:read
+func (h *CoachHandler) GetAthleteRecords(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	athleteID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	from, to, err := parseDateRange(c, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	records, total, err := h.service.GetAthleteRecords(userID.(uint), athleteID, page, limit, from, to)
+	if err != nil {
+		coachError(c, err, "用户不存在", "获取记录失败")
+		return
+	}
+	loc := requestLocation(c)
+	for i := range records {
+		records[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetAthleteAnalysis 查看攀岩者的攀岩分析，需要 analysis:read；筛选参数同 /analysis/climbing
+func (h *CoachHandler) GetAthleteAnalysis(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	athleteID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+	filter, err := parseAnalysisFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analysis, err := h.service.GetAthleteAnalysis(userID.(uint), athleteID, from, to, requestLocation(c), filter)
+	if err != nil {
+		coachError(c, err, "用户不存在", "获取分析数据失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
+
+// AssignPlan 为攀岩者布置训练计划，需要 plans:assign；开始日期按攀岩者时区的同一天开始
+func (h *CoachHandler) AssignPlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	athleteID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	plan, ok := bindPlan(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.AssignPlan(userID.(uint), athleteID, plan)
+	if err != nil {
+		coachError(c, err, "用户不存在", "布置训练计划失败")
+		return
+	}
+	result.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetCoaches 获取我的教练和待处理的邀请
+func (h *CoachHandler) GetCoaches(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	links, err := h.service.GetCoaches(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取教练列表失败"})
+		return
+	}
+	localizeCoachLinks(links, c)
+
+	c.JSON(http.StatusOK, links)
+}
+
+// AcceptCoach 接受教练的邀请，未提供 scopes 时授予教练申请的全部范围
+func (h *CoachHandler) AcceptCoach(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	coachID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ScopesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+			return
+		}
+	}
+
+	link, err := h.service.Accept(userID.(uint), coachID, req.Scopes)
+	if err != nil {
+		coachError(c, err, "没有该教练的邀请", "接受邀请失败")
+		return
+	}
+	link.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, link)
+}
+
+// DeclineCoach 拒绝教练的邀请
+func (h *CoachHandler) DeclineCoach(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	coachID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Decline(userID.(uint), coachID); err != nil {
+		coachError(c, err, "没有该教练的邀请", "拒绝邀请失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已拒绝邀请"})
+}
+
+// UpdateCoachScopes 修改授予教练的访问范围，立即生效
+func (h *CoachHandler) UpdateCoachScopes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	coachID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Scopes == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	link, err := h.service.UpdateScopes(userID.(uint), coachID, req.Scopes)
+	if err != nil {
+		coachError(c, err, "该用户不是你的教练", "修改访问范围失败")
+		return
+	}
+	link.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, link)
+}
+
+// RemoveCoach 解除与教练的关系
+func (h *CoachHandler) RemoveCoach(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	coachID, ok := pathUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.EndLink(coachID, userID.(uint)); err != nil {
+		coachError(c, err, "该用户不是你的教练", "解除教练关系失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除教练关系"})
+}
+
+// GetComments 获取记录的评论
+func (h *CoachHandler) GetComments(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	comments, err := h.service.GetComments(userID.(uint), uint(recordID))
+	if err != nil {
+		coachError(c, err, "记录不存在", "获取评论失败")
+		return
+	}
+	loc := requestLocation(c)
+	for i := range comments {
+		comments[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// AddComment 评论攀岩记录
+func (h *CoachHandler) AddComment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	comment, err := h.service.AddComment(userID.(uint), uint(recordID), req.Body)
+	if err != nil {
+		coachError(c, err, "记录不存在", "评论失败")
+		return
+	}
+	comment.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment 删除评论
+func (h *CoachHandler) DeleteComment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的评论ID"})
+		return
+	}
+
+	if err := h.service.DeleteComment(userID.(uint), uint(recordID), uint(commentID)); err != nil {
+		coachError(c, err, "评论不存在", "删除评论失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "评论删除成功"})
+}
+
+// pathUserID 解析路径中的用户ID，失败时已写入错误响应
+func pathUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func localizeCoachLinks(links []services.CoachLinkView, c *gin.Context) {
+	loc := requestLocation(c)
+	for i := range links {
+		links[i].LocalizeTimes(loc)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowHandler struct {
+	service *services.FollowService
+}
+
+func NewFollowHandler(service *services.FollowService) *FollowHandler {
+	return &FollowHandler{service: service}
+}
+
+// Follow 关注用户
+func (h *FollowHandler) Follow(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	followeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.service.Follow(userID.(uint), uint(followeeID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		case errors.Is(err, services.ErrInvalidFollow):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "关注失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "关注成功"})
+}
+
+// Unfollow 取消关注
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	followeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.service.Unfollow(userID.(uint), uint(followeeID)); err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "未关注该用户"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消关注失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "取消关注成功"})
+}
+
+// RemoveFollower 移除关注者
+func (h *FollowHandler) RemoveFollower(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	followerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.service.RemoveFollower(userID.(uint), uint(followerID)); err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "该用户不是你的关注者"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除关注者失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移除关注者成功"})
+}
+
+// GetFollowing 获取我关注的人
+func (h *FollowHandler) GetFollowing(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	users, err := h.service.GetFollowing(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关注列表失败"})
+		return
+	}
+	localizeFollowUsers(users, c)
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetFollowers 获取我的关注者
+func (h *FollowHandler) GetFollowers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	users, err := h.service.GetFollowers(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关注者失败"})
+		return
+	}
+	localizeFollowUsers(users, c)
+
+	c.JSON(http.StatusOK, users)
+}
+
+func localizeFollowUsers(users []services.FollowUser, c *gin.Context) {
+	loc := requestLocation(c)
+	for i := range users {
+		users[i].FollowedAt = users[i].FollowedAt.In(loc)
+	}
+}
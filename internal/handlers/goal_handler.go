@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GoalHandler struct {
+	service *services.GoalService
+}
+
+func NewGoalHandler(service *services.GoalService) *GoalHandler {
+	return &GoalHandler{service: service}
+}
+
+// GoalRequest 创建或修改目标的请求，日期为 YYYY-MM-DD (用户时区) 或 RFC 3339
+type GoalRequest struct {
+	Title       string                `json:"title"`
+	Metric      models.GoalMetric     `json:"metric"`
+	Target      float64               `json:"target"`
+	TargetGrade string                `json:"target_grade"`
+	Type        models.ClimbingType   `json:"type"`
+	Recurrence  models.GoalRecurrence `json:"recurrence"`
+	StartDate   string                `json:"start_date"`
+	Deadline    string                `json:"deadline"` // 截止日期当天全天有效
+	Archived    bool                  `json:"archived"`
+}
+
+// bindGoal 解析请求体为目标，失败时已写入错误响应
+func bindGoal(c *gin.Context) (*models.Goal, bool) {
+	var req GoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return nil, false
+	}
+
+	goal := &models.Goal{
+		Title:       req.Title,
+		Metric:      req.Metric,
+		Target:      req.Target,
+		TargetGrade: req.TargetGrade,
+		Type:        req.Type,
+		Recurrence:  req.Recurrence,
+		Archived:    req.Archived,
+	}
+
+	loc := requestLocation(c)
+	if req.StartDate != "" {
+		start, err := utils.ParseDate(req.StartDate, loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始日期"})
+			return nil, false
+		}
+		goal.StartDate = start
+	}
+	if req.Deadline != "" {
+		deadline, err := utils.ParseDate(req.Deadline, loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的截止日期"})
+			return nil, false
+		}
+		goal.Deadline = &deadline
+	}
+	return goal, true
+}
+
+// CreateGoal 创建目标
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	goal, ok := bindGoal(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateGoal(userID.(uint), goal); err != nil {
+		if errors.Is(err, services.ErrInvalidGoal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建目标失败"})
+		return
+	}
+
+	progress, err := h.service.GetGoal(userID.(uint), goal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取目标进度失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, progress)
+}
+
+// GetGoals 获取目标列表及进度，archived=true 时包含已归档的目标
+func (h *GoalHandler) GetGoals(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	includeArchived, _ := strconv.ParseBool(c.DefaultQuery("archived", "false"))
+	goals, err := h.service.ListGoals(userID.(uint), includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取目标失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, goals)
+}
+
+// GetGoal 获取单个目标及进度
+func (h *GoalHandler) GetGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	progress, err := h.service.GetGoal(userID.(uint), uint(goalID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "目标不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取目标失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// UpdateGoal 修改目标
+func (h *GoalHandler) UpdateGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	goal, ok := bindGoal(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UpdateGoal(userID.(uint), uint(goalID), goal); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "目标不存在"})
+		case errors.Is(err, services.ErrInvalidGoal):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新目标失败"})
+		}
+		return
+	}
+
+	progress, err := h.service.GetGoal(userID.(uint), uint(goalID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取目标进度失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// DeleteGoal 删除目标
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标ID"})
+		return
+	}
+
+	if err := h.service.DeleteGoal(userID.(uint), uint(goalID)); err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "目标不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除目标失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "目标删除成功"})
+}
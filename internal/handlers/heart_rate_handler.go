@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HeartRateHandler struct {
+	service *services.HeartRateService
+}
+
+func NewHeartRateHandler(service *services.HeartRateService) *HeartRateHandler {
+	return &HeartRateHandler{service: service}
+}
+
+// heartRateRequest 上传心率序列请求
+type heartRateRequest struct {
+	Samples []models.HeartRatePoint `json:"samples" binding:"required"`
+}
+
+// SaveHeartRate 上传 (覆盖) 攀岩记录的心率序列
+func (h *HeartRateHandler) SaveHeartRate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	var req heartRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	series, err := h.service.SaveSeries(userID.(uint), uint(recordID), req.Samples)
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存心率数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetHeartRate 获取攀岩记录的原始心率序列
+func (h *HeartRateHandler) GetHeartRate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	series, points, err := h.service.GetSeries(userID.(uint), uint(recordID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "心率数据不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取心率数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"samples": points,
+	})
+}
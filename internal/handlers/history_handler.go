@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HistoryHandler struct {
+	service *services.HistoryService
+}
+
+func NewHistoryHandler(service *services.HistoryService) *HistoryHandler {
+	return &HistoryHandler{service: service}
+}
+
+// GetRecordHistory 获取记录的变更历史 (含字段差异)
+func (h *HistoryHandler) GetRecordHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	entries, err := h.service.GetRecordHistory(userID.(uint), uint(recordID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取历史记录失败"})
+		return
+	}
+	localizeHistory(entries, c)
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// RestoreRecord 将记录恢复到某条历史记录对应的版本，提供 If-Match 时版本不一致返回 412
+func (h *HistoryHandler) RestoreRecord(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的历史记录ID"})
+		return
+	}
+
+	expected, ok := ifMatchVersion(c, uint(recordID))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "记录已被修改，请获取最新版本后重试"})
+		return
+	}
+
+	record, err := h.service.RestoreRecord(userID.(uint), uint(recordID), uint(entryID), expected)
+	if err != nil {
+		recordError(c, err, "恢复记录失败")
+		return
+	}
+	record.LocalizeTimes(requestLocation(c))
+
+	c.Header("ETag", recordETag(record))
+	c.JSON(http.StatusOK, record)
+}
+
+// GetProfileHistory 获取个人信息的变更历史
+func (h *HistoryHandler) GetProfileHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	entries, err := h.service.GetProfileHistory(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取历史记录失败"})
+		return
+	}
+	localizeHistory(entries, c)
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// RestoreProfile 将个人信息恢复到某条历史记录对应的版本
+func (h *HistoryHandler) RestoreProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的历史记录ID"})
+		return
+	}
+
+	if err := h.service.RestoreProfile(userID.(uint), uint(entryID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "历史记录不存在"})
+		case errors.Is(err, services.ErrInvalidProfile):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复个人信息失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "个人信息已恢复"})
+}
+
+func localizeHistory(entries []models.HistoryEntry, c *gin.Context) {
+	loc := requestLocation(c)
+	for i := range entries {
+		entries[i].LocalizeTimes(loc)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/wearable"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	service *services.ImportService
+}
+
+func NewImportHandler(service *services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportWearable 导入穿戴设备训练文件 (FIT / TCX / Apple 健康 export.xml 或 export.zip)
+func (h *ImportHandler) ImportWearable(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传训练文件"})
+		return
+	}
+
+	// 优先使用显式指定的格式，否则根据扩展名判断
+	var format wearable.Format
+	if formatStr := c.Query("format"); formatStr != "" {
+		format, err = wearable.ParseFormat(formatStr)
+	} else {
+		format, err = wearable.DetectFormat(fileHeader.Filename)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的文件格式"})
+		return
+	}
+
+	// 新建记录的默认攀岩类型，设备标记为抱石的训练除外
+	climbingType := models.ClimbingType(c.DefaultQuery("type", string(models.Bouldering)))
+	if climbingType != models.Bouldering && climbingType != models.SportClimbing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的攀岩类型"})
+		return
+	}
+
+	opts := wearable.ParseOptions{}
+	opts.AssumeClimbing, _ = strconv.ParseBool(c.DefaultQuery("assume_climbing", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+	defer file.Close()
+
+	var workouts []wearable.Workout
+	if format == wearable.FormatAppleHealthZip {
+		workouts, err = wearable.ParseAppleHealthZip(file, fileHeader.Size, opts)
+	} else {
+		workouts, err = wearable.Parse(format, file, opts)
+	}
+	if err != nil {
+		if errors.Is(err, wearable.ErrAppleExportNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "压缩包中没有 export.xml"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "解析训练文件失败", "details": err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportWorkouts(userID.(uint), workouts, services.ImportOptions{
+		DefaultType: climbingType,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入训练数据失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range result.Items {
+		result.Items[i].StartTime = result.Items[i].StartTime.In(loc)
+		result.Items[i].EndTime = result.Items[i].EndTime.In(loc)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
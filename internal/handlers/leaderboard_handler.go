@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderboardHandler struct {
+	service *services.LeaderboardService
+}
+
+func NewLeaderboardHandler(service *services.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{service: service}
+}
+
+// leaderboardError 将服务层错误转换为响应
+func leaderboardError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrInvalidLeaderboard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// leaderboardQuery 解析排行榜查询参数，metric 默认 sessions，period 默认 week，scope 默认 global
+func leaderboardQuery(c *gin.Context) services.LeaderboardQuery {
+	teamID, _ := strconv.Atoi(c.Query("team_id"))
+	return services.LeaderboardQuery{
+		Metric:   c.DefaultQuery("metric", services.MetricSessions),
+		Period:   c.DefaultQuery("period", services.PeriodWeek),
+		Key:      c.Query("key"),
+		Scope:    c.DefaultQuery("scope", services.ScopeGlobal),
+		Location: c.Query("location"),
+		TeamID:   uint(teamID),
+	}
+}
+
+// localizeLeaderboard 将达到成绩的时间转换到 loc 时区
+func localizeLeaderboard(board *services.Leaderboard, loc *time.Location) {
+	for i := range board.Entries {
+		board.Entries[i].AchievedAt = board.Entries[i].AchievedAt.In(loc)
+	}
+}
+
+// GetLeaderboard 获取排行榜，around=me 时返回以当前用户为中心的一页
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	q := leaderboardQuery(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+
+	var board *services.Leaderboard
+	var err error
+	switch c.Query("around") {
+	case "":
+		board, err = h.service.GetLeaderboard(userID.(uint), q, requestTimeSettings(c), (page-1)*limit, limit)
+	case "me":
+		board, err = h.service.GetAround(userID.(uint), q, requestTimeSettings(c), limit)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "around 只支持 me"})
+		return
+	}
+	if err != nil {
+		leaderboardError(c, err, "你还没有上榜或团队不存在", "获取排行榜失败")
+		return
+	}
+	localizeLeaderboard(board, requestLocation(c))
+
+	c.JSON(http.StatusOK, board)
+}
+
+// GetMyRank 获取当前用户在排行榜上的名次
+func (h *LeaderboardHandler) GetMyRank(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	q := leaderboardQuery(c)
+	entry, total, err := h.service.GetRank(userID.(uint), userID.(uint), q, requestTimeSettings(c))
+	if err != nil {
+		leaderboardError(c, err, "你还没有上榜或团队不存在", "获取排名失败")
+		return
+	}
+	entry.AchievedAt = entry.AchievedAt.In(requestLocation(c))
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry": entry,
+		"total": total,
+	})
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// liveKeepAlive 实时推送连接的心跳间隔，避免代理关闭空闲连接
+const liveKeepAlive = 25 * time.Second
+
+type LiveSessionHandler struct {
+	service *services.LiveSessionService
+	hub     *events.Hub
+}
+
+func NewLiveSessionHandler(service *services.LiveSessionService, hub *events.Hub) *LiveSessionHandler {
+	return &LiveSessionHandler{service: service, hub: hub}
+}
+
+// liveError 将服务层错误转换为响应
+func liveError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNoLiveSession):
+		c.JSON(http.StatusNotFound, gin.H{"error": "当前没有进行中的实时攀岩"})
+	case errors.Is(err, services.ErrLiveSessionActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "已有进行中的实时攀岩"})
+	case errors.Is(err, services.ErrInvalidLiveSession):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// StartSession 开始实时攀岩
+func (h *LiveSessionHandler) StartSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var req services.LiveSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	state, err := h.service.Start(userID.(uint), req)
+	if err != nil {
+		liveError(c, err, "开始实时攀岩失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, state.In(requestLocation(c)))
+}
+
+// StopSession 结束实时攀岩，已记录的线路转为攀岩记录
+func (h *LiveSessionHandler) StopSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	state, err := h.service.Stop(userID.(uint))
+	if err != nil {
+		liveError(c, err, "结束实时攀岩失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, state.In(requestLocation(c)))
+}
+
+// PauseSession 暂停实时攀岩
+func (h *LiveSessionHandler) PauseSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	state, err := h.service.Pause(userID.(uint))
+	if err != nil {
+		liveError(c, err, "暂停实时攀岩失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, state.In(requestLocation(c)))
+}
+
+// ResumeSession 继续实时攀岩
+func (h *LiveSessionHandler) ResumeSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	state, err := h.service.Resume(userID.(uint))
+	if err != nil {
+		liveError(c, err, "继续实时攀岩失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, state.In(requestLocation(c)))
+}
+
+// AddAscent 在实时攀岩中记录一条线路
+func (h *LiveSessionHandler) AddAscent(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var ascent models.LiveAscent
+	if err := c.ShouldBindJSON(&ascent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	state, err := h.service.AddAscent(userID.(uint), &ascent)
+	if err != nil {
+		liveError(c, err, "记录线路失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, state.In(requestLocation(c)))
+}
+
+// GetCurrentSession 获取进行中的实时攀岩
+func (h *LiveSessionHandler) GetCurrentSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	state, err := h.service.GetCurrent(userID.(uint))
+	if err != nil {
+		liveError(c, err, "获取实时攀岩失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, state.In(requestLocation(c)))
+}
+
+// StreamSession 通过 SSE 推送本人实时攀岩的状态变化，用于同步其他设备
+func (h *LiveSessionHandler) StreamSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	h.stream(c, userID.(uint), userID.(uint))
+}
+
+// GetUserLive 关注者查看用户进行中的实时攀岩
+func (h *LiveSessionHandler) GetUserLive(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	ownerID, ok := h.watchable(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	state, err := h.service.GetCurrent(ownerID)
+	if err == nil && !state.Session.ShareLive && ownerID != userID.(uint) {
+		err = services.ErrNoLiveSession
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrNoLiveSession) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "该用户当前没有实时攀岩"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取实时攀岩失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, state.In(requestLocation(c)))
+}
+
+// StreamUserLive 通过 SSE 向关注者推送用户的实时攀岩
+func (h *LiveSessionHandler) StreamUserLive(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	ownerID, ok := h.watchable(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	h.stream(c, ownerID, userID.(uint))
+}
+
+// watchable 解析路径中的用户ID并检查当前用户是否可以观看，不可观看时已写入响应
+func (h *LiveSessionHandler) watchable(c *gin.Context, viewerID uint) (uint, bool) {
+	ownerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, false
+	}
+
+	allowed, err := h.service.CanWatch(viewerID, uint(ownerID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取实时攀岩失败"})
+		return 0, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有关注者可以观看实时攀岩"})
+		return 0, false
+	}
+	return uint(ownerID), true
+}
+
+// stream 推送 ownerID 的实时攀岩: 先发送当前状态 (state)，之后转发每次变化，事件名为主题名
+//
+// 观看者不是本人时，未开启 share_live 的实时攀岩不推送；关注关系被删除后重新检查权限，不能再观看时断开连接。
+func (h *LiveSessionHandler) stream(c *gin.Context, ownerID, viewerID uint) {
+	// 先订阅再读取当前状态，避免丢失两者之间的变化
+	ch, cancel := h.hub.Subscribe(ownerID)
+	defer cancel()
+
+	visible := func(state services.LiveSessionState) bool {
+		return ownerID == viewerID || state.Session.ShareLive
+	}
+	loc := requestLocation(c)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	state, err := h.service.GetCurrent(ownerID)
+	switch {
+	case err == nil && visible(*state):
+		c.SSEvent("state", state.In(loc))
+	case err == nil || errors.Is(err, services.ErrNoLiveSession):
+		c.SSEvent("state", nil)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取实时攀岩失败"})
+		return
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			if event.Topic == events.FollowRemoved {
+				allowed, err := h.service.CanWatch(viewerID, ownerID)
+				return err == nil && allowed
+			}
+			state, isState := event.Payload.(services.LiveSessionState)
+			if isState && visible(state) {
+				c.SSEvent(string(event.Topic), state.In(loc))
+			}
+			return true
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/models"
+	"movePoint/internal/reports"
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	service *services.ReportService
+}
+
+func NewReportHandler(service *services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GenerateReportRequest 生成报告请求
+type GenerateReportRequest struct {
+	Period    models.ReportPeriod `json:"period" binding:"required,oneof=monthly yearly"`
+	PeriodKey string              `json:"period_key" binding:"required"` // YYYY-MM 或 YYYY
+}
+
+// GenerateReport 立即生成 (或重新生成) 月度/年度报告
+func (h *ReportHandler) GenerateReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var req GenerateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	report, err := h.service.GenerateReport(userID.(uint), req.Period, req.PeriodKey, false)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReport) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的报告周期"})
+			return
+		}
+		if errors.Is(err, reports.ErrPDFFontRequired) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "服务器未配置 PDF 字体，仅生成了 HTML 报告", "id": report.ID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成报告失败"})
+		return
+	}
+	report.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetReports 获取报告列表
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	list, err := h.service.ListReports(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取报告列表失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range list {
+		list[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetReport 获取报告信息
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	report.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DownloadReport 下载报告，format 为 html 或 pdf
+func (h *ReportHandler) DownloadReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	if report.Status != models.ReportReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "报告尚未生成成功"})
+		return
+	}
+
+	filename := fmt.Sprintf("climbing-report-%s", report.PeriodKey)
+	switch c.Param("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(report.HTML))
+	case "pdf":
+		if len(report.PDF) == 0 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "服务器未配置 PDF 字体，请下载 HTML 报告"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+		c.Data(http.StatusOK, "application/pdf", report.PDF)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "不支持的报告格式"})
+	}
+}
+
+// loadReport 读取路径参数指定的报告，失败时已写入错误响应
+func (h *ReportHandler) loadReport(c *gin.Context) (*models.Report, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return nil, false
+	}
+
+	reportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的报告ID"})
+		return nil, false
+	}
+
+	report, err := h.service.GetReport(userID.(uint), uint(reportID))
+	if err != nil {
+		if errors.Is(err, services.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取报告失败"})
+		return nil, false
+	}
+	return report, true
+}
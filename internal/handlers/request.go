@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestTimeSettings 获取 TimeSettingsMiddleware 存入的用户时间设置
+func requestTimeSettings(c *gin.Context) utils.TimeSettings {
+	if settings, ok := c.Get("timeSettings"); ok {
+		return settings.(utils.TimeSettings)
+	}
+	return utils.DefaultTimeSettings()
+}
+
+// requestLocation 当前请求使用的时区 (用户设置或 tz 查询参数)
+func requestLocation(c *gin.Context) *time.Location {
+	return requestTimeSettings(c).Location
+}
+
+// parseDateRange 在用户时区内解析 from/to 查询参数
+//
+// 纯日期的 to 包含当天全天；未指定的一端返回 defaultFrom/defaultTo。
+func parseDateRange(c *gin.Context, defaultFrom, defaultTo time.Time) (time.Time, time.Time, error) {
+	return parseDateParams(c, "from", "to", defaultFrom, defaultTo)
+}
+
+// parseDateParams 在用户时区内解析指定名称的起止日期查询参数
+func parseDateParams(c *gin.Context, fromKey, toKey string, defaultFrom, defaultTo time.Time) (time.Time, time.Time, error) {
+	loc := requestLocation(c)
+	from, to := defaultFrom, defaultTo
+
+	var err error
+	if fromStr := c.Query(fromKey); fromStr != "" {
+		if from, err = utils.ParseDate(fromStr, loc, false); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if toStr := c.Query(toKey); toStr != "" {
+		if to, err = utils.ParseDate(toStr, loc, true); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return from, to, nil
+}
+
+// parseAnalysisFilter 解析分析筛选参数
+//
+// type、location、style、grade_min、grade_max、success、attempts (逗号分隔)、
+// rating_min、rating_max、tags (逗号分隔，需全部包含)。
+func parseAnalysisFilter(c *gin.Context) (services.AnalysisFilter, error) {
+	filter := services.AnalysisFilter{
+		Type:     models.ClimbingType(c.Query("type")),
+		Location: c.Query("location"),
+		Style:    models.AscentStyle(c.Query("style")),
+		GradeMin: c.Query("grade_min"),
+		GradeMax: c.Query("grade_max"),
+		Tags:     splitQueryList(c.Query("tags")),
+	}
+
+	switch filter.Type {
+	case "", models.Bouldering, models.SportClimbing:
+	default:
+		return filter, fmt.Errorf("无效的攀岩类型: %s", filter.Type)
+	}
+
+	switch filter.Style {
+	case "", models.StyleOnsight, models.StyleFlash, models.StyleRedpoint, models.StyleTopRope, models.StyleRepeat:
+	default:
+		return filter, fmt.Errorf("无效的完成方式: %s", filter.Style)
+	}
+
+	if v := c.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("无效的完成状态: %s", v)
+		}
+		filter.Success = &success
+	}
+
+	for _, attempts := range splitQueryList(c.Query("attempts")) {
+		switch a := models.AttemptRange(attempts); a {
+		case models.Flash, models.TwoThree, models.FourSix, models.SevenPlus, models.Failed:
+			filter.Attempts = append(filter.Attempts, a)
+		default:
+			return filter, fmt.Errorf("无效的尝试次数: %s", attempts)
+		}
+	}
+
+	var err error
+	for key, target := range map[string]*int{"rating_min": &filter.RatingMin, "rating_max": &filter.RatingMax} {
+		if v := c.Query(key); v != "" {
+			if *target, err = strconv.Atoi(v); err != nil {
+				return filter, fmt.Errorf("无效的评分: %s", v)
+			}
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// splitQueryList 拆分逗号分隔的查询参数，忽略空项
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StrengthHandler struct {
+	service *services.StrengthService
+}
+
+func NewStrengthHandler(service *services.StrengthService) *StrengthHandler {
+	return &StrengthHandler{service: service}
+}
+
+// strengthError 将服务层错误转换为响应
+func strengthError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrInvalidStrength):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// parseBenchmarkTrendParams 解析趋势的日期范围 (默认最近一年) 和 edge_size/grip/hand 筛选条件
+func parseBenchmarkTrendParams(c *gin.Context) (time.Time, time.Time, services.BenchmarkFilter, error) {
+	today := utils.StartOfDay(time.Now(), requestLocation(c))
+	from, to, err := parseDateRange(c, today.AddDate(-1, 0, 0), today.AddDate(0, 0, 1).Add(-time.Microsecond))
+	if err != nil {
+		return from, to, services.BenchmarkFilter{}, errors.New("无效的日期格式")
+	}
+
+	filter := services.BenchmarkFilter{
+		Grip: models.GripType(c.Query("grip")),
+		Hand: models.Hand(c.Query("hand")),
+	}
+	if edge := c.Query("edge_size"); edge != "" {
+		if filter.EdgeSize, err = strconv.ParseFloat(edge, 64); err != nil || filter.EdgeSize <= 0 {
+			return from, to, filter, errors.New("无效的边缘深度")
+		}
+	}
+	return from, to, filter, nil
+}
+
+// CreateSession 创建力量训练记录
+func (h *StrengthHandler) CreateSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var session models.StrengthSession
+	if err := c.ShouldBindJSON(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.CreateSession(userID.(uint), &session); err != nil {
+		strengthError(c, err, "训练记录不存在", "创建训练记录失败")
+		return
+	}
+	session.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetSessions 获取力量训练记录列表
+func (h *StrengthHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	from, to, err := parseDateRange(c, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	sessions, total, err := h.service.GetSessions(userID.(uint), page, limit, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取训练记录失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range sessions {
+		sessions[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  sessions,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetSession 获取单条力量训练记录
+func (h *StrengthHandler) GetSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	session, err := h.service.GetSession(userID.(uint), uint(sessionID))
+	if err != nil {
+		strengthError(c, err, "训练记录不存在", "获取训练记录失败")
+		return
+	}
+	session.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, session)
+}
+
+// UpdateSession 修改力量训练记录
+func (h *StrengthHandler) UpdateSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	var session models.StrengthSession
+	if err := c.ShouldBindJSON(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	session.ID = uint(sessionID)
+	if err := h.service.UpdateSession(userID.(uint), &session); err != nil {
+		strengthError(c, err, "训练记录不存在", "更新训练记录失败")
+		return
+	}
+	session.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, session)
+}
+
+// DeleteSession 删除力量训练记录
+func (h *StrengthHandler) DeleteSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录ID"})
+		return
+	}
+
+	if err := h.service.DeleteSession(userID.(uint), uint(sessionID)); err != nil {
+		strengthError(c, err, "训练记录不存在", "删除训练记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "训练记录删除成功"})
+}
+
+// CreateBenchmark 记录力量测试成绩
+func (h *StrengthHandler) CreateBenchmark(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var benchmark models.StrengthBenchmark
+	if err := c.ShouldBindJSON(&benchmark); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.CreateBenchmark(userID.(uint), &benchmark); err != nil {
+		strengthError(c, err, "测试成绩不存在", "记录测试成绩失败")
+		return
+	}
+	benchmark.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, benchmark)
+}
+
+// GetBenchmarks 获取力量测试成绩，可按 kind 筛选
+func (h *StrengthHandler) GetBenchmarks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	benchmarks, err := h.service.GetBenchmarks(userID.(uint), models.BenchmarkKind(c.Query("kind")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取测试成绩失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range benchmarks {
+		benchmarks[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, benchmarks)
+}
+
+// DeleteBenchmark 删除力量测试成绩
+func (h *StrengthHandler) DeleteBenchmark(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	benchmarkID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成绩ID"})
+		return
+	}
+
+	if err := h.service.DeleteBenchmark(userID.(uint), uint(benchmarkID)); err != nil {
+		strengthError(c, err, "测试成绩不存在", "删除测试成绩失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "测试成绩删除成功"})
+}
+
+// GetBenchmarkTrend 获取测试成绩趋势，kind 必填
+func (h *StrengthHandler) GetBenchmarkTrend(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, filter, err := parseBenchmarkTrendParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := models.BenchmarkKind(c.Query("kind"))
+	trend, err := h.service.GetBenchmarkTrend(userID.(uint), kind, from, to, filter, requestLocation(c))
+	if err != nil {
+		strengthError(c, err, "测试成绩不存在", "获取成绩趋势失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
+// GetVolume 获取力量训练量统计，默认最近三个月
+func (h *StrengthHandler) GetVolume(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	from, to, err := parseAnalysisRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	volume, err := h.service.GetVolume(userID.(uint), from, to, requestTimeSettings(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取训练量失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, volume)
+}
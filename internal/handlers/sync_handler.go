@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movePoint/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	service *services.SyncService
+}
+
+func NewSyncHandler(service *services.SyncService) *SyncHandler {
+	return &SyncHandler{service: service}
+}
+
+// GetChanges 获取 cursor 之后的变更 (含删除的墓碑)，cursor 为空时从头拉取
+func (h *SyncHandler) GetChanges(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	changes, err := h.service.GetChanges(userID.(uint), c.Query("cursor"), limit, requestLocation(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSync) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取同步变更失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// PushChanges 推送客户端离线产生的新增、修改和删除，逐条返回处理结果
+func (h *SyncHandler) PushChanges(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var req services.SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	results, err := h.service.Push(userID.(uint), req, requestLocation(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSync) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "同步失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	service *services.TeamService
+}
+
+func NewTeamHandler(service *services.TeamService) *TeamHandler {
+	return &TeamHandler{service: service}
+}
+
+// JoinTeamRequest 通过邀请码加入团队的请求
+type JoinTeamRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MemberRoleRequest 修改成员角色的请求
+type MemberRoleRequest struct {
+	Role models.TeamRole `json:"role" binding:"required"`
+}
+
+// OptOutRequest 设置是否参与排行榜或团队动态的请求
+type OptOutRequest struct {
+	OptOut *bool `json:"opt_out" binding:"required"`
+}
+
+// teamError 将服务层错误转换为响应
+func teamError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "团队或成员不存在"})
+	case errors.Is(err, services.ErrTeamForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "没有该团队的管理权限"})
+	case errors.Is(err, services.ErrInvalidTeam), errors.Is(err, services.ErrInvalidLeaderboard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// teamParams 解析路径中的团队ID，失败时已写入错误响应
+func teamParams(c *gin.Context) (uint, uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return 0, 0, false
+	}
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的团队ID"})
+		return 0, 0, false
+	}
+	return userID.(uint), uint(teamID), true
+}
+
+// CreateTeam 创建团队
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var team models.Team
+	if err := c.ShouldBindJSON(&team); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.CreateTeam(userID.(uint), &team); err != nil {
+		teamError(c, err, "创建团队失败")
+		return
+	}
+	team.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusCreated, team)
+}
+
+// GetTeams 获取我加入的团队
+func (h *TeamHandler) GetTeams(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	teams, err := h.service.GetTeams(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取团队列表失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range teams {
+		teams[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+// JoinTeam 通过邀请码加入团队
+func (h *TeamHandler) JoinTeam(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	var req JoinTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	team, err := h.service.JoinTeam(userID.(uint), req.Code)
+	if err != nil {
+		teamError(c, err, "加入团队失败")
+		return
+	}
+	team.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, team)
+}
+
+// GetTeam 获取团队信息
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.service.GetTeam(userID, teamID)
+	if err != nil {
+		teamError(c, err, "获取团队信息失败")
+		return
+	}
+	team.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, team)
+}
+
+// UpdateTeam 修改团队信息和排行榜设置
+func (h *TeamHandler) UpdateTeam(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	var update models.Team
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	team, err := h.service.UpdateTeam(userID, teamID, &update)
+	if err != nil {
+		teamError(c, err, "更新团队失败")
+		return
+	}
+	team.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam 删除团队
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTeam(userID, teamID); err != nil {
+		teamError(c, err, "删除团队失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "团队删除成功"})
+}
+
+// RegenerateInviteCode 重新生成邀请码
+func (h *TeamHandler) RegenerateInviteCode(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.service.RegenerateInviteCode(userID, teamID)
+	if err != nil {
+		teamError(c, err, "生成邀请码失败")
+		return
+	}
+	team.LocalizeTimes(requestLocation(c))
+
+	c.JSON(http.StatusOK, team)
+}
+
+// LeaveTeam 退出团队
+func (h *TeamHandler) LeaveTeam(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.LeaveTeam(userID, teamID); err != nil {
+		teamError(c, err, "退出团队失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出团队"})
+}
+
+// GetMembers 获取团队成员
+func (h *TeamHandler) GetMembers(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.service.GetMembers(userID, teamID)
+	if err != nil {
+		teamError(c, err, "获取团队成员失败")
+		return
+	}
+	loc := requestLocation(c)
+	for i := range members {
+		members[i].JoinedAt = members[i].JoinedAt.In(loc)
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// SetMemberRole 修改成员角色，role 为 owner 时转让团队
+func (h *TeamHandler) SetMemberRole(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req MemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.SetMemberRole(userID, teamID, uint(memberID), req.Role); err != nil {
+		teamError(c, err, "修改成员角色失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "成员角色已更新"})
+}
+
+// RemoveMember 移出成员
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.service.RemoveMember(userID, teamID, uint(memberID)); err != nil {
+		teamError(c, err, "移出成员失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已移出成员"})
+}
+
+// SetLeaderboardOptOut 设置是否参与团队排行榜
+func (h *TeamHandler) SetLeaderboardOptOut(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	var req OptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.SetLeaderboardOptOut(userID, teamID, *req.OptOut); err != nil {
+		teamError(c, err, "更新排行榜设置失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leaderboard_opt_out": *req.OptOut})
+}
+
+// SetFeedOptOut 设置是否在团队动态中显示自己的记录
+func (h *TeamHandler) SetFeedOptOut(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	var req OptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.service.SetFeedOptOut(userID, teamID, *req.OptOut); err != nil {
+		teamError(c, err, "更新团队动态设置失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feed_opt_out": *req.OptOut})
+}
+
+// GetFeed 获取团队动态 (成员的攀岩记录)，limit 最大为 100
+func (h *TeamHandler) GetFeed(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	feed, err := h.service.GetFeed(userID, teamID, page, limit)
+	if err != nil {
+		teamError(c, err, "获取团队动态失败")
+		return
+	}
+	loc := requestLocation(c)
+	for i := range feed.Data {
+		feed.Data[i].StartTime = feed.Data[i].StartTime.In(loc)
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// GetStats 获取团队汇总统计，from/to 默认为本月
+func (h *TeamHandler) GetStats(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	loc := requestLocation(c)
+	now := time.Now()
+	from, to, err := parseDateRange(c, utils.StartOfMonth(now, loc), utils.StartOfDay(now, loc).AddDate(0, 0, 1).Add(-time.Microsecond))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return
+	}
+
+	stats, err := h.service.GetStats(userID, teamID, from, to, loc)
+	if err != nil {
+		teamError(c, err, "获取团队统计失败")
+		return
+	}
+	stats.From, stats.To = stats.From.In(loc), stats.To.In(loc)
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetLeaderboard 获取团队排行榜，参数同 /leaderboards
+func (h *TeamHandler) GetLeaderboard(c *gin.Context) {
+	userID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+
+	q := leaderboardQuery(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+
+	board, err := h.service.GetLeaderboard(userID, teamID, q, requestTimeSettings(c), (page-1)*limit, limit)
+	if err != nil {
+		teamError(c, err, "获取排行榜失败")
+		return
+	}
+	localizeLeaderboard(board, requestLocation(c))
+
+	c.JSON(http.StatusOK, board)
+}
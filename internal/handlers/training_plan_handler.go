@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/internal/services"
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrainingPlanHandler struct {
+	service *services.TrainingPlanService
+}
+
+func NewTrainingPlanHandler(service *services.TrainingPlanService) *TrainingPlanHandler {
+	return &TrainingPlanHandler{service: service}
+}
+
+// PlanRequest 创建或修改训练计划的请求，修改时整体替换计划训练
+type PlanRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	IsTemplate  bool             `json:"is_template"`
+	Public      bool             `json:"public"`     // 仅模板有效
+	StartDate   string           `json:"start_date"` // YYYY-MM-DD (用户时区)，模板不需要
+	Weeks       int              `json:"weeks"`      // 为 0 时取计划训练的最大周
+	Sessions    []SessionRequest `json:"sessions"`
+}
+
+// SessionRequest 计划训练
+type SessionRequest struct {
+	Week           int                 `json:"week"`
+	Day            int                 `json:"day"`
+	Kind           models.SessionKind  `json:"kind"`
+	Title          string              `json:"title"`
+	Notes          string              `json:"notes"`
+	Type           models.ClimbingType `json:"type"`
+	TargetDuration int                 `json:"target_duration"`
+	TargetSends    int                 `json:"target_sends"`
+	TargetGrade    string              `json:"target_grade"`
+}
+
+// CopyPlanRequest 复制计划或模板的请求
+type CopyPlanRequest struct {
+	Name       string `json:"name"`       // 为空时沿用原名称
+	StartDate  string `json:"start_date"` // 复制为计划时必填
+	IsTemplate bool   `json:"is_template"`
+}
+
+// CompleteSessionRequest 手动标记训练完成的请求
+type CompleteSessionRequest struct {
+	Completed *bool `json:"completed" binding:"required"`
+}
+
+// bindPlan 解析请求体为训练计划，失败时已写入错误响应
+func bindPlan(c *gin.Context) (*models.TrainingPlan, bool) {
+	var req PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return nil, false
+	}
+
+	plan := &models.TrainingPlan{
+		Name:        req.Name,
+		Description: req.Description,
+		IsTemplate:  req.IsTemplate,
+		Public:      req.Public,
+		Weeks:       req.Weeks,
+	}
+	if req.StartDate != "" && !req.IsTemplate {
+		start, ok := parsePlanDate(c, req.StartDate)
+		if !ok {
+			return nil, false
+		}
+		plan.StartDate = &start
+	}
+	for _, s := range req.Sessions {
+		plan.Sessions = append(plan.Sessions, models.PlannedSession{
+			Week:           s.Week,
+			Day:            s.Day,
+			Kind:           s.Kind,
+			Title:          s.Title,
+			Notes:          s.Notes,
+			Type:           s.Type,
+			TargetDuration: s.TargetDuration,
+			TargetSends:    s.TargetSends,
+			TargetGrade:    s.TargetGrade,
+		})
+	}
+	return plan, true
+}
+
+// parsePlanDate 解析用户时区的开始日期，失败时已写入错误响应
+func parsePlanDate(c *gin.Context, value string) (time.Time, bool) {
+	start, err := utils.ParseDate(value, requestLocation(c), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始日期"})
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// planError 将服务层错误转换为响应
+func planError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "训练计划不存在"})
+	case errors.Is(err, services.ErrInvalidPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// CreatePlan 创建训练计划或模板
+func (h *TrainingPlanHandler) CreatePlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	plan, ok := bindPlan(c)
+	if !ok {
+		return
+	}
+	if err := h.service.CreatePlan(userID.(uint), plan); err != nil {
+		planError(c, err, "创建训练计划失败")
+		return
+	}
+
+	result, err := h.service.GetPlan(userID.(uint), plan.ID)
+	if err != nil {
+		planError(c, err, "获取训练计划失败")
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetPlans 获取训练计划列表，template=true 时获取自己的模板
+func (h *TrainingPlanHandler) GetPlans(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	templates, _ := strconv.ParseBool(c.DefaultQuery("template", "false"))
+	plans, err := h.service.ListPlans(userID.(uint), templates)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取训练计划失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range plans {
+		plans[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetTemplates 获取可复制的模板 (自己的和公开的)
+func (h *TrainingPlanHandler) GetTemplates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	plans, err := h.service.ListTemplates(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取模板失败"})
+		return
+	}
+	loc := requestLocation(c)
+	for i := range plans {
+		plans[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetPlan 获取训练计划及每次训练的完成状态
+func (h *TrainingPlanHandler) GetPlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+
+	plan, err := h.service.GetPlan(userID.(uint), uint(planID))
+	if err != nil {
+		planError(c, err, "获取训练计划失败")
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdatePlan 修改训练计划
+func (h *TrainingPlanHandler) UpdatePlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+
+	plan, ok := bindPlan(c)
+	if !ok {
+		return
+	}
+	if err := h.service.UpdatePlan(userID.(uint), uint(planID), plan); err != nil {
+		planError(c, err, "更新训练计划失败")
+		return
+	}
+
+	result, err := h.service.GetPlan(userID.(uint), uint(planID))
+	if err != nil {
+		planError(c, err, "获取训练计划失败")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DeletePlan 删除训练计划
+func (h *TrainingPlanHandler) DeletePlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+
+	if err := h.service.DeletePlan(userID.(uint), uint(planID)); err != nil {
+		planError(c, err, "删除训练计划失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "训练计划删除成功"})
+}
+
+// CopyPlan 复制计划或模板为自己的新计划或模板
+func (h *TrainingPlanHandler) CopyPlan(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+
+	var req CopyPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	var startDate *time.Time
+	if req.StartDate != "" && !req.IsTemplate {
+		start, ok := parsePlanDate(c, req.StartDate)
+		if !ok {
+			return
+		}
+		startDate = &start
+	}
+
+	plan, err := h.service.CopyPlan(userID.(uint), uint(planID), req.Name, startDate, req.IsTemplate)
+	if err != nil {
+		planError(c, err, "复制训练计划失败")
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// CompleteSession 手动标记指力板、体能等无法自动匹配的训练完成
+func (h *TrainingPlanHandler) CompleteSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的训练ID"})
+		return
+	}
+
+	var req CompleteSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	plan, err := h.service.CompleteSession(userID.(uint), uint(planID), uint(sessionID), *req.Completed)
+	if err != nil {
+		planError(c, err, "更新训练完成状态失败")
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// GetAdherence 获取训练计划的执行情况
+func (h *TrainingPlanHandler) GetAdherence(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	planID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
+		return
+	}
+
+	adherence, err := h.service.GetAdherence(userID.(uint), uint(planID))
+	if err != nil {
+		planError(c, err, "获取计划执行情况失败")
+		return
+	}
+	c.JSON(http.StatusOK, adherence)
+}
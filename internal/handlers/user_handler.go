@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"movePoint/internal/services"
@@ -9,11 +10,12 @@ import (
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService           *services.UserService
+	personalRecordService *services.PersonalRecordService
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *services.UserService, personalRecordService *services.PersonalRecordService) *UserHandler {
+	return &UserHandler{userService: userService, personalRecordService: personalRecordService}
 }
 
 // GetProfile 获取用户个人信息
@@ -29,6 +31,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
 		return
 	}
+	user.LocalizeTimes(requestLocation(c))
 
 	c.JSON(http.StatusOK, user)
 }
@@ -48,6 +51,10 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	if err := h.userService.UpdateUserProfile(userID.(uint), updates); err != nil {
+		if errors.Is(err, services.ErrInvalidProfile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户信息失败"})
 		return
 	}
@@ -63,7 +70,7 @@ func (h *UserHandler) GetStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.userService.GetUserStats(userID.(uint))
+	stats, err := h.userService.GetUserStats(userID.(uint), requestTimeSettings(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户统计数据失败"})
 		return
@@ -104,3 +111,53 @@ func (h *UserHandler) CheckAchievements(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "成就检查完成"})
 }
+
+// GetPersonalRecords 获取用户个人纪录和里程碑
+func (h *UserHandler) GetPersonalRecords(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	records, err := h.personalRecordService.GetPersonalRecords(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取个人纪录失败"})
+		return
+	}
+
+	milestones, err := h.personalRecordService.GetMilestones(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	loc := requestLocation(c)
+	for i := range records {
+		records[i].LocalizeTimes(loc)
+	}
+	for i := range milestones {
+		milestones[i].LocalizeTimes(loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"personal_records": records,
+		"milestones":       milestones,
+	})
+}
+
+// RecalculatePersonalRecords 从全部历史记录重新计算个人纪录
+func (h *UserHandler) RecalculatePersonalRecords(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
+		return
+	}
+
+	if err := h.personalRecordService.RecalculateAll(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新计算个人纪录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "个人纪录已重新计算"})
+}
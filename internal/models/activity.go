@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ActivityKind 活动类型
+type ActivityKind string
+
+const (
+	ActivityClimbing ActivityKind = "climbing" // 攀岩 (ClimbingRecord)
+	ActivityStrength ActivityKind = "strength" // 指力板/力量训练 (StrengthSession)
+	ActivityRunning  ActivityKind = "running"  // 跑步 (Workout)
+	ActivityYoga     ActivityKind = "yoga"     // 瑜伽 (Workout)
+	ActivityLifting  ActivityKind = "lifting"  // 举重/器械训练 (Workout)
+)
+
+// ActivityKinds 全部活动类型，统计结果按此顺序排列
+var ActivityKinds = []ActivityKind{ActivityClimbing, ActivityStrength, ActivityRunning, ActivityYoga, ActivityLifting}
+
+// Activity 各类训练记录的共同接口，热量估算、训练负荷和跨类型统计都基于此接口
+type Activity interface {
+	ActivityKind() ActivityKind
+	ActivityID() uint
+	ActivityStart() time.Time
+	ActivityDuration() int // 分钟
+	ActivityCalories() float64
+	ActivityRPE() int // 主观疲劳度 1-10，0 表示未填写
+}
+
+func (r *ClimbingRecord) ActivityKind() ActivityKind { return ActivityClimbing }
+func (r *ClimbingRecord) ActivityID() uint           { return r.ID }
+func (r *ClimbingRecord) ActivityStart() time.Time   { return r.StartTime }
+func (r *ClimbingRecord) ActivityDuration() int      { return r.Duration }
+func (r *ClimbingRecord) ActivityCalories() float64  { return r.Calories }
+func (r *ClimbingRecord) ActivityRPE() int           { return r.RPE }
+
+func (s *StrengthSession) ActivityKind() ActivityKind { return ActivityStrength }
+func (s *StrengthSession) ActivityID() uint           { return s.ID }
+func (s *StrengthSession) ActivityStart() time.Time   { return s.StartTime }
+func (s *StrengthSession) ActivityDuration() int      { return s.Duration }
+func (s *StrengthSession) ActivityCalories() float64  { return s.Calories }
+func (s *StrengthSession) ActivityRPE() int           { return s.RPE }
+
+func (w *Workout) ActivityKind() ActivityKind { return w.Kind }
+func (w *Workout) ActivityID() uint           { return w.ID }
+func (w *Workout) ActivityStart() time.Time   { return w.StartTime }
+func (w *Workout) ActivityDuration() int      { return w.Duration }
+func (w *Workout) ActivityCalories() float64  { return w.Calories }
+func (w *Workout) ActivityRPE() int           { return w.RPE }
@@ -22,14 +22,18 @@ type RegisterRequest struct {
 	AvatarURL    string  `json:"avatar_url"`
 	Bio          string  `json:"bio"`
 	Achievements string  `json:"achievements"` // JSON 字符串格式
+	Timezone     string  `json:"timezone"`     // IANA 时区名，默认 UTC
+	WeekStart    *int    `json:"week_start"`   // 每周起始日，0 = 周日，默认周一
+	Locale       string  `json:"locale"`       // 默认 zh-CN
 }
 
 // AuthResponse 认证响应结构体
 type AuthResponse struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Token    string `json:"token"`
+	UserID   uint     `json:"user_id"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles"`
+	Token    string   `json:"token"`
 }
 
 // HashPassword 使用bcrypt加密密码
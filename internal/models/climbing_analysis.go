@@ -3,9 +3,14 @@ package models
 import "time"
 
 // ClimbingAnalysis 用于存储用户的分析结果（可缓存）
+//
+// 以用户和 CacheKey (时间范围、时区) 为键，用户记录变更时整体失效。
 type ClimbingAnalysis struct {
-	ID     uint      `gorm:"primaryKey" json:"id"`
-	UserID uint      `gorm:"not null;index" json:"user_id"`
-	Date   time.Time `json:"date"`                  // 分析日期
-	Data   string    `gorm:"type:text" json:"data"` // JSON格式的分析数据
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index;index:idx_user_cache_key" json:"user_id"`
+	CacheKey  string    `gorm:"type:varchar(191);index:idx_user_cache_key" json:"cache_key"`
+	RangeFrom time.Time `json:"range_from"`
+	RangeTo   time.Time `json:"range_to"`
+	Date      time.Time `gorm:"index" json:"date"`           // 分析日期 (生成时间)
+	Data      string    `gorm:"type:mediumtext" json:"data"` // JSON格式的分析数据
 }
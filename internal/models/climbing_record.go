@@ -24,13 +24,36 @@ const (
 	Failed    AttemptRange = "failed" // 未完成
 )
 
+// AscentStyle 完成方式枚举
+type AscentStyle string
+
+const (
+	StyleOnsight  AscentStyle = "onsight"  // 看攀: 无任何信息首次完成
+	StyleFlash    AscentStyle = "flash"    // 闪攀: 有信息首次完成
+	StyleRedpoint AscentStyle = "redpoint" // 红点: 多次尝试后完成
+	StyleTopRope  AscentStyle = "toprope"  // 顶绳
+	StyleRepeat   AscentStyle = "repeat"   // 重复完成
+)
+
+// RecordSource 记录来源枚举
+type RecordSource string
+
+const (
+	SourceManual      RecordSource = "manual"       // 手动录入
+	SourceGarminFIT   RecordSource = "garmin_fit"   // Garmin FIT 文件导入
+	SourceTCX         RecordSource = "tcx"          // TCX 文件导入
+	SourceAppleHealth RecordSource = "apple_health" // Apple 健康导出导入
+	SourceLive        RecordSource = "live"         // 实时攀岩结束时生成
+)
+
 type ClimbingRecord struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
-	UserID uint `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	UserID  uint `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	Version uint `gorm:"not null;default:1" json:"version"` // 每次修改递增，用于乐观并发控制 (ETag)
 
 	// 基本记录信息
 	Type      ClimbingType `gorm:"type:varchar(20);not null" json:"type"`
@@ -43,13 +66,33 @@ type ClimbingRecord struct {
 	Color    string       `gorm:"type:varchar(20)" json:"color"` // 抱石垫颜色
 	Attempts AttemptRange `gorm:"type:varchar(10)" json:"attempts"`
 	Success  bool         `json:"success"`                                     // 是否成功完成
+	Style    AscentStyle  `gorm:"type:varchar(20)" json:"style"`               // 完成方式，可为空
 	Rating   int          `gorm:"check:rating>=1 AND rating<=5" json:"rating"` // 1-5星评分
+	RPE      int          `gorm:"check:rpe>=0 AND rpe<=10" json:"rpe"`         // 主观疲劳度 1-10，0 表示未填写
 
 	// 位置和媒体
-	Location  string `gorm:"type:varchar(255)" json:"location"`
-	Notes     string `gorm:"type:text" json:"notes"`
-	MediaURLs string `gorm:"type:text" json:"media_urls"` // JSON数组存储多个媒体URL
+	Location  string  `gorm:"type:varchar(255)" json:"location"`
+	Notes     string  `gorm:"type:text" json:"notes"`
+	MediaURLs string  `gorm:"type:text" json:"media_urls"`   // JSON数组存储多个媒体URL
+	Tags      TagList `gorm:"type:varchar(512)" json:"tags"` // 自定义标签，如 "比赛"、"室外"
 
 	// 计算字段
-	Calories float64 `json:"calories"` // 估算的热量消耗
+	Calories float64 `json:"calories"` // 估算的热量消耗，导入穿戴设备数据后为实测值
+
+	// 穿戴设备数据
+	AvgHeartRate int          `json:"avg_heart_rate"`                                // 平均心率 (bpm)
+	MaxHeartRate int          `json:"max_heart_rate"`                                // 最大心率 (bpm)
+	Source       RecordSource `gorm:"type:varchar(20);default:manual" json:"source"` // 数据来源
+	ExternalID   string       `gorm:"type:varchar(128);index" json:"external_id"`    // 外部设备的训练标识，用于重复导入去重
+}
+
+// IsFirstGoSend 是否为首次尝试即完成 (看攀或闪攀)
+func (r *ClimbingRecord) IsFirstGoSend() bool {
+	if !r.Success {
+		return false
+	}
+	if r.Style != "" {
+		return r.Style == StyleOnsight || r.Style == StyleFlash
+	}
+	return r.Attempts == Flash
 }
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// CoachLinkStatus 教练关系的状态
+type CoachLinkStatus string
+
+const (
+	CoachLinkPending  CoachLinkStatus = "pending"  // 教练已邀请，等待攀岩者接受
+	CoachLinkActive   CoachLinkStatus = "active"   // 攀岩者已接受
+	CoachLinkDeclined CoachLinkStatus = "declined" // 攀岩者已拒绝
+	CoachLinkEnded    CoachLinkStatus = "ended"    // 任一方已解除
+)
+
+// 攀岩者授予教练的访问范围
+const (
+	CoachScopeRecords  = "records:read"  // 查看攀岩记录
+	CoachScopeAnalysis = "analysis:read" // 查看数据分析
+	CoachScopeComment  = "comment"       // 评论攀岩记录
+	CoachScopePlans    = "plans:assign"  // 布置训练计划
+)
+
+// CoachScopes 全部访问范围
+var CoachScopes = []string{CoachScopeRecords, CoachScopeAnalysis, CoachScopeComment, CoachScopePlans}
+
+// CoachLink 教练与攀岩者的关系，由教练邀请、攀岩者接受，访问范围由攀岩者决定
+type CoachLink struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CoachID     uint            `gorm:"type:int unsigned;not null;uniqueIndex:idx_coach_link" json:"coach_id"`
+	AthleteID   uint            `gorm:"type:int unsigned;not null;uniqueIndex:idx_coach_link;index" json:"athlete_id"`
+	Status      CoachLinkStatus `gorm:"type:varchar(16);not null;index" json:"status"`
+	Scopes      PermissionList  `gorm:"type:varchar(255)" json:"scopes"` // 待接受时为教练申请的范围
+	Message     string          `gorm:"type:varchar(500)" json:"message"`
+	RespondedAt *time.Time      `json:"responded_at"`
+}
+
+// HasScope 关系是否有效且包含访问范围 scope
+func (l *CoachLink) HasScope(scope string) bool {
+	if l.Status != CoachLinkActive {
+		return false
+	}
+	for _, s := range l.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordComment 攀岩记录的评论，作者为记录本人或有评论权限的教练
+type RecordComment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RecordID  uint   `gorm:"not null;index" json:"record_id"`
+	AthleteID uint   `gorm:"type:int unsigned;not null;index" json:"athlete_id"` // 记录所属用户
+	AuthorID  uint   `gorm:"type:int unsigned;not null" json:"author_id"`
+	Author    string `gorm:"->;-:migration" json:"author,omitempty"` // 作者用户名，查询时填充
+	Body      string `gorm:"type:text;not null" json:"body"`
+}
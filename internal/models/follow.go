@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Follow 关注关系，关注后可以观看对方开启了 share_live 的实时攀岩；被关注者可以移除关注者
+type Follow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	FollowerID uint      `gorm:"type:int unsigned;not null;uniqueIndex:idx_follow" json:"follower_id"`
+	FolloweeID uint      `gorm:"type:int unsigned;not null;uniqueIndex:idx_follow;index" json:"followee_id"`
+}
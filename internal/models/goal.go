@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// GoalMetric 目标指标
+type GoalMetric string
+
+const (
+	GoalSessions   GoalMetric = "sessions"    // 攀岩次数
+	GoalDuration   GoalMetric = "duration"    // 攀岩时长 (分钟)
+	GoalSends      GoalMetric = "sends"       // 完成线路数
+	GoalActiveDays GoalMetric = "active_days" // 攀岩天数
+	GoalGrade      GoalMetric = "grade"       // 完成指定难度
+)
+
+// GoalRecurrence 目标重复周期，none 表示在截止日期前完成的一次性目标
+type GoalRecurrence string
+
+const (
+	RecurrenceNone      GoalRecurrence = "none"
+	RecurrenceWeekly    GoalRecurrence = "weekly"
+	RecurrenceMonthly   GoalRecurrence = "monthly"
+	RecurrenceQuarterly GoalRecurrence = "quarterly"
+	RecurrenceYearly    GoalRecurrence = "yearly"
+)
+
+// GoalState 目标在当前周期的状态
+type GoalState string
+
+const (
+	GoalOnTrack GoalState = "on_track" // 按当前进度可以完成
+	GoalAtRisk  GoalState = "at_risk"  // 按当前进度无法完成
+	GoalMet     GoalState = "met"      // 已完成
+	GoalMissed  GoalState = "missed"   // 周期已结束仍未完成
+)
+
+// Goal 用户自定义的攀岩目标，如 "6 月前完成 V6"、"每周攀岩 3 次"
+type Goal struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      uint           `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	Title       string         `gorm:"type:varchar(100);not null" json:"title"`
+	Metric      GoalMetric     `gorm:"type:varchar(20);not null" json:"metric"`
+	Target      float64        `json:"target"`                                            // 数值目标，难度目标时为 1
+	TargetGrade string         `gorm:"type:varchar(10)" json:"target_grade,omitempty"`    // 难度目标的难度，如 "V6"
+	Type        ClimbingType   `gorm:"type:varchar(20);default:''" json:"type,omitempty"` // 只统计该类型，为空表示全部
+	Recurrence  GoalRecurrence `gorm:"type:varchar(16);not null;default:none" json:"recurrence"`
+	StartDate   time.Time      `json:"start_date"`
+	Deadline    *time.Time     `json:"deadline"` // 一次性目标必填，重复目标可选 (之后不再重复)
+	Archived    bool           `gorm:"default:false" json:"archived"`
+
+	// 最近一次评估的周期和状态，状态变化时发布事件
+	PeriodKey string     `gorm:"type:varchar(10)" json:"period_key"`
+	State     GoalState  `gorm:"type:varchar(16)" json:"state"`
+	MetAt     *time.Time `json:"met_at"` // 当前周期的完成时间
+}
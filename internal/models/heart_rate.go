@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// HeartRateSeries 攀岩记录的心率时间序列
+//
+// 采样以紧凑二进制存储: 每个采样为 [距上一采样秒数 uvarint][心率 1 字节]，
+// 一小时每秒一个采样约 7KB。
+type HeartRateSeries struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	RecordID uint `gorm:"not null;uniqueIndex" json:"record_id"`
+
+	StartTime   time.Time `json:"start_time"` // 第一个采样的时间
+	SampleCount int       `json:"sample_count"`
+	MinBPM      int       `json:"min_bpm"`
+	MaxBPM      int       `json:"max_bpm"`
+	AvgBPM      int       `json:"avg_bpm"`
+	Data        []byte    `gorm:"type:mediumblob" json:"-"`
+}
+
+// HeartRatePoint 心率采样点
+type HeartRatePoint struct {
+	Time time.Time `json:"time"`
+	BPM  int       `json:"bpm"`
+}
+
+// ErrCorruptHeartRateData 心率数据损坏
+var ErrCorruptHeartRateData = errors.New("corrupt heart rate data")
+
+// SetPoints 编码采样点 (需按时间升序)，并更新统计字段
+func (s *HeartRateSeries) SetPoints(points []HeartRatePoint) {
+	s.Data = s.Data[:0]
+	s.SampleCount = len(points)
+	s.MinBPM, s.MaxBPM, s.AvgBPM = 0, 0, 0
+	if len(points) == 0 {
+		return
+	}
+
+	s.StartTime = points[0].Time
+	prev := s.StartTime
+	sum := 0
+	for i, p := range points {
+		delta := p.Time.Sub(prev) / time.Second
+		if delta < 0 {
+			delta = 0
+		}
+		bpm := p.BPM
+		if bpm > 255 {
+			bpm = 255
+		}
+
+		s.Data = binary.AppendUvarint(s.Data, uint64(delta))
+		s.Data = append(s.Data, byte(bpm))
+		prev = prev.Add(delta * time.Second)
+
+		sum += bpm
+		if i == 0 || bpm < s.MinBPM {
+			s.MinBPM = bpm
+		}
+		if bpm > s.MaxBPM {
+			s.MaxBPM = bpm
+		}
+	}
+	s.AvgBPM = sum / len(points)
+}
+
+// Points 解码采样点
+func (s *HeartRateSeries) Points() ([]HeartRatePoint, error) {
+	points := make([]HeartRatePoint, 0, s.SampleCount)
+	t := s.StartTime
+	for pos := 0; pos < len(s.Data); {
+		delta, n := binary.Uvarint(s.Data[pos:])
+		if n <= 0 || pos+n >= len(s.Data) {
+			return nil, ErrCorruptHeartRateData
+		}
+		pos += n
+		t = t.Add(time.Duration(delta) * time.Second)
+		points = append(points, HeartRatePoint{Time: t, BPM: int(s.Data[pos])})
+		pos++
+	}
+	return points, nil
+}
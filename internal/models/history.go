@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// HistoryAction 历史记录的操作类型
+type HistoryAction string
+
+const (
+	HistoryCreate   HistoryAction = "create"   // 创建
+	HistoryUpdate   HistoryAction = "update"   // 修改
+	HistoryDelete   HistoryAction = "delete"   // 删除 (移入回收站)
+	HistoryUndelete HistoryAction = "undelete" // 从回收站恢复
+	HistoryRestore  HistoryAction = "restore"  // 恢复到历史版本
+	HistoryPurge    HistoryAction = "purge"    // 回收站到期后彻底删除
+)
+
+// 历史记录的实体类型
+const (
+	HistoryEntityRecord  = "climbing_record"
+	HistoryEntityProfile = "profile"
+)
+
+// FieldChange 单个字段的变化，值为 JSON
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// HistoryEntry 攀岩记录和个人信息的变更历史，只追加不修改
+//
+// Snapshot 为变更后的可编辑字段 (删除时为删除前)，用于查看和恢复历史版本。
+type HistoryEntry struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	UserID   uint          `gorm:"type:int unsigned;not null;index:idx_history_entity" json:"user_id"`
+	Entity   string        `gorm:"type:varchar(32);not null;index:idx_history_entity" json:"entity"`
+	EntityID uint          `gorm:"type:int unsigned;not null;index:idx_history_entity" json:"entity_id"`
+	Action   HistoryAction `gorm:"type:varchar(16);not null" json:"action"`
+	Version  uint          `json:"version"` // 变更后的记录版本，个人信息为 0
+
+	ActorID uint   `gorm:"type:int unsigned" json:"actor_id"`        // 发起变更的用户
+	Device  string `gorm:"type:varchar(64)" json:"device,omitempty"` // 通过离线同步推送时的设备
+
+	Changes  []FieldChange              `gorm:"serializer:json;type:text" json:"changes"`
+	Snapshot map[string]json.RawMessage `gorm:"serializer:json;type:text" json:"snapshot"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LeaderboardScore 用户在某个排行榜 (指标、周期、地点) 上的成绩，攀岩记录变更时增量更新
+//
+// Period 为周期键: ISO 周 (2026-W42)、月份 (2026-10) 或 all，按用户时区划分；
+// Location 为空表示全部地点，否则为小写并去除首尾空白的地点。
+type LeaderboardScore struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Metric   string `gorm:"type:varchar(16);not null;uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank" json:"metric"`
+	Period   string `gorm:"type:varchar(16);not null;uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank" json:"period"`
+	Location string `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank" json:"location"`
+	UserID   uint   `gorm:"type:int unsigned;not null;uniqueIndex:idx_leaderboard_user;index" json:"user_id"`
+
+	Value      float64   `gorm:"not null;index:idx_leaderboard_rank" json:"value"`
+	Grade      string    `gorm:"type:varchar(10)" json:"grade,omitempty"` // 最高完攀难度 (hardest)
+	AchievedAt time.Time `json:"achieved_at"`                             // 达到该成绩的记录时间，成绩相同时先达到者排名靠前
+	Threshold  float64   `gorm:"not null;default:0" json:"-"`             // 积分 (points) 计入的最后一次完攀得分，不足 10 次完攀时为 0
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// LiveStatus 实时攀岩状态
+type LiveStatus string
+
+const (
+	LiveActive    LiveStatus = "active"    // 进行中
+	LivePaused    LiveStatus = "paused"    // 已暂停
+	LiveFinished  LiveStatus = "finished"  // 已结束
+	LiveAbandoned LiveStatus = "abandoned" // 长时间无操作被自动结束
+)
+
+// LiveSession 实时记录的攀岩，结束时每条线路转为一条攀岩记录
+type LiveSession struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint         `gorm:"type:int unsigned;not null;index:idx_user_live_status" json:"user_id"`
+	Status    LiveStatus   `gorm:"type:varchar(16);not null;index:idx_user_live_status" json:"status"`
+	Type      ClimbingType `gorm:"type:varchar(20);not null" json:"type"` // 线路未指定类型时使用
+	Location  string       `gorm:"type:varchar(255)" json:"location"`
+	ShareLive bool         `gorm:"not null;default:false" json:"share_live"` // 关注者是否可以实时观看，默认不公开
+
+	StartedAt      time.Time   `json:"started_at"`
+	PausedAt       *time.Time  `json:"paused_at"`                               // 当前暂停开始的时间
+	Pauses         []LivePause `gorm:"serializer:json;type:text" json:"pauses"` // 已结束的暂停
+	LastActivityAt time.Time   `gorm:"index" json:"last_activity_at"`
+	EndedAt        *time.Time  `json:"ended_at"`
+
+	Ascents []LiveAscent `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"ascents"`
+}
+
+// LiveAscent 实时攀岩中完成或尝试的一条线路
+type LiveAscent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"type:int unsigned;not null;index" json:"session_id"`
+	LoggedAt  time.Time `json:"logged_at"`
+
+	Type     ClimbingType `gorm:"type:varchar(20)" json:"type"` // 为空时使用本次攀岩的类型
+	Grade    string       `gorm:"type:varchar(10)" json:"grade"`
+	Color    string       `gorm:"type:varchar(20)" json:"color"`
+	Attempts AttemptRange `gorm:"type:varchar(10)" json:"attempts"`
+	Success  bool         `json:"success"`
+	Style    AscentStyle  `gorm:"type:varchar(20)" json:"style"`
+	Rating   int          `json:"rating"`
+	Notes    string       `gorm:"type:text" json:"notes"`
+
+	RecordID *uint `json:"record_id"` // 结束后生成的攀岩记录
+}
+
+// LivePause 一次暂停
+type LivePause struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ElapsedSeconds 截至 now 的有效攀岩时长 (不含暂停)
+func (s *LiveSession) ElapsedSeconds(now time.Time) int {
+	end := now
+	switch {
+	case s.EndedAt != nil:
+		end = *s.EndedAt
+	case s.PausedAt != nil:
+		end = *s.PausedAt
+	}
+	elapsed := end.Sub(s.StartedAt)
+	for _, p := range s.Pauses {
+		elapsed -= p.End.Sub(p.Start)
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	return int(elapsed.Seconds())
+}
+
+// ClosePause 结束当前暂停并计入已结束的暂停
+func (s *LiveSession) ClosePause(end time.Time) {
+	if s.PausedAt == nil {
+		return
+	}
+	if end.After(*s.PausedAt) {
+		s.Pauses = append(s.Pauses, LivePause{Start: *s.PausedAt, End: end})
+	}
+	s.PausedAt = nil
+}
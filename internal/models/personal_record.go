@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// PersonalRecordCategory 个人纪录类别
+type PersonalRecordCategory string
+
+const (
+	PRHardestSend     PersonalRecordCategory = "hardest_send"      // 最高完成难度
+	PRHardestFlash    PersonalRecordCategory = "hardest_flash"     // 最高首攀难度 (含看攀)
+	PRHardestOnsight  PersonalRecordCategory = "hardest_onsight"   // 最高看攀难度
+	PRLongestSession  PersonalRecordCategory = "longest_session"   // 单次最长时长 (分钟)
+	PRMostSendsDay    PersonalRecordCategory = "most_sends_day"    // 单日最多完成
+	PRMostMinutesWeek PersonalRecordCategory = "most_minutes_week" // 单周最长攀岩时长 (分钟)
+)
+
+// PRLegacyVolumeWeek 旧版按完成数统计的单周纪录，与单日最多完成重复，已由 PRMostMinutesWeek 取代
+const PRLegacyVolumeWeek PersonalRecordCategory = "most_volume_week"
+
+// PersonalRecord 用户个人纪录，每个用户每个类别 (及攀岩类型) 一条
+type PersonalRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint                   `gorm:"type:int unsigned;not null;uniqueIndex:idx_user_pr" json:"user_id"`
+	Category PersonalRecordCategory `gorm:"type:varchar(32);not null;uniqueIndex:idx_user_pr" json:"category"`
+	Type     ClimbingType           `gorm:"type:varchar(20);not null;default:'';uniqueIndex:idx_user_pr" json:"type"` // 为空表示不区分类型
+
+	Value      float64   `json:"value"`                                    // 用于比较的数值 (难度数值、分钟数、完成次数)
+	Display    string    `json:"display"`                                  // 展示值，如 "V6"、"135"
+	RecordID   *uint     `json:"record_id"`                                // 单条记录类纪录对应的攀岩记录
+	Period     string    `gorm:"type:varchar(16)" json:"period,omitempty"` // 按天/周统计的纪录所在周期 (当天或该周第一天的日期)
+	AchievedAt time.Time `json:"achieved_at"`
+}
+
+// MilestoneKind 里程碑类型
+type MilestoneKind string
+
+const (
+	MilestoneSessions MilestoneKind = "sessions" // 累计攀岩次数
+	MilestoneHours    MilestoneKind = "hours"    // 累计攀岩小时数
+	MilestoneSends    MilestoneKind = "sends"    // 累计完成线路数
+)
+
+// Milestone 用户达成的里程碑，达成后不会撤销
+type Milestone struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	UserID    uint          `gorm:"type:int unsigned;not null;uniqueIndex:idx_user_milestone" json:"user_id"`
+	Kind      MilestoneKind `gorm:"type:varchar(20);not null;uniqueIndex:idx_user_milestone" json:"kind"`
+	Threshold int           `gorm:"not null;uniqueIndex:idx_user_milestone" json:"threshold"`
+	RecordID  uint          `json:"record_id"` // 达成时的攀岩记录
+	ReachedAt time.Time     `json:"reached_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ReportPeriod 报告周期
+type ReportPeriod string
+
+const (
+	ReportMonthly ReportPeriod = "monthly"
+	ReportYearly  ReportPeriod = "yearly"
+)
+
+// ReportStatus 报告生成状态
+type ReportStatus string
+
+const (
+	ReportReady  ReportStatus = "ready"
+	ReportFailed ReportStatus = "failed"
+)
+
+// Report 已生成的月度/年度攀岩报告，HTML 和 PDF 内容保存在库中供后续下载
+type Report struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint         `gorm:"type:int unsigned;not null;uniqueIndex:idx_user_report" json:"user_id"`
+	Period    ReportPeriod `gorm:"type:varchar(16);not null;uniqueIndex:idx_user_report" json:"period"`
+	PeriodKey string       `gorm:"type:varchar(7);not null;uniqueIndex:idx_user_report" json:"period_key"` // YYYY-MM 或 YYYY
+	Status    ReportStatus `gorm:"type:varchar(16);not null" json:"status"`
+	Error     string       `gorm:"type:varchar(255)" json:"error,omitempty"`
+	Scheduled bool         `json:"scheduled"` // 是否由定时任务生成
+
+	HTML        string     `gorm:"type:mediumtext" json:"-"`
+	PDF         []byte     `gorm:"type:mediumblob" json:"-"`
+	GeneratedAt *time.Time `json:"generated_at"`
+}
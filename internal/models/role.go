@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 内置角色
+const (
+	RoleAdmin    = "admin"     // 管理员: 全部权限
+	RoleCoach    = "coach"     // 教练: 查看授权学员的数据
+	RoleGymStaff = "gym_staff" // 岩馆工作人员: 目前与攀岩者权限相同
+	RoleAthlete  = "athlete"   // 攀岩者: 管理自己的数据，新用户的默认角色
+)
+
+// 权限，格式为 资源:操作[:范围]，own 为自己的数据，any 为任意用户的数据
+//
+// records:read:own / records:write:own 覆盖自己的全部训练数据 (攀岩记录、实时攀岩、其他训练、目标和训练计划)
+// 以及由此派生的分析、图表、报告、个人纪录和排行榜。
+const (
+	PermRecordsReadOwn  = "records:read:own"
+	PermRecordsWriteOwn = "records:write:own"
+	PermRecordsReadAny  = "records:read:any"
+	PermAthletesRead    = "athletes:read" // 查看授权给自己的学员数据
+	PermUsersReadAny    = "users:read:any"
+	PermRolesRead       = "roles:read"
+	PermRolesWrite      = "roles:write"
+	PermRolesAssign     = "roles:assign"
+	PermAuditRead       = "audit:read"
+	PermAll             = "*"
+)
+
+// BuiltinRoles 内置角色的默认权限，启动时不存在则创建
+var BuiltinRoles = map[string]PermissionList{
+	RoleAdmin:    {PermAll},
+	RoleCoach:    {PermRecordsReadOwn, PermRecordsWriteOwn, PermAthletesRead},
+	RoleGymStaff: {PermRecordsReadOwn, PermRecordsWriteOwn},
+	RoleAthlete:  {PermRecordsReadOwn, PermRecordsWriteOwn},
+}
+
+// Role 角色及其权限
+type Role struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string         `gorm:"type:varchar(32);uniqueIndex;not null" json:"name"`
+	Description string         `gorm:"type:varchar(255)" json:"description"`
+	Builtin     bool           `json:"builtin"` // 内置角色不能删除或改名
+	Permissions PermissionList `gorm:"type:varchar(1024)" json:"permissions"`
+}
+
+// PermissionList 权限列表，数据库中以逗号分隔存储
+type PermissionList []string
+
+// Value 实现 driver.Valuer
+func (p PermissionList) Value() (driver.Value, error) {
+	return strings.Join(p, ","), nil
+}
+
+// Scan 实现 sql.Scanner
+func (p *PermissionList) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*p = PermissionList{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("unsupported permission list type %T", value)
+	}
+
+	*p = PermissionList{}
+	if s != "" {
+		*p = strings.Split(s, ",")
+	}
+	return nil
+}
+
+// AuditLog 管理操作审计日志
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ActorID      uint   `gorm:"type:int unsigned;index" json:"actor_id"`
+	Method       string `gorm:"type:varchar(8)" json:"method"`
+	Route        string `gorm:"type:varchar(255)" json:"route"` // 路由模板，如 /api/admin/users/:id
+	Path         string `gorm:"type:varchar(512)" json:"path"`
+	TargetUserID *uint  `gorm:"type:int unsigned;index" json:"target_user_id"` // 操作涉及的用户
+	Status       int    `json:"status"`
+	IP           string `gorm:"type:varchar(64)" json:"ip"`
+	Body         string `gorm:"type:text" json:"body,omitempty"` // 修改类请求的请求体
+}
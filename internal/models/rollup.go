@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DailyRollup 按用户、日期、攀岩类型和难度预聚合的每日统计
+//
+// Day 为用户时区内的日历日期 (YYYY-MM-DD)，用户修改时区后需要重建。
+// 每条攀岩记录计为一次 session，Grade 为规范化后的难度，空表示未填写。
+type DailyRollup struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint         `gorm:"type:int unsigned;not null;uniqueIndex:idx_user_day_bucket" json:"user_id"`
+	Day    string       `gorm:"type:char(10);not null;uniqueIndex:idx_user_day_bucket" json:"day"`
+	Type   ClimbingType `gorm:"type:varchar(20);not null;uniqueIndex:idx_user_day_bucket" json:"type"`
+	Grade  string       `gorm:"type:varchar(10);not null;default:'';uniqueIndex:idx_user_day_bucket" json:"grade"`
+
+	RollupTotals
+}
+
+// MonthlyRollup 按用户、月份、攀岩类型和难度预聚合的每月统计，由每日统计汇总得到
+type MonthlyRollup struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint         `gorm:"type:int unsigned;not null;uniqueIndex:idx_user_month_bucket" json:"user_id"`
+	Month  string       `gorm:"type:char(7);not null;uniqueIndex:idx_user_month_bucket" json:"month"` // YYYY-MM
+	Type   ClimbingType `gorm:"type:varchar(20);not null;uniqueIndex:idx_user_month_bucket" json:"type"`
+	Grade  string       `gorm:"type:varchar(10);not null;default:'';uniqueIndex:idx_user_month_bucket" json:"grade"`
+
+	RollupTotals
+}
+
+// RollupTotals 预聚合的统计值
+type RollupTotals struct {
+	Sessions     int       `json:"sessions"`
+	Duration     int       `json:"duration"` // 分钟
+	Calories     float64   `json:"calories"`
+	Sends        int       `json:"sends"`
+	LastActivity time.Time `json:"last_activity"` // 桶内最近一次攀岩的开始时间
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ShareLink 分享链接，无需登录即可按保存的参数访问用户的图表等资源
+type ShareLink struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Code      string    `gorm:"type:varchar(16);not null;uniqueIndex" json:"code"` // 由用户、资源和参数确定，同一内容重复分享得到相同链接
+	UserID    uint      `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	Resource  string    `gorm:"type:varchar(64);not null" json:"resource"` // 如 "chart:heatmap"
+	Query     string    `gorm:"type:text" json:"query"`                    // 生成时的查询参数 (URL 编码)
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
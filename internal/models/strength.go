@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HangProtocol 指力板训练方式
+type HangProtocol string
+
+const (
+	ProtocolMaxHang   HangProtocol = "max_hang"  // 最大悬挂: 大负重短时间悬挂
+	ProtocolRepeaters HangProtocol = "repeaters" // 间歇悬挂: 如 7 秒挂 3 秒休 x 6
+	ProtocolOneArm    HangProtocol = "one_arm"   // 单臂悬挂
+	ProtocolCustom    HangProtocol = "custom"    // 其他
+)
+
+// GripType 握法
+type GripType string
+
+const (
+	GripOpenHand  GripType = "open_hand"  // 开掌
+	GripHalfCrimp GripType = "half_crimp" // 半抠
+	GripFullCrimp GripType = "full_crimp" // 全抠
+	GripPinch     GripType = "pinch"      // 捏
+	GripSloper    GripType = "sloper"     // 斜面
+)
+
+// Hand 单臂训练使用的手，双手为空
+type Hand string
+
+const (
+	HandLeft  Hand = "left"
+	HandRight Hand = "right"
+)
+
+// StrengthSession 指力板/力量训练记录
+type StrengthSession struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	UserID    uint      `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	StartTime time.Time `gorm:"index" json:"start_time"`
+	Duration  int       `json:"duration"` // 单位: 分钟
+
+	Protocol    HangProtocol `gorm:"type:varchar(20);not null" json:"protocol"`
+	EdgeSize    float64      `json:"edge_size"` // 边缘深度 (mm)
+	Grip        GripType     `gorm:"type:varchar(20)" json:"grip"`
+	Hand        Hand         `gorm:"type:varchar(8)" json:"hand,omitempty"`
+	AddedWeight float64      `json:"added_weight"` // 附加负重 (kg)，负数表示滑轮减重
+	BodyWeight  float64      `json:"body_weight"`  // 训练时的体重 (kg)，未填写时取个人资料中的体重
+
+	HangTime int `json:"hang_time"` // 每次悬挂时间 (秒)
+	RestTime int `json:"rest_time"` // 每次悬挂之间的休息 (秒)
+	Reps     int `json:"reps"`      // 每组悬挂次数
+	Sets     int `json:"sets"`      // 组数
+	SetRest  int `json:"set_rest"`  // 组间休息 (秒)
+
+	Calories float64 `json:"calories"`                            // 未填写时按时长估算
+	RPE      int     `gorm:"check:rpe>=0 AND rpe<=10" json:"rpe"` // 主观疲劳度 1-10，0 表示未填写
+	Notes    string  `gorm:"type:text" json:"notes"`
+}
+
+// TimeUnderTension 总悬挂时间 (秒)
+func (s *StrengthSession) TimeUnderTension() int {
+	return s.HangTime * s.Reps * s.Sets
+}
+
+// LoadPercent 悬挂负荷占体重的百分比，体重未知时为 0
+func (s *StrengthSession) LoadPercent() float64 {
+	if s.BodyWeight <= 0 {
+		return 0
+	}
+	return (s.BodyWeight + s.AddedWeight) / s.BodyWeight * 100
+}
+
+// BenchmarkKind 力量测试项目
+type BenchmarkKind string
+
+const (
+	BenchmarkMaxHang      BenchmarkKind = "max_hang"      // 最大悬挂，成绩为总负荷占体重的百分比
+	BenchmarkPullUpMax    BenchmarkKind = "pull_up_max"   // 引体向上极限，成绩为估算单次极限负荷占体重的百分比
+	BenchmarkCampusLadder BenchmarkKind = "campus_ladder" // campus 板阶梯，成绩为到达的最高横档
+)
+
+// StrengthBenchmark 力量测试成绩
+type StrengthBenchmark struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint          `gorm:"type:int unsigned;not null;index:idx_user_benchmark" json:"user_id"`
+	Kind     BenchmarkKind `gorm:"type:varchar(20);not null;index:idx_user_benchmark" json:"kind"`
+	TestedAt time.Time     `json:"tested_at"`
+
+	BodyWeight  float64  `json:"body_weight"`  // 测试时的体重 (kg)，未填写时取个人资料中的体重
+	AddedWeight float64  `json:"added_weight"` // 附加负重 (kg)，负数表示减重
+	EdgeSize    float64  `json:"edge_size"`    // 最大悬挂的边缘深度 (mm)
+	Grip        GripType `gorm:"type:varchar(20)" json:"grip"`
+	Hand        Hand     `gorm:"type:varchar(8)" json:"hand,omitempty"`
+	HangTime    int      `json:"hang_time"`                      // 最大悬挂的悬挂时间 (秒)
+	Reps        int      `json:"reps"`                           // 引体向上完成次数，用于估算单次极限
+	Ladder      string   `gorm:"type:varchar(64)" json:"ladder"` // campus 板阶梯的横档序列，如 "1-4-7"
+
+	Score float64 `json:"score"` // 按项目计算的成绩，用于比较和趋势
+	Notes string  `gorm:"type:text" json:"notes"`
+}
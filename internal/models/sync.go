@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// VersionVector 版本向量: 设备ID -> 该设备对实体的修改次数
+type VersionVector map[string]uint64
+
+// Dominates v 是否包含 other 的全部修改 (逐项不小于 other)
+func (v VersionVector) Dominates(other VersionVector) bool {
+	for device, n := range other {
+		if v[device] < n {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge 返回逐项取最大值的新向量
+func (v VersionVector) Merge(other VersionVector) VersionVector {
+	merged := make(VersionVector, len(v)+len(other))
+	for device, n := range v {
+		merged[device] = n
+	}
+	for device, n := range other {
+		if n > merged[device] {
+			merged[device] = n
+		}
+	}
+	return merged
+}
+
+// FieldClock 字段最后一次修改的时间和设备，用于逐字段的最后写入优先
+type FieldClock struct {
+	Time   time.Time `json:"time"`
+	Device string    `json:"device"`
+}
+
+// After c 是否晚于 other，时间相同时按设备ID比较，保证各端结果一致
+func (c FieldClock) After(other FieldClock) bool {
+	if !c.Time.Equal(other.Time) {
+		return c.Time.After(other.Time)
+	}
+	return c.Device > other.Device
+}
+
+// SyncState 实体的同步元数据
+//
+// Seq 为该用户同步序号，每次变更递增，客户端据此增量拉取。
+// 删除的实体保留墓碑 (Deleted)，实体本身通过 gorm.DeletedAt 软删除。
+type SyncState struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint   `gorm:"type:int unsigned;not null;uniqueIndex:idx_sync_client;index:idx_sync_seq" json:"user_id"`
+	Entity   string `gorm:"type:varchar(32);not null;uniqueIndex:idx_sync_entity" json:"entity"`
+	EntityID uint   `gorm:"type:int unsigned;not null;uniqueIndex:idx_sync_entity" json:"entity_id"`
+	ClientID string `gorm:"type:varchar(64);not null;uniqueIndex:idx_sync_client" json:"client_id"` // 客户端生成的ID，服务端创建的实体自动生成
+	Seq      uint64 `gorm:"not null;index:idx_sync_seq" json:"seq"`
+	Deleted  bool   `json:"deleted"`
+
+	Version     VersionVector         `gorm:"serializer:json;type:text" json:"version"`
+	FieldClocks map[string]FieldClock `gorm:"serializer:json;type:text" json:"field_clocks"`
+}
+
+// SyncCursor 用户当前的同步序号，分配序号时锁定该行，保证序号按提交顺序递增
+type SyncCursor struct {
+	UserID uint   `gorm:"primaryKey;type:int unsigned" json:"user_id"`
+	Seq    uint64 `gorm:"not null" json:"seq"`
+}
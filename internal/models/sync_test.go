@@ -0,0 +1,95 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVersionVectorDominates(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     VersionVector
+		other VersionVector
+		want  bool
+	}{
+		{"equal", VersionVector{"a": 1, "b": 2}, VersionVector{"a": 1, "b": 2}, true},
+		{"ahead", VersionVector{"a": 2, "b": 2}, VersionVector{"a": 1, "b": 2}, true},
+		{"behind", VersionVector{"a": 1}, VersionVector{"a": 2}, false},
+		{"concurrent", VersionVector{"a": 2, "b": 1}, VersionVector{"a": 1, "b": 2}, false},
+		{"missing device counts as zero", VersionVector{"a": 1}, VersionVector{"a": 1, "b": 1}, false},
+		{"extra device", VersionVector{"a": 1, "b": 1}, VersionVector{"a": 1}, true},
+		{"zero entry", VersionVector{"a": 1}, VersionVector{"a": 1, "b": 0}, true},
+		{"empty other", VersionVector{"a": 1}, VersionVector{}, true},
+		{"empty dominates empty", VersionVector{}, nil, true},
+		{"nil behind", nil, VersionVector{"a": 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.Dominates(tt.other); got != tt.want {
+				t.Errorf("%v.Dominates(%v) = %v, want %v", tt.v, tt.other, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionVectorMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     VersionVector
+		other VersionVector
+		want  VersionVector
+	}{
+		{"pairwise max", VersionVector{"a": 2, "b": 1}, VersionVector{"a": 1, "b": 3}, VersionVector{"a": 2, "b": 3}},
+		{"union of devices", VersionVector{"a": 1}, VersionVector{"b": 1}, VersionVector{"a": 1, "b": 1}},
+		{"nil receiver", nil, VersionVector{"a": 1}, VersionVector{"a": 1}},
+		{"nil other", VersionVector{"a": 1}, nil, VersionVector{"a": 1}},
+		{"both empty", nil, nil, VersionVector{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.v.Merge(tt.other)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v.Merge(%v) = %v, want %v", tt.v, tt.other, got, tt.want)
+			}
+			if !got.Dominates(tt.v) || !got.Dominates(tt.other) {
+				t.Errorf("merged %v does not dominate both inputs", got)
+			}
+		})
+	}
+
+	// 合并结果是新向量，不修改输入
+	v := VersionVector{"a": 1}
+	v.Merge(VersionVector{"a": 5})
+	if v["a"] != 1 {
+		t.Errorf("Merge modified receiver: %v", v)
+	}
+}
+
+func TestFieldClockAfter(t *testing.T) {
+	t0 := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		c     FieldClock
+		other FieldClock
+		want  bool
+	}{
+		{"later time", FieldClock{t0.Add(time.Second), "a"}, FieldClock{t0, "b"}, true},
+		{"earlier time", FieldClock{t0, "b"}, FieldClock{t0.Add(time.Second), "a"}, false},
+		{"tie broken by device", FieldClock{t0, "b"}, FieldClock{t0, "a"}, true},
+		{"tie lower device", FieldClock{t0, "a"}, FieldClock{t0, "b"}, false},
+		{"identical", FieldClock{t0, "a"}, FieldClock{t0, "a"}, false},
+		{"same instant other zone", FieldClock{t0.In(time.FixedZone("UTC+8", 8*3600)), "b"}, FieldClock{t0, "a"}, true},
+		{"after zero clock", FieldClock{t0, ""}, FieldClock{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.After(tt.other); got != tt.want {
+				t.Errorf("%v.After(%v) = %v, want %v", tt.c, tt.other, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// 记录标签的数量和长度限制，逗号连接后的总长度不超过 tags 列的 varchar(512)
+const (
+	MaxRecordTags      = 20
+	MaxRecordTagLength = 32
+	MaxRecordTagsTotal = 512
+)
+
+// TagList 记录标签，数据库中以逗号分隔存储，便于使用 FIND_IN_SET 筛选
+type TagList []string
+
+// NormalizeTag 标签统一去除首尾空白、转为小写，并去掉逗号
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")))
+}
+
+// Normalize 规范化并去重，保持原有顺序
+func (t TagList) Normalize() TagList {
+	seen := make(map[string]bool)
+	normalized := TagList{}
+	for _, tag := range t {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Value 实现 driver.Valuer
+func (t TagList) Value() (driver.Value, error) {
+	return strings.Join(t.Normalize(), ","), nil
+}
+
+// Scan 实现 sql.Scanner
+func (t *TagList) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*t = TagList{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("unsupported tag list type %T", value)
+	}
+
+	*t = TagList{}
+	if s != "" {
+		*t = strings.Split(s, ",")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TeamRole 团队成员的角色
+type TeamRole string
+
+const (
+	TeamOwner  TeamRole = "owner"  // 创建者: 管理员权限，可以删除团队、转让团队
+	TeamAdmin  TeamRole = "admin"  // 管理员: 管理成员、邀请码和排行榜设置
+	TeamMember TeamRole = "member" // 成员
+)
+
+// Team 团队/俱乐部，成员通过邀请码加入
+type Team struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string `gorm:"type:varchar(100);not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	OwnerID     uint   `gorm:"type:int unsigned;not null;index" json:"owner_id"`
+	InviteCode  string `gorm:"type:varchar(16);uniqueIndex;not null" json:"invite_code,omitempty"` // 仅管理员可见
+
+	// 排行榜设置，为空时启用全部指标和周期
+	LeaderboardMetrics TagList `gorm:"type:varchar(255)" json:"leaderboard_metrics"`
+	LeaderboardPeriods TagList `gorm:"type:varchar(255)" json:"leaderboard_periods"`
+
+	MemberCount int      `gorm:"-" json:"member_count"`
+	Role        TeamRole `gorm:"-" json:"role,omitempty"` // 当前用户在团队中的角色
+}
+
+// TeamMembership 团队成员
+type TeamMembership struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"joined_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TeamID            uint     `gorm:"not null;uniqueIndex:idx_team_member" json:"team_id"`
+	UserID            uint     `gorm:"type:int unsigned;not null;uniqueIndex:idx_team_member;index" json:"user_id"`
+	Role              TeamRole `gorm:"type:varchar(16);not null" json:"role"`
+	LeaderboardOptOut bool     `gorm:"default:false" json:"leaderboard_opt_out"` // 不参与团队排行榜
+	FeedOptOut        bool     `gorm:"default:false" json:"feed_opt_out"`        // 不在团队动态中显示自己的记录
+}
+
+// IsAdmin 成员是否有管理权限
+func (m *TeamMembership) IsAdmin() bool {
+	return m.Role == TeamOwner || m.Role == TeamAdmin
+}
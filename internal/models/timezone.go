@@ -0,0 +1,176 @@
+package models
+
+import "time"
+
+// 数据库统一以 UTC 存储时间，返回给客户端前转换到用户时区，
+// 序列化后为带时区偏移的 RFC 3339 格式 (如 2024-05-01T19:30:00+08:00)。
+
+// LocalizeTimes 将记录的时间转换到 loc 时区
+func (r *ClimbingRecord) LocalizeTimes(loc *time.Location) {
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+	r.StartTime = r.StartTime.In(loc)
+	r.EndTime = r.EndTime.In(loc)
+}
+
+// LocalizeTimes 将用户信息中的时间转换到 loc 时区，生日为日历日期不做转换
+func (u *User) LocalizeTimes(loc *time.Location) {
+	u.CreatedAt = u.CreatedAt.In(loc)
+	u.UpdatedAt = u.UpdatedAt.In(loc)
+	for i := range u.ClimbingRecords {
+		u.ClimbingRecords[i].LocalizeTimes(loc)
+	}
+	for i := range u.PersonalRecords {
+		u.PersonalRecords[i].LocalizeTimes(loc)
+	}
+	for i := range u.Milestones {
+		u.Milestones[i].LocalizeTimes(loc)
+	}
+	for i := range u.Roles {
+		u.Roles[i].LocalizeTimes(loc)
+	}
+}
+
+// LocalizeTimes 将个人纪录的时间转换到 loc 时区
+func (p *PersonalRecord) LocalizeTimes(loc *time.Location) {
+	p.CreatedAt = p.CreatedAt.In(loc)
+	p.UpdatedAt = p.UpdatedAt.In(loc)
+	p.AchievedAt = p.AchievedAt.In(loc)
+}
+
+// LocalizeTimes 将里程碑的时间转换到 loc 时区
+func (m *Milestone) LocalizeTimes(loc *time.Location) {
+	m.ReachedAt = m.ReachedAt.In(loc)
+}
+
+// LocalizeTimes 将报告的时间转换到 loc 时区
+func (r *Report) LocalizeTimes(loc *time.Location) {
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+	if r.GeneratedAt != nil {
+		t := r.GeneratedAt.In(loc)
+		r.GeneratedAt = &t
+	}
+}
+
+// LocalizeTimes 将目标的时间转换到 loc 时区
+func (g *Goal) LocalizeTimes(loc *time.Location) {
+	g.CreatedAt = g.CreatedAt.In(loc)
+	g.UpdatedAt = g.UpdatedAt.In(loc)
+	g.StartDate = g.StartDate.In(loc)
+	if g.Deadline != nil {
+		t := g.Deadline.In(loc)
+		g.Deadline = &t
+	}
+	if g.MetAt != nil {
+		t := g.MetAt.In(loc)
+		g.MetAt = &t
+	}
+}
+
+// LocalizeTimes 将训练计划及其计划训练的时间转换到 loc 时区
+func (p *TrainingPlan) LocalizeTimes(loc *time.Location) {
+	p.CreatedAt = p.CreatedAt.In(loc)
+	p.UpdatedAt = p.UpdatedAt.In(loc)
+	if p.StartDate != nil {
+		t := p.StartDate.In(loc)
+		p.StartDate = &t
+	}
+	for i := range p.Sessions {
+		p.Sessions[i].LocalizeTimes(loc)
+	}
+}
+
+// LocalizeTimes 将计划训练的时间转换到 loc 时区
+func (s *PlannedSession) LocalizeTimes(loc *time.Location) {
+	if s.CompletedAt != nil {
+		t := s.CompletedAt.In(loc)
+		s.CompletedAt = &t
+	}
+	if s.Date != nil {
+		t := s.Date.In(loc)
+		s.Date = &t
+	}
+}
+
+// LocalizeTimes 将力量训练的时间转换到 loc 时区
+func (s *StrengthSession) LocalizeTimes(loc *time.Location) {
+	s.CreatedAt = s.CreatedAt.In(loc)
+	s.UpdatedAt = s.UpdatedAt.In(loc)
+	s.StartTime = s.StartTime.In(loc)
+}
+
+// LocalizeTimes 将力量测试的时间转换到 loc 时区
+func (b *StrengthBenchmark) LocalizeTimes(loc *time.Location) {
+	b.CreatedAt = b.CreatedAt.In(loc)
+	b.UpdatedAt = b.UpdatedAt.In(loc)
+	b.TestedAt = b.TestedAt.In(loc)
+}
+
+// LocalizeTimes 将训练的时间转换到 loc 时区
+func (w *Workout) LocalizeTimes(loc *time.Location) {
+	w.CreatedAt = w.CreatedAt.In(loc)
+	w.UpdatedAt = w.UpdatedAt.In(loc)
+	w.StartTime = w.StartTime.In(loc)
+}
+
+// LocalizeTimes 将实时攀岩及其线路的时间转换到 loc 时区
+func (s *LiveSession) LocalizeTimes(loc *time.Location) {
+	s.CreatedAt = s.CreatedAt.In(loc)
+	s.UpdatedAt = s.UpdatedAt.In(loc)
+	s.StartedAt = s.StartedAt.In(loc)
+	s.LastActivityAt = s.LastActivityAt.In(loc)
+	if s.PausedAt != nil {
+		t := s.PausedAt.In(loc)
+		s.PausedAt = &t
+	}
+	if s.EndedAt != nil {
+		t := s.EndedAt.In(loc)
+		s.EndedAt = &t
+	}
+	for i := range s.Pauses {
+		s.Pauses[i].Start = s.Pauses[i].Start.In(loc)
+		s.Pauses[i].End = s.Pauses[i].End.In(loc)
+	}
+	for i := range s.Ascents {
+		s.Ascents[i].LoggedAt = s.Ascents[i].LoggedAt.In(loc)
+	}
+}
+
+// LocalizeTimes 将历史记录的时间转换到 loc 时区
+func (h *HistoryEntry) LocalizeTimes(loc *time.Location) {
+	h.CreatedAt = h.CreatedAt.In(loc)
+}
+
+// LocalizeTimes 将审计日志的时间转换到 loc 时区
+func (l *AuditLog) LocalizeTimes(loc *time.Location) {
+	l.CreatedAt = l.CreatedAt.In(loc)
+}
+
+// LocalizeTimes 将角色的时间转换到 loc 时区
+func (r *Role) LocalizeTimes(loc *time.Location) {
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+}
+
+// LocalizeTimes 将教练关系的时间转换到 loc 时区
+func (l *CoachLink) LocalizeTimes(loc *time.Location) {
+	l.CreatedAt = l.CreatedAt.In(loc)
+	l.UpdatedAt = l.UpdatedAt.In(loc)
+	if l.RespondedAt != nil {
+		t := l.RespondedAt.In(loc)
+		l.RespondedAt = &t
+	}
+}
+
+// LocalizeTimes 将评论的时间转换到 loc 时区
+func (c *RecordComment) LocalizeTimes(loc *time.Location) {
+	c.CreatedAt = c.CreatedAt.In(loc)
+	c.UpdatedAt = c.UpdatedAt.In(loc)
+}
+
+// LocalizeTimes 将团队的时间转换到 loc 时区
+func (t *Team) LocalizeTimes(loc *time.Location) {
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+}
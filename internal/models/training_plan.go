@@ -0,0 +1,121 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionKind 计划训练的类型
+type SessionKind string
+
+const (
+	SessionClimbing     SessionKind = "climbing"     // 攀岩
+	SessionHangboard    SessionKind = "hangboard"    // 指力板
+	SessionConditioning SessionKind = "conditioning" // 体能训练
+	SessionRest         SessionKind = "rest"         // 休息
+)
+
+// SessionStatus 计划训练的完成状态
+type SessionStatus string
+
+const (
+	StatusUpcoming  SessionStatus = "upcoming"  // 尚未到计划日期
+	StatusCompleted SessionStatus = "completed" // 已完成且达到训练目标
+	StatusPartial   SessionStatus = "partial"   // 已完成但未达到全部训练目标
+	StatusMissed    SessionStatus = "missed"    // 计划日期已过仍未完成
+	StatusRested    SessionStatus = "rested"    // 休息日没有攀岩
+	StatusBroken    SessionStatus = "broken"    // 休息日有攀岩记录
+)
+
+// TrainingPlan 训练计划，由若干周、每周若干天的计划训练组成
+//
+// 模板没有开始日期，不参与记录匹配，只能复制为新的计划或模板。
+type TrainingPlan struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      uint       `gorm:"type:int unsigned;not null;index" json:"user_id"` // 执行计划的用户
+	AuthorID    uint       `gorm:"type:int unsigned;not null" json:"author_id"`     // 编写计划的用户 (本人或教练)
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	IsTemplate  bool       `gorm:"default:false;index" json:"is_template"`
+	Public      bool       `gorm:"default:false" json:"public"` // 模板是否对所有用户可见
+	SourceID    *uint      `json:"source_id,omitempty"`         // 复制来源
+	StartDate   *time.Time `json:"start_date"`                  // 第 1 周第 1 天 (用户时区的零点)，模板为空
+	Weeks       int        `json:"weeks"`
+
+	Sessions []PlannedSession `gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE" json:"sessions"`
+}
+
+// PlannedSession 计划中的一次训练
+//
+// 攀岩训练根据当天的攀岩记录自动匹配完成情况，其他类型需手动标记完成。
+type PlannedSession struct {
+	ID     uint        `gorm:"primaryKey" json:"id"`
+	PlanID uint        `gorm:"type:int unsigned;not null;index" json:"plan_id"`
+	Week   int         `gorm:"not null" json:"week"` // 从 1 开始
+	Day    int         `gorm:"not null" json:"day"`  // 周内第几天 1-7，从计划开始日期起算
+	Kind   SessionKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Title  string      `gorm:"type:varchar(100)" json:"title"`
+	Notes  string      `gorm:"type:text" json:"notes"`
+
+	// 训练目标，0 或空表示不要求
+	Type           ClimbingType `gorm:"type:varchar(20);default:''" json:"type,omitempty"` // 攀岩训练只匹配该类型的记录
+	TargetDuration int          `json:"target_duration"`                                   // 分钟
+	TargetSends    int          `json:"target_sends"`
+	TargetGrade    string       `gorm:"type:varchar(10)" json:"target_grade,omitempty"` // 当天需完成的最低难度
+
+	// 完成情况
+	RecordIDs   IDList     `gorm:"type:varchar(512)" json:"record_ids"` // 匹配到的攀岩记录
+	CompletedAt *time.Time `json:"completed_at"`                        // 匹配到记录或手动标记完成的时间
+	Manual      bool       `gorm:"default:false" json:"manual"`         // 是否为手动标记完成
+	TargetsMet  bool       `gorm:"default:false" json:"targets_met"`    // 完成时是否达到全部训练目标
+
+	Date   *time.Time    `gorm:"-" json:"date,omitempty"`   // 计划日期 (用户时区)，模板为空
+	Status SessionStatus `gorm:"-" json:"status,omitempty"` // 查询时计算，模板为空
+}
+
+// IDList 记录 ID 列表，数据库中以逗号分隔存储
+type IDList []uint
+
+// Value 实现 driver.Valuer
+func (l IDList) Value() (driver.Value, error) {
+	parts := make([]string, len(l))
+	for i, id := range l {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// Scan 实现 sql.Scanner
+func (l *IDList) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*l = IDList{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("unsupported id list type %T", value)
+	}
+
+	*l = IDList{}
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id %q: %v", part, err)
+		}
+		*l = append(*l, uint(id))
+	}
+	return nil
+}
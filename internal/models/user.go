@@ -22,7 +22,22 @@ type User struct {
 	Bio          string     `gorm:"type:text" json:"bio"`
 	Achievements string     `gorm:"type:text" json:"achievements"`
 
+	// 心率参数，用于心率区间和训练负荷计算，未设置时按年龄估算
+	MaxHeartRate     int `json:"max_heart_rate"`
+	RestingHeartRate int `json:"resting_heart_rate"`
+
+	// 时间设置: 按天/周/月的统计都在用户时区内划分
+	Timezone  string `gorm:"type:varchar(64);default:UTC" json:"timezone"` // IANA 时区名，如 Asia/Shanghai
+	WeekStart int    `gorm:"default:1" json:"week_start"`                  // 每周起始日，0 = 周日，1 = 周一
+	Locale    string `gorm:"type:varchar(16);default:zh-CN" json:"locale"` // BCP 47 语言区域
+
+	LeaderboardOptOut bool `gorm:"default:false" json:"leaderboard_opt_out"` // 不参与全站和地点排行榜
+
+	Roles []Role `gorm:"many2many:user_roles" json:"roles,omitempty"`
+
 	ClimbingRecords []ClimbingRecord `json:"climbing_records,omitempty"`
+	PersonalRecords []PersonalRecord `json:"personal_records,omitempty"`
+	Milestones      []Milestone      `json:"milestones,omitempty"`
 }
 
 // Achievement 成就结构
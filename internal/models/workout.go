@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Workout 攀岩和指力板以外的训练，如跑步、瑜伽、举重
+//
+// 各类型的专有数据保存在对应的属性字段中，只有与 Kind 一致的字段有效。
+type Workout struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	UserID    uint         `gorm:"type:int unsigned;not null;index" json:"user_id"`
+	Kind      ActivityKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Title     string       `gorm:"type:varchar(100)" json:"title"`
+	StartTime time.Time    `gorm:"index" json:"start_time"`
+	Duration  int          `json:"duration"` // 单位: 分钟
+
+	Calories     float64 `json:"calories"`                            // 未填写时按类型估算
+	RPE          int     `gorm:"check:rpe>=0 AND rpe<=10" json:"rpe"` // 主观疲劳度 1-10，0 表示未填写
+	AvgHeartRate int     `json:"avg_heart_rate"`                      // 平均心率 (bpm)
+	Notes        string  `gorm:"type:text" json:"notes"`
+
+	Running *RunningAttributes `gorm:"serializer:json;type:text" json:"running,omitempty"`
+	Yoga    *YogaAttributes    `gorm:"serializer:json;type:text" json:"yoga,omitempty"`
+	Lifting *LiftingAttributes `gorm:"serializer:json;type:text" json:"lifting,omitempty"`
+}
+
+// RunningAttributes 跑步数据
+type RunningAttributes struct {
+	Distance      float64 `json:"distance"`       // 公里
+	ElevationGain float64 `json:"elevation_gain"` // 累计爬升 (米)
+	Surface       string  `json:"surface"`        // road/trail/track/treadmill
+}
+
+// Pace 平均配速 (分钟/公里)，距离未知时为 0
+func (a *RunningAttributes) Pace(duration int) float64 {
+	if a == nil || a.Distance <= 0 {
+		return 0
+	}
+	return float64(duration) / a.Distance
+}
+
+// YogaAttributes 瑜伽数据
+type YogaAttributes struct {
+	Style string `json:"style"` // 如 hatha、vinyasa、yin
+	Focus string `json:"focus"` // 如 flexibility、mobility、recovery
+}
+
+// LiftingAttributes 举重/器械训练数据
+type LiftingAttributes struct {
+	Exercises []LiftExercise `json:"exercises"`
+}
+
+// LiftExercise 一个训练动作
+type LiftExercise struct {
+	Name   string  `json:"name"`
+	Sets   int     `json:"sets"`
+	Reps   int     `json:"reps"`
+	Weight float64 `json:"weight"` // kg，自重动作为 0
+}
+
+// Volume 总训练量 (组数 × 次数 × 重量，kg)
+func (a *LiftingAttributes) Volume() float64 {
+	if a == nil {
+		return 0
+	}
+	var volume float64
+	for _, e := range a.Exercises {
+		volume += float64(e.Sets*e.Reps) * e.Weight
+	}
+	return volume
+}
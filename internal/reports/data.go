@@ -0,0 +1,210 @@
+// Package reports 月度/年度攀岩报告的 HTML 和 PDF 渲染。
+//
+// 报告数据由 services.ReportService 汇总，本包只负责排版和多语言标签。
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Data 报告内容，列表类字段的 Key 为标签键或标识 (个人纪录类别、成就 ID 等)
+type Data struct {
+	Lang        string // zh 或 en
+	Username    string
+	Period      string // monthly 或 yearly
+	PeriodKey   string // YYYY-MM 或 YYYY
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+
+	Totals          Totals
+	Changes         []Change // 与上一周期对比
+	Highlights      []Item
+	PersonalRecords []Item
+	NewGrades       []Item
+	Locations       []Item
+	Streak          Streak
+	Achievements    []Item
+	Milestones      []Item
+}
+
+// Totals 周期内的汇总数据
+type Totals struct {
+	Sessions    int
+	ActiveDays  int
+	Duration    int // 分钟
+	Calories    float64
+	Sends       int
+	SuccessRate float64
+}
+
+// Change 指标与上一周期的对比，上一周期为 0 时 PercentChange 为 nil
+type Change struct {
+	Metric        string
+	Current       float64
+	Previous      float64
+	PercentChange *float64
+}
+
+// Item 列表中的一项
+type Item struct {
+	Key   string
+	Title string // 用户数据中的名称 (如成就名、攀岩类型)，可为空
+	Value string
+	Date  time.Time
+}
+
+// Streak 周期内最长的连续攀岩天数
+type Streak struct {
+	Days  int
+	Start string
+	End   string
+}
+
+// Title 报告标题
+func (d Data) Title() string {
+	return fmt.Sprintf(label(d.Lang, "title_"+d.Period), d.PeriodKey)
+}
+
+// Language 根据用户的语言区域选择报告语言
+func Language(locale string) string {
+	if strings.HasPrefix(locale, "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+// labels 报告中的固定文字
+var labels = map[string]map[string]string{
+	"zh": {
+		"title_monthly":      "%s 月度攀岩报告",
+		"title_yearly":       "%s 年度攀岩报告",
+		"range":              "统计范围",
+		"generated_at":       "生成时间",
+		"totals":             "总览",
+		"changes":            "与上一周期对比",
+		"highlights":         "亮点",
+		"personal_records":   "新的个人纪录",
+		"new_grades":         "首次完成的难度",
+		"favorite_locations": "常去的场馆",
+		"streak":             "最长连续攀岩",
+		"achievements":       "解锁的成就",
+		"milestones":         "达成的里程碑",
+		"none":               "暂无",
+		"metric":             "指标",
+		"current":            "本期",
+		"previous":           "上期",
+		"change":             "变化",
+		"sessions":           "攀岩次数",
+		"active_days":        "攀岩天数",
+		"duration":           "总时长 (分钟)",
+		"calories":           "热量 (千卡)",
+		"sends":              "完成线路",
+		"success_rate":       "完成率",
+		"hardest_send":       "最高完成难度",
+		"longest_session":    "最长单次攀岩 (分钟)",
+		"busiest_day":        "攀岩最多的一天",
+		"hardest_flash":      "最高首攀难度",
+		"hardest_onsight":    "最高看攀难度",
+		"most_sends_day":     "单日最多完成",
+		"most_minutes_week":  "单周最长攀岩 (分钟)",
+		"milestone_sessions": "累计攀岩 %s 次",
+		"milestone_hours":    "累计攀岩 %s 小时",
+		"milestone_sends":    "累计完成 %s 条线路",
+		"days":               "%d 天",
+		"bouldering":         "抱石",
+		"sport_climbing":     "运动攀",
+	},
+	"en": {
+		"title_monthly":      "Climbing Recap %s",
+		"title_yearly":       "%s Year in Climbing",
+		"range":              "Period",
+		"generated_at":       "Generated",
+		"totals":             "Overview",
+		"changes":            "Compared with previous period",
+		"highlights":         "Highlights",
+		"personal_records":   "New personal records",
+		"new_grades":         "New grades",
+		"favorite_locations": "Favorite locations",
+		"streak":             "Longest streak",
+		"achievements":       "Achievements unlocked",
+		"milestones":         "Milestones reached",
+		"none":               "None",
+		"metric":             "Metric",
+		"current":            "This period",
+		"previous":           "Previous",
+		"change":             "Change",
+		"sessions":           "Sessions",
+		"active_days":        "Active days",
+		"duration":           "Total minutes",
+		"calories":           "Calories (kcal)",
+		"sends":              "Sends",
+		"success_rate":       "Success rate",
+		"hardest_send":       "Hardest send",
+		"longest_session":    "Longest session (min)",
+		"busiest_day":        "Busiest day",
+		"hardest_flash":      "Hardest flash",
+		"hardest_onsight":    "Hardest onsight",
+		"most_sends_day":     "Most sends in a day",
+		"most_minutes_week":  "Most minutes in a week",
+		"milestone_sessions": "%s sessions",
+		"milestone_hours":    "%s hours",
+		"milestone_sends":    "%s sends",
+		"days":               "%d days",
+		"bouldering":         "Bouldering",
+		"sport_climbing":     "Sport",
+	},
+}
+
+// lookup 查找固定文字，当前语言缺失时使用英文
+func lookup(lang, key string) (string, bool) {
+	if text, ok := labels[lang][key]; ok {
+		return text, true
+	}
+	text, ok := labels["en"][key]
+	return text, ok
+}
+
+// label 固定文字，缺失时返回键本身
+func label(lang, key string) string {
+	if text, ok := lookup(lang, key); ok {
+		return text
+	}
+	return key
+}
+
+// itemLabel 列表项的展示名称，Key 不是标签键时为用户数据 (场馆名、成就 ID 等)
+func itemLabel(lang string, item Item) string {
+	name, ok := lookup(lang, item.Key)
+	if !ok {
+		if item.Title != "" {
+			return item.Title
+		}
+		return item.Key
+	}
+	if strings.HasPrefix(item.Key, "milestone_") {
+		name = fmt.Sprintf(name, item.Value)
+	}
+	if item.Title != "" {
+		name += " (" + label(lang, item.Title) + ")"
+	}
+	return name
+}
+
+// formatChange 百分比变化
+func formatChange(c Change) string {
+	if c.PercentChange == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", *c.PercentChange)
+}
+
+// formatNumber 数值，整数不带小数位
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
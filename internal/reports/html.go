@@ -0,0 +1,49 @@
+package reports
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+//go:embed templates/report.html
+var templateFS embed.FS
+
+var reportTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"date":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"number": formatNumber,
+	"change": formatChange,
+}).ParseFS(templateFS, "templates/report.html"))
+
+// htmlView 模板数据，T、Item 和 Days 按报告语言取文字
+type htmlView struct {
+	Data
+}
+
+func (v htmlView) T(key string) string {
+	return label(v.Lang, key)
+}
+
+func (v htmlView) Item(item Item) string {
+	return itemLabel(v.Lang, item)
+}
+
+// Section 列表小节的模板数据
+func (v htmlView) Section(items []Item) map[string]interface{} {
+	return map[string]interface{}{"View": v, "Items": items}
+}
+
+func (v htmlView) Days(days int) string {
+	return fmt.Sprintf(label(v.Lang, "days"), days)
+}
+
+// RenderHTML 渲染 HTML 报告
+func RenderHTML(data Data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, htmlView{data}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,184 @@
+package reports
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// pdfFontEnv 环境变量，指定包含中文字形的 TrueType 字体路径
+//
+// 未设置时使用 PDF 内置的 Helvetica 字体，只能显示 Latin-1 字符；
+// 中文报告或包含其他字符的用户数据 (地点、线路名、用户名) 无法渲染。
+const pdfFontEnv = "REPORT_PDF_FONT"
+
+// ErrPDFFontRequired 报告内容需要中文字形，但没有配置字体
+var ErrPDFFontRequired = errors.New("report: " + pdfFontEnv + " must point to a TrueType font with CJK glyphs")
+
+// CheckPDFFont 检查按语言区域 locale 生成的报告能否渲染为 PDF，用于启动时检查配置
+func CheckPDFFont(locale string) error {
+	path := os.Getenv(pdfFontEnv)
+	if path == "" {
+		if Language(locale) != "en" {
+			return ErrPDFFontRequired
+		}
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("report: %s: %w", pdfFontEnv, err)
+	}
+	return nil
+}
+
+// A4 纵向去掉左右边距后的可用宽度 (mm)
+const pdfContentWidth = 190.0
+
+type pdfWriter struct {
+	pdf    *fpdf.Fpdf
+	family string
+	lang   string
+	text   func(string) string
+}
+
+// RenderPDF 渲染 PDF 报告，没有配置字体而报告需要中文字形时返回 ErrPDFFontRequired
+func RenderPDF(data Data) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	w := &pdfWriter{pdf: pdf, family: "Helvetica", lang: data.Lang}
+
+	if path := os.Getenv(pdfFontEnv); path != "" {
+		pdf.AddUTF8Font("report", "", path)
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("report: load %s: %w", pdfFontEnv, err)
+		}
+		w.family = "report"
+		w.text = func(s string) string { return s }
+	} else {
+		if data.Lang != "en" || !data.latin1() {
+			return nil, ErrPDFFontRequired
+		}
+		w.text = pdf.UnicodeTranslatorFromDescriptor("")
+	}
+
+	pdf.SetTitle(data.Title(), true)
+	pdf.SetCreator("movePoint", true)
+	pdf.SetMargins(10, 15, 10)
+	pdf.AddPage()
+
+	w.font(20)
+	pdf.CellFormat(0, 12, w.text(data.Title()), "", 1, "L", false, 0, "")
+	w.font(10)
+	pdf.SetTextColor(0x8c, 0x95, 0x9f)
+	meta := fmt.Sprintf("%s  %s %s ~ %s  %s %s", data.Username,
+		label(w.lang, "range"), data.From.Format("2006-01-02"), data.To.Format("2006-01-02"),
+		label(w.lang, "generated_at"), data.GeneratedAt.Format("2006-01-02"))
+	pdf.CellFormat(0, 6, w.text(meta), "", 1, "L", false, 0, "")
+
+	w.heading("totals")
+	totals := [][2]string{
+		{"sessions", strconv.Itoa(data.Totals.Sessions)},
+		{"active_days", strconv.Itoa(data.Totals.ActiveDays)},
+		{"duration", strconv.Itoa(data.Totals.Duration)},
+		{"sends", strconv.Itoa(data.Totals.Sends)},
+		{"success_rate", formatNumber(data.Totals.SuccessRate) + "%"},
+		{"calories", formatNumber(data.Totals.Calories)},
+	}
+	for _, t := range totals {
+		w.row([]string{label(w.lang, t[0]), t[1]}, []float64{120, 70}, "LR")
+	}
+
+	w.heading("changes")
+	w.row([]string{label(w.lang, "metric"), label(w.lang, "current"), label(w.lang, "previous"), label(w.lang, "change")},
+		[]float64{85, 35, 35, 35}, "LRRR")
+	for _, c := range data.Changes {
+		w.row([]string{label(w.lang, c.Metric), formatNumber(c.Current), formatNumber(c.Previous), formatChange(c)},
+			[]float64{85, 35, 35, 35}, "LRRR")
+	}
+
+	w.items("highlights", data.Highlights)
+	w.items("personal_records", data.PersonalRecords)
+	w.items("new_grades", data.NewGrades)
+	w.items("favorite_locations", data.Locations)
+
+	w.heading("streak")
+	if data.Streak.Days > 0 {
+		streak := fmt.Sprintf(label(w.lang, "days"), data.Streak.Days) + fmt.Sprintf(" (%s ~ %s)", data.Streak.Start, data.Streak.End)
+		w.row([]string{streak}, []float64{pdfContentWidth}, "L")
+	} else {
+		w.row([]string{label(w.lang, "none")}, []float64{pdfContentWidth}, "L")
+	}
+
+	w.items("achievements", data.Achievements)
+	w.items("milestones", data.Milestones)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// latin1 报告中的用户数据是否只包含 Latin-1 字符，即内置字体能否完整显示
+func (d Data) latin1() bool {
+	texts := []string{d.Username}
+	for _, items := range [][]Item{d.Highlights, d.PersonalRecords, d.NewGrades, d.Locations, d.Achievements, d.Milestones} {
+		for _, item := range items {
+			texts = append(texts, item.Key, item.Title, item.Value)
+		}
+	}
+	for _, text := range texts {
+		for _, r := range text {
+			if r > 0xff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *pdfWriter) font(size float64) {
+	w.pdf.SetFont(w.family, "", size)
+	w.pdf.SetTextColor(0x24, 0x29, 0x2e)
+}
+
+// heading 小节标题
+func (w *pdfWriter) heading(key string) {
+	w.pdf.Ln(4)
+	w.font(14)
+	w.pdf.SetTextColor(0xf0, 0x6a, 0x2a)
+	w.pdf.CellFormat(0, 9, w.text(label(w.lang, key)), "B", 1, "L", false, 0, "")
+	w.pdf.Ln(1)
+}
+
+// row 表格行，aligns 为每列的对齐方式 (L/R)
+func (w *pdfWriter) row(cols []string, widths []float64, aligns string) {
+	w.font(10)
+	for i, col := range cols {
+		w.pdf.CellFormat(widths[i], 7, w.text(col), "B", 0, aligns[i:i+1], false, 0, "")
+	}
+	w.pdf.Ln(-1)
+}
+
+// items 列表小节，名称无法显示时使用标识
+func (w *pdfWriter) items(key string, items []Item) {
+	w.heading(key)
+	if len(items) == 0 {
+		w.row([]string{label(w.lang, "none")}, []float64{pdfContentWidth}, "L")
+		return
+	}
+	for _, item := range items {
+		name := itemLabel(w.lang, item)
+		if strings.TrimSpace(name) == "" {
+			name = "-"
+		}
+		date := ""
+		if !item.Date.IsZero() {
+			date = item.Date.Format("2006-01-02")
+		}
+		w.row([]string{name, item.Value, date}, []float64{110, 45, 35}, "LRR")
+	}
+}
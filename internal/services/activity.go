@@ -0,0 +1,131 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+)
+
+// calorieRates 各类活动每分钟的估算热量 (kcal)，攀岩按类型细分见 climbingCalorieRates
+var calorieRates = map[models.ActivityKind]float64{
+	models.ActivityClimbing: 10,
+	models.ActivityStrength: 6,
+	models.ActivityRunning:  11,
+	models.ActivityYoga:     4,
+	models.ActivityLifting:  6,
+}
+
+var climbingCalorieRates = map[models.ClimbingType]float64{
+	models.Bouldering:    12, // 抱石强度更大
+	models.SportClimbing: 8,  // 难度攀登更持久但强度略低
+}
+
+// defaultIntensities 未填写 RPE 且无法从难度估算时各类活动的默认强度 (1-10)
+var defaultIntensities = map[models.ActivityKind]float64{
+	models.ActivityClimbing: 5,
+	models.ActivityStrength: 7,
+	models.ActivityRunning:  6,
+	models.ActivityYoga:     3,
+	models.ActivityLifting:  6,
+}
+
+// estimateCalories 按活动类型和时长估算热量，climbingType 只对攀岩有效
+func estimateCalories(kind models.ActivityKind, climbingType models.ClimbingType, duration int) float64 {
+	rate, ok := calorieRates[kind]
+	if !ok {
+		rate = calorieRates[models.ActivityClimbing]
+	}
+	if kind == models.ActivityClimbing {
+		if r, ok := climbingCalorieRates[climbingType]; ok {
+			rate = r
+		}
+	}
+	return rate * float64(duration)
+}
+
+// activityLoad 计算单次活动负荷 (session-RPE 法: 时长 × 强度)
+//
+// 强度优先使用 RPE；攀岩其次根据难度估算；其余使用该类活动的默认强度。
+func activityLoad(a models.Activity, loc *time.Location) SessionLoad {
+	session := SessionLoad{
+		Kind:       a.ActivityKind(),
+		ActivityID: a.ActivityID(),
+		Date:       a.ActivityStart().In(loc).Format("2006-01-02"),
+		Duration:   a.ActivityDuration(),
+	}
+	record, isClimbing := a.(*models.ClimbingRecord)
+	if isClimbing {
+		session.RecordID = record.ID
+	}
+
+	session.Intensity = defaultIntensities[a.ActivityKind()]
+	session.IntensitySource = IntensityFromDefault
+	if a.ActivityRPE() > 0 {
+		session.Intensity = float64(a.ActivityRPE())
+		session.IntensitySource = IntensityFromRPE
+	} else if isClimbing {
+		if info, ok := parseGrade(record.Type, record.Grade); ok {
+			// 难度归一化后映射到 RPE 3-9
+			session.Intensity = round1(3 + 6*gradeDifficulty(info))
+			session.IntensitySource = IntensityFromGrade
+		}
+	}
+
+	session.Load = round1(float64(a.ActivityDuration()) * session.Intensity)
+	return session
+}
+
+// loadActivities 读取 [from, to] 内开始的全部活动，按开始时间排序；kinds 为空时读取全部类型
+func loadActivities(db *gorm.DB, userID uint, from, to time.Time, kinds ...models.ActivityKind) ([]models.Activity, error) {
+	want := make(map[models.ActivityKind]bool)
+	for _, kind := range kinds {
+		want[kind] = true
+	}
+	all := len(want) == 0
+	var activities []models.Activity
+
+	if all || want[models.ActivityClimbing] {
+		var records []models.ClimbingRecord
+		if err := db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for i := range records {
+			activities = append(activities, &records[i])
+		}
+	}
+
+	if all || want[models.ActivityStrength] {
+		var sessions []models.StrengthSession
+		if err := db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).Find(&sessions).Error; err != nil {
+			return nil, err
+		}
+		for i := range sessions {
+			activities = append(activities, &sessions[i])
+		}
+	}
+
+	var workoutKinds []models.ActivityKind
+	for _, kind := range workoutActivityKinds {
+		if all || want[kind] {
+			workoutKinds = append(workoutKinds, kind)
+		}
+	}
+	if len(workoutKinds) > 0 {
+		var workouts []models.Workout
+		err := db.Where("user_id = ? AND kind IN ? AND start_time BETWEEN ? AND ?", userID, workoutKinds, from, to).
+			Find(&workouts).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range workouts {
+			activities = append(activities, &workouts[i])
+		}
+	}
+
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].ActivityStart().Before(activities[j].ActivityStart())
+	})
+	return activities, nil
+}
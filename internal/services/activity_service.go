@@ -0,0 +1,402 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// ErrInvalidWorkout 训练数据无效
+var ErrInvalidWorkout = errors.New("invalid workout")
+
+// workoutActivityKinds 以 Workout 记录的活动类型
+var workoutActivityKinds = []models.ActivityKind{models.ActivityRunning, models.ActivityYoga, models.ActivityLifting}
+
+// 活动统计的分组周期
+const (
+	ActivityGroupWeek  = "week"
+	ActivityGroupMonth = "month"
+)
+
+type ActivityService struct {
+	db *gorm.DB
+}
+
+func NewActivityService(db *gorm.DB) *ActivityService {
+	return &ActivityService{db: db}
+}
+
+// ActivitySummary 活动列表中的一项，不同类型的活动使用相同的结构
+type ActivitySummary struct {
+	Kind      models.ActivityKind `json:"kind"`
+	ID        uint                `json:"id"`
+	Title     string              `json:"title"`
+	StartTime time.Time           `json:"start_time"`
+	Duration  int                 `json:"duration"`
+	Calories  float64             `json:"calories"`
+	Intensity float64             `json:"intensity"`
+	Load      float64             `json:"load"`
+}
+
+// ActivityTotals 活动合计
+type ActivityTotals struct {
+	Sessions int     `json:"sessions"`
+	Duration int     `json:"duration"` // 分钟
+	Calories float64 `json:"calories"`
+	Load     float64 `json:"load"`
+}
+
+func (t *ActivityTotals) add(duration int, calories, load float64) {
+	t.Sessions++
+	t.Duration += duration
+	t.Calories += calories
+	t.Load += load
+}
+
+func (t *ActivityTotals) round() {
+	t.Calories = round1(t.Calories)
+	t.Load = round1(t.Load)
+}
+
+// KindStats 单一活动类型的合计及占全部活动的比例
+type KindStats struct {
+	Kind models.ActivityKind `json:"kind"`
+	ActivityTotals
+	DurationShare float64 `json:"duration_share"` // %
+	LoadShare     float64 `json:"load_share"`     // %
+}
+
+// ActivityPeriod 单个周期的合计和按类型的分项
+type ActivityPeriod struct {
+	Key   string    `json:"key"`
+	Start time.Time `json:"start"`
+	ActivityTotals
+	ByKind map[models.ActivityKind]ActivityTotals `json:"by_kind"`
+}
+
+// ActivityStats 跨活动类型的统计
+type ActivityStats struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	GroupBy string           `json:"group_by"`
+	Totals  ActivityTotals   `json:"totals"`
+	Kinds   []KindStats      `json:"kinds"`
+	Periods []ActivityPeriod `json:"periods"`
+}
+
+// ValidActivityKind 是否为支持的活动类型
+func ValidActivityKind(kind models.ActivityKind) bool {
+	for _, k := range models.ActivityKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWorkout 创建训练记录
+func (s *ActivityService) CreateWorkout(userID uint, workout *models.Workout) error {
+	workout.ID = 0
+	workout.UserID = userID
+	if err := normalizeWorkout(workout); err != nil {
+		return err
+	}
+	return s.db.Create(workout).Error
+}
+
+// GetWorkouts 分页获取训练记录，kind 为空时获取全部类型
+func (s *ActivityService) GetWorkouts(userID uint, kind models.ActivityKind, page, limit int, from, to time.Time) ([]models.Workout, int64, error) {
+	var workouts []models.Workout
+	var total int64
+
+	query := s.db.Where("user_id = ?", userID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if !from.IsZero() {
+		query = query.Where("start_time >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_time <= ?", to)
+	}
+	if err := query.Model(&models.Workout{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("start_time DESC").Offset(offset).Limit(limit).Find(&workouts).Error
+	return workouts, total, err
+}
+
+// GetWorkout 获取单条训练记录
+func (s *ActivityService) GetWorkout(userID, workoutID uint) (*models.Workout, error) {
+	var workout models.Workout
+	err := s.db.Where("user_id = ? AND id = ?", userID, workoutID).First(&workout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &workout, nil
+}
+
+// UpdateWorkout 修改训练记录
+func (s *ActivityService) UpdateWorkout(userID uint, workout *models.Workout) error {
+	existing, err := s.GetWorkout(userID, workout.ID)
+	if err != nil {
+		return err
+	}
+	workout.UserID = userID
+	workout.CreatedAt = existing.CreatedAt
+	if err := normalizeWorkout(workout); err != nil {
+		return err
+	}
+	return s.db.Save(workout).Error
+}
+
+// DeleteWorkout 删除训练记录
+func (s *ActivityService) DeleteWorkout(userID, workoutID uint) error {
+	result := s.db.Where("user_id = ? AND id = ?", userID, workoutID).Delete(&models.Workout{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetActivities 获取 [from, to] 内的全部活动 (攀岩、力量训练和其他训练)，按开始时间倒序
+func (s *ActivityService) GetActivities(userID uint, from, to time.Time, kinds []models.ActivityKind, loc *time.Location) ([]ActivitySummary, error) {
+	activities, err := loadActivities(s.db, userID, from, to, kinds...)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]ActivitySummary, 0, len(activities))
+	for i := len(activities) - 1; i >= 0; i-- {
+		a := activities[i]
+		load := activityLoad(a, loc)
+		list = append(list, ActivitySummary{
+			Kind:      a.ActivityKind(),
+			ID:        a.ActivityID(),
+			Title:     activityTitle(a),
+			StartTime: a.ActivityStart().In(loc),
+			Duration:  a.ActivityDuration(),
+			Calories:  round1(a.ActivityCalories()),
+			Intensity: load.Intensity,
+			Load:      load.Load,
+		})
+	}
+	return list, nil
+}
+
+// GetActivityStats 统计 [from, to] 内各类活动的时长、热量和负荷，按周或月分组
+func (s *ActivityService) GetActivityStats(userID uint, from, to time.Time, settings utils.TimeSettings, groupBy string) (*ActivityStats, error) {
+	if groupBy != ActivityGroupWeek && groupBy != ActivityGroupMonth {
+		return nil, fmt.Errorf("%w: 无效的分组 %s", ErrInvalidFilter, groupBy)
+	}
+	activities, err := loadActivities(s.db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := settings.Location
+	stats := &ActivityStats{From: from.In(loc), To: to.In(loc), GroupBy: groupBy, Kinds: []KindStats{}, Periods: []ActivityPeriod{}}
+
+	periodStart := func(t time.Time) time.Time {
+		if groupBy == ActivityGroupWeek {
+			return utils.StartOfWeek(t, loc, settings.WeekStart)
+		}
+		return utils.StartOfMonth(t, loc)
+	}
+	index := make(map[string]int)
+	for start := periodStart(from); !start.After(to); {
+		key := start.Format("2006-01-02")
+		next := start.AddDate(0, 0, 7)
+		if groupBy == ActivityGroupMonth {
+			key = start.Format("2006-01")
+			next = start.AddDate(0, 1, 0)
+		}
+		index[key] = len(stats.Periods)
+		stats.Periods = append(stats.Periods, ActivityPeriod{Key: key, Start: start, ByKind: map[models.ActivityKind]ActivityTotals{}})
+		start = next
+	}
+
+	kinds := make(map[models.ActivityKind]*ActivityTotals)
+	for _, a := range activities {
+		kind := a.ActivityKind()
+		load := activityLoad(a, loc).Load
+		duration, calories := a.ActivityDuration(), a.ActivityCalories()
+
+		stats.Totals.add(duration, calories, load)
+		if kinds[kind] == nil {
+			kinds[kind] = &ActivityTotals{}
+		}
+		kinds[kind].add(duration, calories, load)
+
+		start := periodStart(a.ActivityStart())
+		key := start.Format("2006-01-02")
+		if groupBy == ActivityGroupMonth {
+			key = start.Format("2006-01")
+		}
+		if i, ok := index[key]; ok {
+			p := &stats.Periods[i]
+			p.ActivityTotals.add(duration, calories, load)
+			byKind := p.ByKind[kind]
+			byKind.add(duration, calories, load)
+			p.ByKind[kind] = byKind
+		}
+	}
+
+	stats.Totals.round()
+	for _, kind := range models.ActivityKinds {
+		t, ok := kinds[kind]
+		if !ok {
+			continue
+		}
+		t.round()
+		stats.Kinds = append(stats.Kinds, KindStats{
+			Kind:           kind,
+			ActivityTotals: *t,
+			DurationShare:  round1(percentage(t.Duration, stats.Totals.Duration)),
+			LoadShare:      round1(shareOf(t.Load, stats.Totals.Load)),
+		})
+	}
+	for i := range stats.Periods {
+		p := &stats.Periods[i]
+		p.ActivityTotals.round()
+		for kind, t := range p.ByKind {
+			t.round()
+			p.ByKind[kind] = t
+		}
+	}
+	return stats, nil
+}
+
+// activityTotalsByKind 用户全部活动按类型的合计，用于个人统计
+func activityTotalsByKind(db *gorm.DB, userID uint) (map[models.ActivityKind]ActivityTotals, error) {
+	type row struct {
+		Kind     models.ActivityKind
+		Sessions int
+		Duration int
+		Calories float64
+	}
+	var rows []row
+
+	var climbing row
+	if err := db.Model(&models.ClimbingRecord{}).
+		Select("COUNT(*) AS sessions, COALESCE(SUM(duration), 0) AS duration, COALESCE(SUM(calories), 0) AS calories").
+		Where("user_id = ?", userID).Scan(&climbing).Error; err != nil {
+		return nil, err
+	}
+	climbing.Kind = models.ActivityClimbing
+	rows = append(rows, climbing)
+
+	var strength row
+	if err := db.Model(&models.StrengthSession{}).
+		Select("COUNT(*) AS sessions, COALESCE(SUM(duration), 0) AS duration, COALESCE(SUM(calories), 0) AS calories").
+		Where("user_id = ?", userID).Scan(&strength).Error; err != nil {
+		return nil, err
+	}
+	strength.Kind = models.ActivityStrength
+	rows = append(rows, strength)
+
+	var workouts []row
+	if err := db.Model(&models.Workout{}).
+		Select("kind, COUNT(*) AS sessions, COALESCE(SUM(duration), 0) AS duration, COALESCE(SUM(calories), 0) AS calories").
+		Where("user_id = ?", userID).Group("kind").Scan(&workouts).Error; err != nil {
+		return nil, err
+	}
+	rows = append(rows, workouts...)
+
+	totals := make(map[models.ActivityKind]ActivityTotals)
+	for _, r := range rows {
+		if r.Sessions == 0 {
+			continue
+		}
+		totals[r.Kind] = ActivityTotals{Sessions: r.Sessions, Duration: r.Duration, Calories: round1(r.Calories)}
+	}
+	return totals, nil
+}
+
+// activityTitle 活动列表中显示的名称
+func activityTitle(a models.Activity) string {
+	switch v := a.(type) {
+	case *models.ClimbingRecord:
+		parts := []string{string(v.Type)}
+		if v.Grade != "" {
+			parts = append(parts, v.Grade)
+		}
+		if v.Location != "" {
+			parts = append(parts, "@ "+v.Location)
+		}
+		return strings.Join(parts, " ")
+	case *models.StrengthSession:
+		return string(v.Protocol)
+	case *models.Workout:
+		if v.Title != "" {
+			return v.Title
+		}
+		return string(v.Kind)
+	}
+	return string(a.ActivityKind())
+}
+
+// normalizeWorkout 校验训练数据，只保留与类型一致的属性，未填写热量时按时长估算
+func normalizeWorkout(w *models.Workout) error {
+	switch w.Kind {
+	case models.ActivityRunning:
+		w.Yoga, w.Lifting = nil, nil
+		if w.Running != nil && (w.Running.Distance < 0 || w.Running.ElevationGain < 0) {
+			return fmt.Errorf("%w: 距离和爬升不能为负数", ErrInvalidWorkout)
+		}
+	case models.ActivityYoga:
+		w.Running, w.Lifting = nil, nil
+	case models.ActivityLifting:
+		w.Running, w.Yoga = nil, nil
+		if w.Lifting != nil {
+			for _, e := range w.Lifting.Exercises {
+				if strings.TrimSpace(e.Name) == "" || e.Sets < 0 || e.Reps < 0 || e.Weight < 0 {
+					return fmt.Errorf("%w: 动作名称不能为空，组数、次数和重量不能为负数", ErrInvalidWorkout)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w: 无效的训练类型 %s，攀岩和指力板训练请使用对应的接口", ErrInvalidWorkout, w.Kind)
+	}
+
+	w.Title = strings.TrimSpace(w.Title)
+	if len([]rune(w.Title)) > 100 {
+		return fmt.Errorf("%w: 训练名称不超过 100 个字符", ErrInvalidWorkout)
+	}
+	if w.StartTime.IsZero() {
+		return fmt.Errorf("%w: 缺少训练时间", ErrInvalidWorkout)
+	}
+	if w.Duration <= 0 {
+		return fmt.Errorf("%w: 训练时长必须大于 0", ErrInvalidWorkout)
+	}
+	if w.RPE < 0 || w.RPE > 10 {
+		return fmt.Errorf("%w: 疲劳度须为 0-10", ErrInvalidWorkout)
+	}
+	if w.Calories < 0 || w.AvgHeartRate < 0 {
+		return fmt.Errorf("%w: 热量和心率不能为负数", ErrInvalidWorkout)
+	}
+	if w.Calories == 0 {
+		w.Calories = estimateCalories(w.Kind, "", w.Duration)
+	}
+	return nil
+}
+
+func shareOf(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+	"movePoint/pkg/middleware"
+)
+
+type AdminService struct {
+	db *gorm.DB
+}
+
+func NewAdminService(db *gorm.DB) *AdminService {
+	return &AdminService{db: db}
+}
+
+// AuditLogFilter 审计日志筛选条件，零值表示不筛选
+type AuditLogFilter struct {
+	ActorID      uint
+	TargetUserID uint
+	From         time.Time
+	To           time.Time
+}
+
+// ListUsers 分页获取用户及其角色，query 按用户名或邮箱模糊匹配
+func (s *AdminService) ListUsers(page, limit int, query string) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	db := s.db.Model(&models.User{})
+	if query = strings.TrimSpace(query); query != "" {
+		like := "%" + query + "%"
+		db = db.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := db.Preload("Roles").Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUser 获取用户及其角色
+func (s *AdminService) GetUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.Preload("Roles").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RecordAudit 保存一条管理操作审计日志，作为 middleware.AuditRecorder 使用
+//
+// /admin/users/:id 下的请求将 :id 记为操作涉及的用户。
+func (s *AdminService) RecordAudit(entry middleware.AuditEntry) error {
+	log := models.AuditLog{
+		ActorID: entry.ActorID,
+		Method:  entry.Method,
+		Route:   entry.Route,
+		Path:    entry.Path,
+		Status:  entry.Status,
+		IP:      entry.IP,
+		Body:    entry.Body,
+	}
+	if strings.Contains(entry.Route, "/admin/users/:id") {
+		if id, err := strconv.ParseUint(entry.Params["id"], 10, 0); err == nil {
+			target := uint(id)
+			log.TargetUserID = &target
+		}
+	}
+	return s.db.Create(&log).Error
+}
+
+// GetAuditLogs 分页获取审计日志，按时间倒序
+func (s *AdminService) GetAuditLogs(filter AuditLogFilter, page, limit int) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var total int64
+
+	db := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetUserID != 0 {
+		db = db.Where("target_user_id = ?", filter.TargetUserID)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("created_at <= ?", filter.To)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := db.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+// 分析缓存默认有效期
+const defaultAnalysisCacheTTL = 24 * time.Hour
+
+// analysisCacheTTL 分析缓存有效期，可通过环境变量 ANALYSIS_CACHE_TTL 覆盖 (如 "6h")
+func analysisCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("ANALYSIS_CACHE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultAnalysisCacheTTL
+}
+
+// analysisCacheKey 缓存键: 时间范围 (UTC)、统计时区和筛选条件
+//
+// 筛选条件可能较长，超出索引长度时使用其摘要。
+func analysisCacheKey(from, to time.Time, loc *time.Location, filter AnalysisFilter) string {
+	key := fmt.Sprintf("climbing|%s|%s|%s|%s", from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), loc.String(), filter.cacheKey())
+	if len(key) > 191 {
+		sum := sha256.Sum256([]byte(key))
+		return "climbing|" + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// Subscribe 订阅攀岩记录和预聚合数据变更事件，使用户的分析缓存失效
+func (s *AnalysisService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted, events.RollupsUpdated} {
+		bus.Subscribe(topic, func(e events.Event) error {
+			return s.InvalidateCache(e.UserID)
+		})
+	}
+}
+
+// InvalidateCache 删除用户的全部分析缓存
+func (s *AnalysisService) InvalidateCache(userID uint) error {
+	s.cacheMu.Lock()
+	s.cacheGen[userID]++
+	s.cacheMu.Unlock()
+
+	return s.db.Where("user_id = ?", userID).Delete(&models.ClimbingAnalysis{}).Error
+}
+
+// CleanupCache 删除过期的分析缓存，返回删除的行数
+func (s *AnalysisService) CleanupCache() (int64, error) {
+	result := s.db.Where("date < ?", time.Now().Add(-analysisCacheTTL())).Delete(&models.ClimbingAnalysis{})
+	return result.RowsAffected, result.Error
+}
+
+// RunCacheCleanup 按 interval 定期清理过期缓存，阻塞运行，应在单独的 goroutine 中调用
+func (s *AnalysisService) RunCacheCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if removed, err := s.CleanupCache(); err != nil {
+			log.Printf("Failed to clean up analysis cache: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d expired analysis cache rows", removed)
+		}
+	}
+}
+
+// cacheGeneration 当前用户的缓存代数
+func (s *AnalysisService) cacheGeneration(userID uint) uint64 {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	return s.cacheGen[userID]
+}
+
+// cachedAnalysis 读取未过期的缓存
+func (s *AnalysisService) cachedAnalysis(userID uint, key string) (*AnalysisData, bool) {
+	var cached models.ClimbingAnalysis
+	err := s.db.Where("user_id = ? AND cache_key = ? AND date >= ?", userID, key, time.Now().Add(-analysisCacheTTL())).
+		Order("date DESC").
+		First(&cached).Error
+	if err != nil {
+		return nil, false
+	}
+
+	var data AnalysisData
+	if err := json.Unmarshal([]byte(cached.Data), &data); err != nil {
+		return nil, false
+	}
+	return &data, true
+}
+
+// cacheAnalysis 缓存分析结果，计算期间缓存已失效 (代数变化) 时放弃写入
+//
+// 锁只保护代数的读取，不在写库期间持有。写入后再次比较代数: 写入期间发生的失效
+// 可能先于本次写入执行了删除，此时删除刚写入的缓存；之后发生的失效会自行删除。
+func (s *AnalysisService) cacheAnalysis(userID uint, key string, from, to time.Time, generation uint64, data *AnalysisData) {
+	if s.cacheGeneration(userID) != generation {
+		return
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to marshal analysis data: %v", err)
+		return
+	}
+
+	analysis := models.ClimbingAnalysis{
+		UserID:    userID,
+		CacheKey:  key,
+		RangeFrom: from,
+		RangeTo:   to,
+		Date:      time.Now(),
+		Data:      string(jsonData),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND cache_key = ?", userID, key).Delete(&models.ClimbingAnalysis{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&analysis).Error
+	})
+	if err != nil {
+		log.Printf("Failed to cache analysis data: %v", err)
+		return
+	}
+
+	if s.cacheGeneration(userID) != generation {
+		if err := s.db.Delete(&analysis).Error; err != nil {
+			log.Printf("Failed to drop stale analysis cache: %v", err)
+		}
+	}
+}
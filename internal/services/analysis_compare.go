@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"movePoint/pkg/utils"
+)
+
+// 对比预设
+const (
+	ComparePresetWeek  = "week"  // 本周 vs 上周
+	ComparePresetMonth = "month" // 本月 vs 上月
+	ComparePresetYear  = "year"  // 今年 vs 去年
+)
+
+// AnalysisRange 分析时间范围 (闭区间)
+type AnalysisRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// AnalysisComparison 两个时间范围的对比分析
+type AnalysisComparison struct {
+	Filter             AnalysisFilter         `json:"filter"`
+	Current            AnalysisWindow         `json:"current"`
+	Previous           AnalysisWindow         `json:"previous"`
+	Summary            map[string]MetricDelta `json:"summary"`
+	HighestGrade       GradeChange            `json:"highest_grade"`
+	GradeDistribution  map[string]GradeDelta  `json:"grade_distribution"`
+	SuccessRateByGrade map[string]MetricDelta `json:"success_rate_by_grade"`
+}
+
+// AnalysisWindow 对比中的一个时间范围及其分析结果
+type AnalysisWindow struct {
+	AnalysisRange
+	Analysis *AnalysisData `json:"analysis"`
+}
+
+// MetricDelta 指标变化，上一周期为 0 时不计算百分比
+type MetricDelta struct {
+	Current       float64  `json:"current"`
+	Previous      float64  `json:"previous"`
+	Delta         float64  `json:"delta"`
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// GradeDelta 单个难度的尝试和完成次数变化
+type GradeDelta struct {
+	Attempts MetricDelta `json:"attempts"`
+	Success  MetricDelta `json:"success"`
+}
+
+// GradeChange 最高难度变化
+type GradeChange struct {
+	Current  string `json:"current"`
+	Previous string `json:"previous"`
+	Improved bool   `json:"improved"`
+}
+
+// PresetRanges 根据预设计算当前和上一周期，周期按用户时区的整天对齐
+func PresetRanges(preset string, now time.Time, settings utils.TimeSettings) (AnalysisRange, AnalysisRange, error) {
+	loc := settings.Location
+	var start time.Time
+	var step func(time.Time, int) time.Time
+	switch preset {
+	case ComparePresetWeek:
+		start = utils.StartOfWeek(now, loc, settings.WeekStart)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) }
+	case ComparePresetMonth:
+		start = utils.StartOfMonth(now, loc)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, n, 0) }
+	case ComparePresetYear:
+		start = time.Date(now.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(n, 0, 0) }
+	default:
+		return AnalysisRange{}, AnalysisRange{}, fmt.Errorf("unknown compare preset: %s", preset)
+	}
+
+	current := AnalysisRange{From: start, To: step(start, 1).Add(-time.Microsecond)}
+	previous := AnalysisRange{From: step(start, -1), To: start.Add(-time.Microsecond)}
+	return current, previous, nil
+}
+
+// PrecedingRange 紧邻 r 之前、天数相同的时间范围
+func PrecedingRange(r AnalysisRange, loc *time.Location) AnalysisRange {
+	from := utils.StartOfDay(r.From, loc)
+	days := daysBetween(from, utils.StartOfDay(r.To, loc)) + 1
+	return AnalysisRange{From: from.AddDate(0, 0, -days), To: r.From.Add(-time.Microsecond)}
+}
+
+// CompareAnalysis 对比两个时间范围的分析结果，两个范围使用相同的筛选条件
+func (s *AnalysisService) CompareAnalysis(userID uint, current, previous AnalysisRange, loc *time.Location, filter AnalysisFilter) (*AnalysisComparison, error) {
+	currentData, err := s.analyzeRange(userID, current.From, current.To, loc, filter)
+	if err != nil {
+		return nil, err
+	}
+	previousData, err := s.analyzeRange(userID, previous.From, previous.To, loc, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &AnalysisComparison{
+		Filter:             filter,
+		Current:            AnalysisWindow{AnalysisRange: AnalysisRange{current.From.In(loc), current.To.In(loc)}, Analysis: currentData},
+		Previous:           AnalysisWindow{AnalysisRange: AnalysisRange{previous.From.In(loc), previous.To.In(loc)}, Analysis: previousData},
+		GradeDistribution:  make(map[string]GradeDelta),
+		SuccessRateByGrade: make(map[string]MetricDelta),
+	}
+
+	cur, prev := currentData.Summary, previousData.Summary
+	curAttempts, curSends := gradeTotals(currentData)
+	prevAttempts, prevSends := gradeTotals(previousData)
+	comparison.Summary = map[string]MetricDelta{
+		"total_sessions": newMetricDelta(float64(cur.TotalSessions), float64(prev.TotalSessions)),
+		"total_duration": newMetricDelta(float64(cur.TotalDuration), float64(prev.TotalDuration)),
+		"total_calories": newMetricDelta(cur.TotalCalories, prev.TotalCalories),
+		"total_sends":    newMetricDelta(float64(curSends), float64(prevSends)),
+		"avg_duration":   newMetricDelta(average(cur.TotalDuration, cur.TotalSessions), average(prev.TotalDuration, prev.TotalSessions)),
+		"success_rate":   newMetricDelta(percentage(curSends, curAttempts), percentage(prevSends, prevAttempts)),
+	}
+
+	comparison.HighestGrade = GradeChange{
+		Current:  cur.HighestGrade,
+		Previous: prev.HighestGrade,
+		Improved: cur.HighestGrade != "" && cur.HighestGrade != prev.HighestGrade && isHigherGrade(cur.HighestGrade, prev.HighestGrade),
+	}
+
+	// 两个周期中出现过的全部难度
+	grades := make(map[string]bool)
+	for grade := range currentData.GradeDistribution {
+		grades[grade] = true
+	}
+	for grade := range previousData.GradeDistribution {
+		grades[grade] = true
+	}
+	for grade := range grades {
+		c, p := currentData.GradeDistribution[grade], previousData.GradeDistribution[grade]
+		comparison.GradeDistribution[grade] = GradeDelta{
+			Attempts: newMetricDelta(float64(c.Attempts), float64(p.Attempts)),
+			Success:  newMetricDelta(float64(c.Success), float64(p.Success)),
+		}
+		comparison.SuccessRateByGrade[grade] = newMetricDelta(currentData.SuccessRateByGrade[grade], previousData.SuccessRateByGrade[grade])
+	}
+
+	return comparison, nil
+}
+
+func newMetricDelta(current, previous float64) MetricDelta {
+	delta := MetricDelta{Current: round2(current), Previous: round2(previous), Delta: round2(current - previous)}
+	if previous != 0 {
+		percent := round1((current - previous) / previous * 100)
+		delta.PercentChange = &percent
+	}
+	return delta
+}
+
+// gradeTotals 有难度记录的总尝试和完成次数
+func gradeTotals(data *AnalysisData) (int, int) {
+	var attempts, sends int
+	for _, stats := range data.GradeDistribution {
+		attempts += stats.Attempts
+		sends += stats.Success
+	}
+	return attempts, sends
+}
+
+func average(total, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(total) / float64(count)
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
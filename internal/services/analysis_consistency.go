@@ -0,0 +1,263 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// 热力图指标
+const (
+	HeatmapSessions = "sessions" // 攀岩次数
+	HeatmapMinutes  = "minutes"  // 攀岩分钟数
+	HeatmapVolume   = "volume"   // 完成线路数
+)
+
+// StreakOptions 连续打卡计算选项
+type StreakOptions struct {
+	RestDays          int          // 允许的连续休息天数，不打断按天连续
+	MinWeeklySessions int          // 按周连续时每周至少的攀岩次数
+	WeekStart         time.Weekday // 每周起始日
+}
+
+// StreakSummary 连续打卡统计
+type StreakSummary struct {
+	RestDays          int    `json:"rest_days"`
+	MinWeeklySessions int    `json:"min_weekly_sessions"`
+	CurrentDaily      Streak `json:"current_daily"`
+	LongestDaily      Streak `json:"longest_daily"`
+	CurrentWeekly     Streak `json:"current_weekly"`
+	LongestWeekly     Streak `json:"longest_weekly"`
+}
+
+// Streak 一段连续打卡，按天时 Length 为有攀岩的天数，按周时为周数
+type Streak struct {
+	Length int    `json:"length"`
+	Start  string `json:"start,omitempty"` // YYYY-MM-DD
+	End    string `json:"end,omitempty"`
+	Active bool   `json:"active"`
+}
+
+// CalendarHeatmap 年度日历热力图
+type CalendarHeatmap struct {
+	Year   int          `json:"year"`
+	Metric string       `json:"metric"`
+	Total  float64      `json:"total"`
+	Max    float64      `json:"max"`
+	Days   []HeatmapDay `json:"days"`
+}
+
+// HeatmapDay 热力图中的一天
+type HeatmapDay struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+	Level int     `json:"level"` // 0-4，用于着色
+}
+
+// ActivityDistribution 星期和时段分布
+type ActivityDistribution struct {
+	Weekdays []DistributionBucket `json:"weekdays"` // 0 = 周日
+	Hours    []DistributionBucket `json:"hours"`    // 0-23
+}
+
+// DistributionBucket 分布桶
+type DistributionBucket struct {
+	Key      int `json:"key"`
+	Sessions int `json:"sessions"`
+	Minutes  int `json:"minutes"`
+	Sends    int `json:"sends"`
+}
+
+// GetStreaks 计算按天和按周的当前/最长连续打卡
+func (s *AnalysisService) GetStreaks(userID uint, loc *time.Location, opts StreakOptions) (*StreakSummary, error) {
+	var startTimes []time.Time
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Where("user_id = ?", userID).
+		Order("start_time ASC").
+		Pluck("start_time", &startTimes).Error; err != nil {
+		return nil, err
+	}
+
+	if opts.RestDays < 0 {
+		opts.RestDays = 0
+	}
+	if opts.MinWeeklySessions < 1 {
+		opts.MinWeeklySessions = 1
+	}
+
+	summary := &StreakSummary{RestDays: opts.RestDays, MinWeeklySessions: opts.MinWeeklySessions}
+	if len(startTimes) == 0 {
+		return summary, nil
+	}
+
+	today := utils.StartOfDay(time.Now(), loc)
+
+	// 按天: 相邻两个攀岩日之间的休息天数不超过 RestDays 视为连续
+	var days []time.Time
+	for _, t := range startTimes {
+		day := utils.StartOfDay(t, loc)
+		if len(days) == 0 || !days[len(days)-1].Equal(day) {
+			days = append(days, day)
+		}
+	}
+	summary.LongestDaily, summary.CurrentDaily = longestAndCurrent(days, opts.RestDays+1, today)
+
+	// 按周: 达到次数要求的周连续出现
+	weekCounts := make(map[time.Time]int)
+	var weeks []time.Time
+	for _, t := range startTimes {
+		week := utils.StartOfWeek(t, loc, opts.WeekStart)
+		if weekCounts[week] == 0 {
+			weeks = append(weeks, week)
+		}
+		weekCounts[week]++
+	}
+	var qualified []time.Time
+	for _, week := range weeks {
+		if weekCounts[week] >= opts.MinWeeklySessions {
+			qualified = append(qualified, week)
+		}
+	}
+	summary.LongestWeekly, summary.CurrentWeekly = longestAndCurrent(qualified, 7, utils.StartOfWeek(time.Now(), loc, opts.WeekStart))
+	// 按周连续的结束日期为最后一周的最后一天
+	for _, streak := range []*Streak{&summary.LongestWeekly, &summary.CurrentWeekly} {
+		if streak.End != "" {
+			end, _ := time.ParseInLocation("2006-01-02", streak.End, loc)
+			streak.End = end.AddDate(0, 0, 6).Format("2006-01-02")
+		}
+	}
+
+	return summary, nil
+}
+
+// longestAndCurrent 在升序排列的周期起点中找出最长和当前连续段
+//
+// 相邻周期相差不超过 maxGap 天视为连续；当前周期与最后一个周期相差不超过
+// maxGap 天时，最后一段仍处于进行中。
+func longestAndCurrent(periods []time.Time, maxGap int, now time.Time) (Streak, Streak) {
+	var longest, current Streak
+	if len(periods) == 0 {
+		return longest, current
+	}
+
+	start, length := 0, 1
+	for i := 1; i <= len(periods); i++ {
+		if i < len(periods) && daysBetween(periods[i-1], periods[i]) <= maxGap {
+			length++
+			continue
+		}
+		if length > longest.Length {
+			longest = Streak{Length: length, Start: periods[start].Format("2006-01-02"), End: periods[i-1].Format("2006-01-02")}
+		}
+		if i < len(periods) {
+			start, length = i, 1
+		}
+	}
+
+	last := periods[len(periods)-1]
+	if daysBetween(last, now) <= maxGap {
+		current = Streak{
+			Length: length,
+			Start:  periods[start].Format("2006-01-02"),
+			End:    last.Format("2006-01-02"),
+			Active: true,
+		}
+		if current.Length == longest.Length && current.Start == longest.Start {
+			longest.Active = true
+		}
+	}
+	return longest, current
+}
+
+// GetCalendarHeatmap 获取指定年份每天的攀岩次数、分钟数或完成线路数
+func (s *AnalysisService) GetCalendarHeatmap(userID uint, year int, metric string, loc *time.Location) (*CalendarHeatmap, error) {
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	var records []models.ClimbingRecord
+	if err := s.db.Select("start_time", "duration", "success").
+		Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, start, end).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	for _, r := range records {
+		key := r.StartTime.In(loc).Format("2006-01-02")
+		switch metric {
+		case HeatmapMinutes:
+			values[key] += float64(r.Duration)
+		case HeatmapVolume:
+			if r.Success {
+				values[key]++
+			}
+		default:
+			values[key]++
+		}
+	}
+
+	heatmap := &CalendarHeatmap{Year: year, Metric: metric, Days: []HeatmapDay{}}
+	for _, v := range values {
+		heatmap.Total += v
+		if v > heatmap.Max {
+			heatmap.Max = v
+		}
+	}
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		value := values[key]
+		level := 0
+		if value > 0 && heatmap.Max > 0 {
+			level = int(math.Ceil(value / heatmap.Max * 4))
+		}
+		heatmap.Days = append(heatmap.Days, HeatmapDay{Date: key, Value: value, Level: level})
+	}
+
+	return heatmap, nil
+}
+
+// GetActivityDistribution 按星期几和一天中的小时统计攀岩分布
+func (s *AnalysisService) GetActivityDistribution(userID uint, from, to time.Time, loc *time.Location) (*ActivityDistribution, error) {
+	var records []models.ClimbingRecord
+	if err := s.db.Select("start_time", "duration", "success").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	dist := &ActivityDistribution{
+		Weekdays: make([]DistributionBucket, 7),
+		Hours:    make([]DistributionBucket, 24),
+	}
+	for i := range dist.Weekdays {
+		dist.Weekdays[i].Key = i
+	}
+	for i := range dist.Hours {
+		dist.Hours[i].Key = i
+	}
+
+	for _, r := range records {
+		t := r.StartTime.In(loc)
+		for _, bucket := range []*DistributionBucket{&dist.Weekdays[t.Weekday()], &dist.Hours[t.Hour()]} {
+			bucket.Sessions++
+			bucket.Minutes += r.Duration
+			if r.Success {
+				bucket.Sends++
+			}
+		}
+	}
+
+	return dist, nil
+}
+
+// daysBetween 两个日期 (零点) 之间相差的天数，跨夏令时也按日历天计算
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+)
+
+// ErrInvalidFilter 筛选条件无效
+var ErrInvalidFilter = errors.New("invalid analysis filter")
+
+// AnalysisFilter 分析筛选条件，零值字段表示不筛选
+type AnalysisFilter struct {
+	Type      models.ClimbingType   `json:"type,omitempty"`
+	Location  string                `json:"location,omitempty"`
+	Style     models.AscentStyle    `json:"style,omitempty"`
+	GradeMin  string                `json:"grade_min,omitempty"` // 难度下限 (含)，只保留同一体系可比较的难度
+	GradeMax  string                `json:"grade_max,omitempty"` // 难度上限 (含)
+	Success   *bool                 `json:"success,omitempty"`
+	Attempts  []models.AttemptRange `json:"attempts,omitempty"` // 尝试次数区间，满足任一即可
+	RatingMin int                   `json:"rating_min,omitempty"`
+	RatingMax int                   `json:"rating_max,omitempty"`
+	Tags      []string              `json:"tags,omitempty"` // 需包含全部标签
+}
+
+// Validate 检查筛选条件中的难度和评分范围
+func (f AnalysisFilter) Validate() error {
+	minGrade, maxGrade, err := f.gradeBounds()
+	if err != nil {
+		return err
+	}
+	if minGrade != nil && maxGrade != nil && (minGrade.Type != maxGrade.Type || minGrade.Level > maxGrade.Level) {
+		return fmt.Errorf("%w: 难度范围无效", ErrInvalidFilter)
+	}
+	if f.RatingMin < 0 || f.RatingMax < 0 || f.RatingMin > 5 || f.RatingMax > 5 ||
+		(f.RatingMax > 0 && f.RatingMin > f.RatingMax) {
+		return fmt.Errorf("%w: 评分范围无效", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// gradeBounds 解析难度上下限，未设置时为 nil
+func (f AnalysisFilter) gradeBounds() (*gradeInfo, *gradeInfo, error) {
+	var bounds [2]*gradeInfo
+	for i, grade := range []string{f.GradeMin, f.GradeMax} {
+		if grade == "" {
+			continue
+		}
+		info, ok := parseGrade(f.Type, grade)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: 无法识别的难度 %s", ErrInvalidFilter, grade)
+		}
+		bounds[i] = &info
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// apply 将可在数据库中完成的筛选条件加到攀岩记录查询上，难度范围需再用 matchGrade 过滤
+func (f AnalysisFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.Location != "" {
+		query = query.Where("location = ?", f.Location)
+	}
+	if f.Style != "" {
+		query = query.Where("style = ?", f.Style)
+	}
+	if f.Success != nil {
+		query = query.Where("success = ?", *f.Success)
+	}
+	if len(f.Attempts) > 0 {
+		query = query.Where("attempts IN ?", f.Attempts)
+	}
+	if f.RatingMin > 0 {
+		query = query.Where("rating >= ?", f.RatingMin)
+	}
+	if f.RatingMax > 0 {
+		query = query.Where("rating <= ?", f.RatingMax)
+	}
+	for _, tag := range f.Tags {
+		query = query.Where("FIND_IN_SET(?, tags) > 0", models.NormalizeTag(tag))
+	}
+	return query
+}
+
+// matchGrade 难度是否在筛选范围内，设置了范围时无法识别或体系不同的难度不匹配
+func (f AnalysisFilter) matchGrade(climbingType models.ClimbingType, grade string) bool {
+	minGrade, maxGrade, err := f.gradeBounds()
+	if err != nil {
+		return false
+	}
+	if minGrade == nil && maxGrade == nil {
+		return true
+	}
+
+	info, ok := parseGrade(climbingType, grade)
+	if !ok {
+		return false
+	}
+	if minGrade != nil && (info.Type != minGrade.Type || info.Level < minGrade.Level) {
+		return false
+	}
+	if maxGrade != nil && (info.Type != maxGrade.Type || info.Level > maxGrade.Level) {
+		return false
+	}
+	return true
+}
+
+// filterRecords 按难度范围过滤已查询的记录
+func (f AnalysisFilter) filterRecords(records []models.ClimbingRecord) []models.ClimbingRecord {
+	if f.GradeMin == "" && f.GradeMax == "" {
+		return records
+	}
+	filtered := records[:0]
+	for _, r := range records {
+		if f.matchGrade(r.Type, r.Grade) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// rollupCompatible 预聚合数据只按类型和难度分桶，只按这两项筛选时可以直接使用
+func (f AnalysisFilter) rollupCompatible() bool {
+	return f.Location == "" && f.Style == "" && f.Success == nil && len(f.Attempts) == 0 &&
+		f.RatingMin == 0 && f.RatingMax == 0 && len(f.Tags) == 0
+}
+
+// matchBucket 预聚合桶是否满足筛选条件 (仅在 rollupCompatible 时使用)
+func (f AnalysisFilter) matchBucket(b rollupBucket) bool {
+	return (f.Type == "" || b.Type == f.Type) && f.matchGrade(b.Type, b.Grade)
+}
+
+// cacheKey 筛选条件在缓存键中的表示
+func (f AnalysisFilter) cacheKey() string {
+	data, _ := json.Marshal(f)
+	return string(data)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"movePoint/internal/models"
+)
+
+// 理想金字塔: 最高难度 1 条，往下每层翻倍
+const pyramidTiers = 4
+
+// gradeLadders 各难度体系的标准难度阶梯，用于补齐金字塔中没有完成记录的难度
+var gradeLadders = map[string][]string{
+	GradeSystemVScale: {"VB", "V0", "V1", "V2", "V3", "V4", "V5", "V6", "V7", "V8", "V9", "V10", "V11", "V12", "V13", "V14", "V15", "V16", "V17"},
+	GradeSystemFont:   {"4", "5", "5+", "6A", "6A+", "6B", "6B+", "6C", "6C+", "7A", "7A+", "7B", "7B+", "7C", "7C+", "8A", "8A+", "8B", "8B+", "8C", "8C+", "9A"},
+	GradeSystemYDS: {"5.6", "5.7", "5.8", "5.9", "5.10a", "5.10b", "5.10c", "5.10d", "5.11a", "5.11b", "5.11c", "5.11d",
+		"5.12a", "5.12b", "5.12c", "5.12d", "5.13a", "5.13b", "5.13c", "5.13d", "5.14a", "5.14b", "5.14c", "5.14d", "5.15a", "5.15b", "5.15c", "5.15d"},
+	GradeSystemFrench: {"5a", "5b", "5c", "6a", "6a+", "6b", "6b+", "6c", "6c+", "7a", "7a+", "7b", "7b+", "7c", "7c+",
+		"8a", "8a+", "8b", "8b+", "8c", "8c+", "9a", "9a+", "9b", "9b+", "9c"},
+}
+
+// GradePyramid 某一攀岩类型的难度金字塔
+type GradePyramid struct {
+	Type     models.ClimbingType `json:"type"`
+	System   string              `json:"system"`
+	TopGrade string              `json:"top_grade"`
+	Levels   []PyramidLevel      `json:"levels"`   // 从最高难度往下排列
+	Complete bool                `json:"complete"` // 理想金字塔各层是否都已填满
+	Unparsed map[string]int      `json:"unparsed"` // 无法识别的难度及其完成次数
+}
+
+// PyramidLevel 金字塔中的一层
+type PyramidLevel struct {
+	Grade    string  `json:"grade"`
+	Level    float64 `json:"level"`
+	Sends    int     `json:"sends"`
+	Attempts int     `json:"attempts"`
+	Ideal    int     `json:"ideal"`   // 理想完成次数，超出理想层数时为 0
+	Deficit  int     `json:"deficit"` // 距离理想还差的完成次数
+}
+
+// GradeProgression 难度进阶分析
+type GradeProgression struct {
+	Type       models.ClimbingType `json:"type"`
+	Months     []MonthlyGrade      `json:"months"`
+	Plateaus   []GradePlateau      `json:"plateaus"`
+	FirstSends []FirstSend         `json:"first_sends"`
+}
+
+// MonthlyGrade 月度完成难度
+type MonthlyGrade struct {
+	Month           string  `json:"month"` // YYYY-MM
+	Sends           int     `json:"sends"`
+	MaxGrade        string  `json:"max_grade"`
+	MedianGrade     string  `json:"median_grade"`
+	RollingMaxGrade string  `json:"rolling_max_grade"` // 截至当月的历史最高完成难度
+	MaxLevel        float64 `json:"max_level"`
+	MedianLevel     float64 `json:"median_level"`
+	RollingMaxLevel float64 `json:"rolling_max_level"`
+}
+
+// GradePlateau 最高完成难度保持不变的阶段
+type GradePlateau struct {
+	Grade   string    `json:"grade"`
+	Level   float64   `json:"level"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Days    int       `json:"days"`
+	Current bool      `json:"current"`
+}
+
+// FirstSend 某难度的首次完成
+type FirstSend struct {
+	Grade    string    `json:"grade"`
+	Level    float64   `json:"level"`
+	Date     time.Time `json:"date"`
+	RecordID uint      `json:"record_id"`
+}
+
+// gradedRecord 带解析后难度的记录
+type gradedRecord struct {
+	record models.ClimbingRecord
+	grade  gradeInfo
+}
+
+// GetGradePyramids 获取各攀岩类型的难度金字塔，climbingType 为空时返回全部类型
+func (s *AnalysisService) GetGradePyramids(userID uint, climbingType models.ClimbingType, from, to time.Time) ([]GradePyramid, error) {
+	query := s.db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to)
+	if climbingType != "" {
+		query = query.Where("type = ?", climbingType)
+	}
+
+	var records []models.ClimbingRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	pyramids := []GradePyramid{}
+	for _, t := range climbingTypes(climbingType) {
+		pyramids = append(pyramids, buildPyramid(t, records))
+	}
+	return pyramids, nil
+}
+
+// GetGradeProgressions 获取各攀岩类型的月度难度进阶、平台期和各难度首次完成日期
+//
+// 首次完成和历史最高需要完整历史，因此读取 to 之前的全部完成记录，
+// 月度数据只输出 [from, to] 范围内的月份。
+func (s *AnalysisService) GetGradeProgressions(userID uint, climbingType models.ClimbingType, from, to time.Time, loc *time.Location) ([]GradeProgression, error) {
+	query := s.db.Where("user_id = ? AND success = ? AND start_time <= ?", userID, true, to)
+	if climbingType != "" {
+		query = query.Where("type = ?", climbingType)
+	}
+
+	var records []models.ClimbingRecord
+	if err := query.Order("start_time ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	progressions := []GradeProgression{}
+	for _, t := range climbingTypes(climbingType) {
+		progressions = append(progressions, buildProgression(t, gradedSends(t, records), from, to, loc))
+	}
+	return progressions, nil
+}
+
+func climbingTypes(climbingType models.ClimbingType) []models.ClimbingType {
+	if climbingType != "" {
+		return []models.ClimbingType{climbingType}
+	}
+	return []models.ClimbingType{models.Bouldering, models.SportClimbing}
+}
+
+// gradedSends 筛选指定类型、难度可识别的完成记录 (保持时间顺序)
+func gradedSends(climbingType models.ClimbingType, records []models.ClimbingRecord) []gradedRecord {
+	var sends []gradedRecord
+	for _, r := range records {
+		if r.Type != climbingType || !r.Success {
+			continue
+		}
+		if info, ok := parseGrade(r.Type, r.Grade); ok {
+			sends = append(sends, gradedRecord{record: r, grade: info})
+		}
+	}
+	return sends
+}
+
+// buildPyramid 以最高完成难度所在体系的难度阶梯构建金字塔
+func buildPyramid(climbingType models.ClimbingType, records []models.ClimbingRecord) GradePyramid {
+	pyramid := GradePyramid{Type: climbingType, Levels: []PyramidLevel{}, Unparsed: map[string]int{}}
+
+	var graded []gradedRecord
+	var top *gradeInfo
+	systems := make(map[string]int)
+	for _, r := range records {
+		if r.Type != climbingType || r.Grade == "" {
+			continue
+		}
+		info, ok := parseGrade(r.Type, r.Grade)
+		if !ok {
+			if r.Success {
+				pyramid.Unparsed[r.Grade]++
+			}
+			continue
+		}
+		graded = append(graded, gradedRecord{record: r, grade: info})
+		if r.Success {
+			systems[info.System]++
+			if top == nil || info.Level > top.Level {
+				current := info
+				top = &current
+			}
+		}
+	}
+	if top == nil {
+		return pyramid
+	}
+
+	// 使用完成次数最多的难度体系作为金字塔阶梯
+	for system, count := range systems {
+		if count > systems[pyramid.System] || (count == systems[pyramid.System] && system < pyramid.System) {
+			pyramid.System = system
+		}
+	}
+
+	// 阶梯中不高于最高完成难度的部分，从高到低
+	var ladder []gradeInfo
+	for _, label := range gradeLadders[pyramid.System] {
+		info, ok := parseGrade(climbingType, label)
+		if ok && info.Level <= top.Level {
+			ladder = append([]gradeInfo{info}, ladder...)
+		}
+	}
+	if len(ladder) == 0 {
+		return pyramid
+	}
+	pyramid.TopGrade = ladder[0].Label
+
+	levels := make([]PyramidLevel, len(ladder))
+	for i, info := range ladder {
+		levels[i] = PyramidLevel{Grade: info.Label, Level: info.Level}
+		if i < pyramidTiers {
+			levels[i].Ideal = 1 << i
+		}
+	}
+
+	// 其他体系的难度按数值归入不高于它的最近一层，高于最高完成难度的尝试不计入
+	for _, g := range graded {
+		if g.grade.Level > top.Level {
+			continue
+		}
+		for i := range levels {
+			if g.grade.Level >= levels[i].Level {
+				levels[i].Attempts++
+				if g.record.Success {
+					levels[i].Sends++
+				}
+				break
+			}
+		}
+	}
+
+	// 去掉低于最低完成难度的空层 (理想层数内的层始终保留)
+	cut := 0
+	for i := range levels {
+		if levels[i].Sends > 0 || i < pyramidTiers {
+			cut = i + 1
+		}
+	}
+	levels = levels[:cut]
+
+	pyramid.Complete = true
+	for i := range levels {
+		if levels[i].Ideal > levels[i].Sends {
+			levels[i].Deficit = levels[i].Ideal - levels[i].Sends
+			pyramid.Complete = false
+		}
+	}
+	pyramid.Levels = levels
+
+	return pyramid
+}
+
+// buildProgression 构建单个攀岩类型的难度进阶
+func buildProgression(climbingType models.ClimbingType, sends []gradedRecord, from, to time.Time, loc *time.Location) GradeProgression {
+	progression := GradeProgression{
+		Type:       climbingType,
+		Months:     []MonthlyGrade{},
+		Plateaus:   []GradePlateau{},
+		FirstSends: []FirstSend{},
+	}
+
+	// 首次完成: 每个难度数值只记第一次
+	seen := make(map[float64]bool)
+	for _, send := range sends {
+		if seen[send.grade.Level] {
+			continue
+		}
+		seen[send.grade.Level] = true
+		progression.FirstSends = append(progression.FirstSends, FirstSend{
+			Grade:    send.grade.Label,
+			Level:    send.grade.Level,
+			Date:     send.record.StartTime.In(loc),
+			RecordID: send.record.ID,
+		})
+	}
+
+	// 平台期: 历史最高难度每次被刷新时开始新阶段
+	var best *gradedRecord
+	for i := range sends {
+		send := &sends[i]
+		if best != nil && send.grade.Level <= best.grade.Level {
+			continue
+		}
+		if n := len(progression.Plateaus); n > 0 {
+			progression.Plateaus[n-1].To = send.record.StartTime.In(loc)
+		}
+		progression.Plateaus = append(progression.Plateaus, GradePlateau{
+			Grade: send.grade.Label,
+			Level: send.grade.Level,
+			From:  send.record.StartTime.In(loc),
+		})
+		best = send
+	}
+	for i := range progression.Plateaus {
+		p := &progression.Plateaus[i]
+		if p.To.IsZero() {
+			p.To = to.In(loc)
+			p.Current = true
+		}
+		p.Days = int(p.To.Sub(p.From).Hours() / 24)
+	}
+
+	// 月度统计
+	byMonth := make(map[string][]gradedRecord)
+	var months []string
+	for _, send := range sends {
+		month := send.record.StartTime.In(loc).Format("2006-01")
+		if _, ok := byMonth[month]; !ok {
+			months = append(months, month)
+		}
+		byMonth[month] = append(byMonth[month], send)
+	}
+
+	var rolling *gradedRecord
+	firstMonth := from.In(loc).Format("2006-01")
+	for _, month := range months {
+		monthSends := byMonth[month]
+		sort.SliceStable(monthSends, func(i, j int) bool {
+			return monthSends[i].grade.Level < monthSends[j].grade.Level
+		})
+
+		hardest := monthSends[len(monthSends)-1]
+		median := monthSends[(len(monthSends)-1)/2]
+		if rolling == nil || hardest.grade.Level > rolling.grade.Level {
+			rolling = &hardest
+		}
+		if month < firstMonth {
+			continue
+		}
+
+		progression.Months = append(progression.Months, MonthlyGrade{
+			Month:           month,
+			Sends:           len(monthSends),
+			MaxGrade:        hardest.grade.Label,
+			MaxLevel:        hardest.grade.Level,
+			MedianGrade:     median.grade.Label,
+			MedianLevel:     median.grade.Level,
+			RollingMaxGrade: rolling.grade.Label,
+			RollingMaxLevel: rolling.grade.Level,
+		})
+	}
+
+	return progression
+}
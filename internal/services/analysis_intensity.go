@@ -0,0 +1,320 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"movePoint/internal/models"
+)
+
+const (
+	defaultMaxHeartRate     = 190
+	defaultRestingHeartRate = 60
+
+	// 相邻采样间隔超过该值视为数据缺口，不计入区间时长
+	maxSampleGap = 30 * time.Second
+	// 心率高于该储备心率比例视为一次发力 (尝试)
+	effortReserveThreshold = 0.6
+	// 持续时间短于该值的高心率片段不算一次尝试
+	minEffortDuration = 10 * time.Second
+)
+
+// heartRateZones 心率区间，按最大心率百分比划分
+var heartRateZones = []struct {
+	Name  string
+	Lower float64
+	Upper float64
+}{
+	{"Z1", 0, 0.6},
+	{"Z2", 0.6, 0.7},
+	{"Z3", 0.7, 0.8},
+	{"Z4", 0.8, 0.9},
+	{"Z5", 0.9, math.MaxFloat64},
+}
+
+// SessionIntensity 单次攀岩的心率强度分析
+type SessionIntensity struct {
+	RecordID         uint               `json:"record_id"`
+	MaxHeartRate     int                `json:"max_heart_rate"`     // 计算使用的最大心率
+	RestingHeartRate int                `json:"resting_heart_rate"` // 计算使用的静息心率
+	AvgBPM           int                `json:"avg_bpm"`
+	PeakBPM          int                `json:"peak_bpm"`
+	Zones            []ZoneTime         `json:"zones"`
+	TRIMP            float64            `json:"trimp"`
+	Efforts          int                `json:"efforts"` // 识别出的发力次数
+	Recoveries       []RecoveryInterval `json:"recoveries"`
+	AvgRestSeconds   float64            `json:"avg_rest_seconds"`
+	AvgRecoveryRate  float64            `json:"avg_recovery_rate"` // 平均心率恢复速度 (bpm/min)
+	Resolution       int                `json:"resolution"`        // 降采样分辨率 (秒)
+	Series           []HeartRateBucket  `json:"series"`
+}
+
+// ZoneTime 心率区间时长
+type ZoneTime struct {
+	Zone    string  `json:"zone"`
+	MinBPM  int     `json:"min_bpm"`
+	MaxBPM  int     `json:"max_bpm"`
+	Seconds int     `json:"seconds"`
+	Percent float64 `json:"percent"`
+}
+
+// RecoveryInterval 两次发力之间的恢复情况
+type RecoveryInterval struct {
+	EffortEnd    time.Time `json:"effort_end"`
+	NextEffort   time.Time `json:"next_effort"`
+	RestSeconds  int       `json:"rest_seconds"`
+	PeakBPM      int       `json:"peak_bpm"`   // 前一次发力的峰值心率
+	LowestBPM    int       `json:"lowest_bpm"` // 休息期间最低心率
+	DropBPM      int       `json:"drop_bpm"`
+	RecoveryRate float64   `json:"recovery_rate"` // bpm/min
+}
+
+// HeartRateBucket 降采样后的心率点
+type HeartRateBucket struct {
+	Time   time.Time `json:"time"`
+	AvgBPM int       `json:"avg_bpm"`
+	MaxBPM int       `json:"max_bpm"`
+}
+
+// GetSessionIntensity 计算单次攀岩的心率区间时长、TRIMP 训练负荷和发力间恢复，
+// 并返回按 resolution 降采样的心率序列
+func (s *AnalysisService) GetSessionIntensity(userID, recordID uint, resolution time.Duration) (*SessionIntensity, error) {
+	_, points, err := NewHeartRateService(s.db).GetSeries(userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxHR, restHR, err := s.heartRateProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolution < time.Second {
+		resolution = 15 * time.Second
+	}
+
+	result := &SessionIntensity{
+		RecordID:         recordID,
+		MaxHeartRate:     maxHR,
+		RestingHeartRate: restHR,
+		Resolution:       int(resolution / time.Second),
+		Recoveries:       []RecoveryInterval{},
+	}
+	if len(points) == 0 {
+		return result, nil
+	}
+
+	result.Zones = zoneTimes(points, maxHR)
+	result.TRIMP = banisterTRIMP(points, maxHR, restHR)
+	result.Series = downsampleHeartRate(points, resolution)
+
+	sum := 0
+	for _, p := range points {
+		sum += p.BPM
+		if p.BPM > result.PeakBPM {
+			result.PeakBPM = p.BPM
+		}
+	}
+	result.AvgBPM = sum / len(points)
+
+	threshold := restHR + int(float64(maxHR-restHR)*effortReserveThreshold)
+	efforts := detectEfforts(points, threshold)
+	result.Efforts = len(efforts)
+	result.Recoveries = recoveryIntervals(points, efforts)
+	if len(result.Recoveries) > 0 {
+		var rest, rate float64
+		for _, r := range result.Recoveries {
+			rest += float64(r.RestSeconds)
+			rate += r.RecoveryRate
+		}
+		result.AvgRestSeconds = rest / float64(len(result.Recoveries))
+		result.AvgRecoveryRate = rate / float64(len(result.Recoveries))
+	}
+
+	return result, nil
+}
+
+// heartRateProfile 获取用户的最大心率和静息心率，未设置时最大心率按 220-年龄 估算
+func (s *AnalysisService) heartRateProfile(userID uint) (int, int, error) {
+	var user models.User
+	if err := s.db.Select("id", "birth_date", "max_heart_rate", "resting_heart_rate").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		return 0, 0, err
+	}
+
+	maxHR := user.MaxHeartRate
+	if maxHR <= 0 {
+		maxHR = defaultMaxHeartRate
+		if user.BirthDate != nil {
+			age := int(time.Since(*user.BirthDate).Hours() / 24 / 365.25)
+			if age > 0 && age < 100 {
+				maxHR = 220 - age
+			}
+		}
+	}
+
+	restHR := user.RestingHeartRate
+	if restHR <= 0 || restHR >= maxHR {
+		restHR = defaultRestingHeartRate
+	}
+
+	return maxHR, restHR, nil
+}
+
+// sampleDurations 每个采样代表的时长 (到下一个采样的间隔，缺口不计)
+func sampleDurations(points []models.HeartRatePoint) []time.Duration {
+	durations := make([]time.Duration, len(points))
+	for i := 0; i < len(points)-1; i++ {
+		gap := points[i+1].Time.Sub(points[i].Time)
+		if gap > 0 && gap <= maxSampleGap {
+			durations[i] = gap
+		}
+	}
+	return durations
+}
+
+// zoneTimes 统计各心率区间的时长
+func zoneTimes(points []models.HeartRatePoint, maxHR int) []ZoneTime {
+	zones := make([]ZoneTime, len(heartRateZones))
+	for i, z := range heartRateZones {
+		zones[i] = ZoneTime{Zone: z.Name, MinBPM: int(z.Lower * float64(maxHR))}
+		if z.Upper < math.MaxFloat64 {
+			zones[i].MaxBPM = int(z.Upper*float64(maxHR)) - 1
+		}
+	}
+
+	var total time.Duration
+	durations := sampleDurations(points)
+	for i, p := range points {
+		ratio := float64(p.BPM) / float64(maxHR)
+		for j, z := range heartRateZones {
+			if ratio >= z.Lower && ratio < z.Upper {
+				zones[j].Seconds += int(durations[i] / time.Second)
+				break
+			}
+		}
+		total += durations[i]
+	}
+
+	if total > 0 {
+		for i := range zones {
+			zones[i].Percent = float64(zones[i].Seconds) / total.Seconds() * 100
+		}
+	}
+	return zones
+}
+
+// banisterTRIMP 计算 Banister TRIMP
+//
+// TRIMP = Σ 时长(分钟) × HRr × 0.64 × e^(1.92 × HRr)，HRr 为储备心率比例。
+// 用户资料中没有性别信息，统一使用男性系数。
+func banisterTRIMP(points []models.HeartRatePoint, maxHR, restHR int) float64 {
+	reserve := float64(maxHR - restHR)
+	if reserve <= 0 {
+		return 0
+	}
+
+	var trimp float64
+	durations := sampleDurations(points)
+	for i, p := range points {
+		hrr := (float64(p.BPM) - float64(restHR)) / reserve
+		if hrr <= 0 {
+			continue
+		}
+		if hrr > 1 {
+			hrr = 1
+		}
+		trimp += durations[i].Minutes() * hrr * 0.64 * math.Exp(1.92*hrr)
+	}
+	return math.Round(trimp*10) / 10
+}
+
+type effort struct {
+	start, end int // 采样下标 (含)
+}
+
+// detectEfforts 识别心率持续高于阈值的发力片段
+func detectEfforts(points []models.HeartRatePoint, threshold int) []effort {
+	var efforts []effort
+	start := -1
+	for i := 0; i <= len(points); i++ {
+		above := i < len(points) && points[i].BPM >= threshold
+		if above && start < 0 {
+			start = i
+		}
+		if !above && start >= 0 {
+			if points[i-1].Time.Sub(points[start].Time) >= minEffortDuration {
+				efforts = append(efforts, effort{start: start, end: i - 1})
+			}
+			start = -1
+		}
+	}
+	return efforts
+}
+
+// recoveryIntervals 计算相邻两次发力之间的休息时长和心率恢复速度
+func recoveryIntervals(points []models.HeartRatePoint, efforts []effort) []RecoveryInterval {
+	intervals := []RecoveryInterval{}
+	for i := 0; i < len(efforts)-1; i++ {
+		cur, next := efforts[i], efforts[i+1]
+
+		peak := 0
+		for k := cur.start; k <= cur.end; k++ {
+			if points[k].BPM > peak {
+				peak = points[k].BPM
+			}
+		}
+
+		lowest, lowestAt := peak, points[cur.end].Time
+		for k := cur.end + 1; k < next.start; k++ {
+			if points[k].BPM < lowest {
+				lowest, lowestAt = points[k].BPM, points[k].Time
+			}
+		}
+
+		interval := RecoveryInterval{
+			EffortEnd:   points[cur.end].Time,
+			NextEffort:  points[next.start].Time,
+			RestSeconds: int(points[next.start].Time.Sub(points[cur.end].Time) / time.Second),
+			PeakBPM:     peak,
+			LowestBPM:   lowest,
+			DropBPM:     peak - lowest,
+		}
+		if minutes := lowestAt.Sub(points[cur.end].Time).Minutes(); minutes > 0 {
+			interval.RecoveryRate = math.Round(float64(interval.DropBPM)/minutes*10) / 10
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// downsampleHeartRate 按固定分辨率对心率序列分桶取平均值和最大值
+func downsampleHeartRate(points []models.HeartRatePoint, resolution time.Duration) []HeartRateBucket {
+	var buckets []HeartRateBucket
+	start := points[0].Time
+
+	var bucketStart time.Time
+	sum, count, maxBPM := 0, 0, 0
+	flush := func() {
+		if count > 0 {
+			buckets = append(buckets, HeartRateBucket{Time: bucketStart, AvgBPM: sum / count, MaxBPM: maxBPM})
+		}
+	}
+
+	for i, p := range points {
+		bs := start.Add(p.Time.Sub(start) / resolution * resolution)
+		if i == 0 || !bs.Equal(bucketStart) {
+			flush()
+			bucketStart, sum, count, maxBPM = bs, 0, 0, 0
+		}
+		sum += p.BPM
+		count++
+		if p.BPM > maxBPM {
+			maxBPM = p.BPM
+		}
+	}
+	flush()
+
+	return buckets
+}
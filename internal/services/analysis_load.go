@@ -0,0 +1,279 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+const (
+	acuteWindowDays   = 7
+	chronicWindowDays = 28
+	// 单日负荷完全相同时标准差为 0，单调性封顶
+	maxMonotony = 10.0
+)
+
+// 负荷来源
+const (
+	IntensityFromRPE     = "rpe"     // 用户填写的主观疲劳度
+	IntensityFromGrade   = "grade"   // 根据难度估算
+	IntensityFromDefault = "default" // 无难度信息时使用默认强度
+)
+
+// 负荷预警类型
+const (
+	LoadWarningACWRHigh = "acwr_high"
+	LoadWarningACWRLow  = "acwr_low"
+	LoadWarningMonotony = "monotony_high"
+	LoadWarningStrain   = "strain_high"
+	LoadWarningSpike    = "load_spike"
+)
+
+// LoadThresholds 负荷预警阈值
+type LoadThresholds struct {
+	ACWRHigh     float64 `json:"acwr_high"`     // 急慢性负荷比上限
+	ACWRLow      float64 `json:"acwr_low"`      // 急慢性负荷比下限 (训练不足)
+	MonotonyMax  float64 `json:"monotony_max"`  // 单调性上限
+	StrainMax    float64 `json:"strain_max"`    // 周应变上限
+	SpikePercent float64 `json:"spike_percent"` // 周负荷环比增幅上限 (%)
+}
+
+// DefaultLoadThresholds 默认阈值，可通过环境变量覆盖
+func DefaultLoadThresholds() LoadThresholds {
+	return LoadThresholds{
+		ACWRHigh:     envFloat("LOAD_ACWR_HIGH", 1.5),
+		ACWRLow:      envFloat("LOAD_ACWR_LOW", 0.8),
+		MonotonyMax:  envFloat("LOAD_MONOTONY_MAX", 2.0),
+		StrainMax:    envFloat("LOAD_STRAIN_MAX", 6000),
+		SpikePercent: envFloat("LOAD_SPIKE_PERCENT", 30),
+	}
+}
+
+// TrainingLoadReport 训练负荷分析结果
+type TrainingLoadReport struct {
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Thresholds LoadThresholds `json:"thresholds"`
+	Sessions   []SessionLoad  `json:"sessions"`
+	Timeline   []DailyLoad    `json:"timeline"`
+	Warnings   []LoadWarning  `json:"warnings"`
+}
+
+// SessionLoad 单次活动负荷 (session-RPE 法: 时长 × 强度)
+type SessionLoad struct {
+	Kind            models.ActivityKind `json:"kind"`
+	ActivityID      uint                `json:"activity_id"`
+	RecordID        uint                `json:"record_id"` // 攀岩记录ID，其他活动为 0
+	Date            string              `json:"date"`
+	Duration        int                 `json:"duration"`
+	Intensity       float64             `json:"intensity"` // 1-10
+	IntensitySource string              `json:"intensity_source"`
+	Load            float64             `json:"load"`
+}
+
+// DailyLoad 每日负荷与准备度
+type DailyLoad struct {
+	Date      string                          `json:"date"`
+	Load      float64                         `json:"load"`
+	Acute     float64                         `json:"acute"`   // 最近7天负荷之和
+	Chronic   float64                         `json:"chronic"` // 最近28天平均周负荷
+	ACWR      float64                         `json:"acwr"`
+	Monotony  float64                         `json:"monotony"`
+	Strain    float64                         `json:"strain"`
+	Readiness float64                         `json:"readiness"`         // 0-100
+	ByKind    map[models.ActivityKind]float64 `json:"by_kind,omitempty"` // 按活动类型的当日负荷
+	Warnings  []string                        `json:"warnings,omitempty"`
+}
+
+// LoadWarning 负荷预警
+type LoadWarning struct {
+	Date      string  `json:"date"`
+	Type      string  `json:"type"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message"`
+}
+
+// GetTrainingLoad 计算训练负荷、急慢性负荷比、单调性、应变和每日准备度
+//
+// 负荷包含攀岩、力量训练和其他训练等全部活动。为计算区间首日的慢性负荷，会额外读取 from 之前 27 天的记录。
+func (s *AnalysisService) GetTrainingLoad(userID uint, from, to time.Time, loc *time.Location, thresholds LoadThresholds) (*TrainingLoadReport, error) {
+	firstDay := utils.StartOfDay(from, loc)
+	lastDay := utils.StartOfDay(to, loc)
+	historyStart := firstDay.AddDate(0, 0, -(chronicWindowDays - 1))
+
+	activities, err := loadActivities(s.db, userID, historyStart, lastDay.AddDate(0, 0, 1).Add(-time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrainingLoadReport{
+		From:       firstDay,
+		To:         lastDay,
+		Thresholds: thresholds,
+		Sessions:   []SessionLoad{},
+		Timeline:   []DailyLoad{},
+		Warnings:   []LoadWarning{},
+	}
+
+	dailyLoads := make(map[string]float64)
+	dailyKinds := make(map[string]map[models.ActivityKind]float64)
+	for _, activity := range activities {
+		session := activityLoad(activity, loc)
+		dailyLoads[session.Date] += session.Load
+		if dailyKinds[session.Date] == nil {
+			dailyKinds[session.Date] = make(map[models.ActivityKind]float64)
+		}
+		dailyKinds[session.Date][session.Kind] += session.Load
+		if !activity.ActivityStart().Before(firstDay) {
+			report.Sessions = append(report.Sessions, session)
+		}
+	}
+
+	// 按天展开负荷序列，下标 0 为 historyStart
+	var loads []float64
+	for day := historyStart; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		loads = append(loads, dailyLoads[day.Format("2006-01-02")])
+	}
+
+	breached := make(map[string]bool)
+	offset := chronicWindowDays - 1
+	for i := offset; i < len(loads); i++ {
+		day := historyStart.AddDate(0, 0, i)
+		acute := windowSum(loads, i, acuteWindowDays)
+		chronic := windowSum(loads, i, chronicWindowDays) / (chronicWindowDays / acuteWindowDays)
+		monotony := loadMonotony(loads[i-acuteWindowDays+1 : i+1])
+
+		daily := DailyLoad{
+			Date:     day.Format("2006-01-02"),
+			Load:     round1(loads[i]),
+			Acute:    round1(acute),
+			Chronic:  round1(chronic),
+			Monotony: round2(monotony),
+			Strain:   round1(acute * monotony),
+		}
+		for kind, load := range dailyKinds[daily.Date] {
+			if daily.ByKind == nil {
+				daily.ByKind = make(map[models.ActivityKind]float64)
+			}
+			daily.ByKind[kind] = round1(load)
+		}
+		if chronic > 0 {
+			daily.ACWR = round2(acute / chronic)
+		}
+		daily.Readiness = readiness(daily.ACWR, daily.Monotony)
+
+		// 与上周同日相比的周负荷增幅
+		var spike float64
+		if i >= acuteWindowDays*2-1 {
+			if previous := windowSum(loads, i-acuteWindowDays, acuteWindowDays); previous > 0 {
+				spike = (acute - previous) / previous * 100
+			}
+		}
+
+		checks := []struct {
+			kind      string
+			value     float64
+			threshold float64
+			hit       bool
+			message   string
+		}{
+			{LoadWarningACWRHigh, daily.ACWR, thresholds.ACWRHigh, thresholds.ACWRHigh > 0 && daily.ACWR > thresholds.ACWRHigh, "急慢性负荷比过高，受伤风险增加"},
+			{LoadWarningACWRLow, daily.ACWR, thresholds.ACWRLow, thresholds.ACWRLow > 0 && chronic > 0 && daily.ACWR < thresholds.ACWRLow, "急慢性负荷比过低，训练量不足"},
+			{LoadWarningMonotony, daily.Monotony, thresholds.MonotonyMax, thresholds.MonotonyMax > 0 && daily.Monotony > thresholds.MonotonyMax, "训练单调性过高，建议安排轻重交替"},
+			{LoadWarningStrain, daily.Strain, thresholds.StrainMax, thresholds.StrainMax > 0 && daily.Strain > thresholds.StrainMax, "周应变过高，注意恢复"},
+			{LoadWarningSpike, round1(spike), thresholds.SpikePercent, thresholds.SpikePercent > 0 && spike > thresholds.SpikePercent, "周负荷增幅过大"},
+		}
+		for _, check := range checks {
+			if !check.hit {
+				breached[check.kind] = false
+				continue
+			}
+			daily.Warnings = append(daily.Warnings, check.kind)
+			// 连续超标只在第一天提示
+			if !breached[check.kind] {
+				report.Warnings = append(report.Warnings, LoadWarning{
+					Date:      daily.Date,
+					Type:      check.kind,
+					Value:     check.value,
+					Threshold: check.threshold,
+					Message:   fmt.Sprintf("%s (%.2f > %.2f)", check.message, check.value, check.threshold),
+				})
+			}
+			breached[check.kind] = true
+		}
+
+		report.Timeline = append(report.Timeline, daily)
+	}
+
+	return report, nil
+}
+
+// windowSum 计算以 end 结尾 (含) 的 size 天负荷之和
+func windowSum(loads []float64, end, size int) float64 {
+	var sum float64
+	for i := end - size + 1; i <= end; i++ {
+		if i >= 0 {
+			sum += loads[i]
+		}
+	}
+	return sum
+}
+
+// loadMonotony 单调性 = 日负荷均值 / 标准差
+func loadMonotony(loads []float64) float64 {
+	var mean float64
+	for _, l := range loads {
+		mean += l
+	}
+	mean /= float64(len(loads))
+	if mean == 0 {
+		return 0
+	}
+
+	var variance float64
+	for _, l := range loads {
+		variance += (l - mean) * (l - mean)
+	}
+	sd := math.Sqrt(variance / float64(len(loads)))
+	if sd == 0 || mean/sd > maxMonotony {
+		return maxMonotony
+	}
+	return mean / sd
+}
+
+// readiness 准备度: 急慢性负荷比超过 1 和单调性超过 1.5 时扣分
+func readiness(acwr, monotony float64) float64 {
+	score := 100.0
+	if acwr > 1 {
+		score -= (acwr - 1) * 100
+	}
+	if monotony > 1.5 {
+		score -= (monotony - 1.5) * 20
+	}
+	if score < 0 {
+		score = 0
+	}
+	return round1(score)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// envFloat 读取浮点型环境变量
+func envFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// 分组维度
+const (
+	GroupByDay      = "day"
+	GroupByWeek     = "week"  // 键为该周第一天
+	GroupByMonth    = "month" // 键为 YYYY-MM
+	GroupByLocation = "location"
+	GroupByType     = "type"
+	GroupByGrade    = "grade"
+)
+
+const (
+	// 单次查询最多的分组维度数
+	maxGroupByDimensions = 3
+	// 补齐空周期时最多生成的周期数，避免超长范围按天分组时生成过多空点
+	maxFilledPeriods = 3660
+)
+
+// AnalysisSeries 按维度分组的统计序列
+type AnalysisSeries struct {
+	GroupBy []string       `json:"group_by"`
+	Filter  AnalysisFilter `json:"filter"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Points  []SeriesPoint  `json:"points"`
+}
+
+// SeriesPoint 序列中的一个分组，Keys 以维度名为键
+type SeriesPoint struct {
+	Keys        map[string]string `json:"keys"`
+	Sessions    int               `json:"sessions"`
+	Duration    int               `json:"duration"`
+	Calories    float64           `json:"calories"`
+	Sends       int               `json:"sends"`
+	SuccessRate float64           `json:"success_rate"`
+}
+
+// seriesItem 参与分组的最小单元 (单条记录或一天的预聚合桶)
+type seriesItem struct {
+	day      string
+	typ      models.ClimbingType
+	grade    string
+	location string
+	models.RollupTotals
+}
+
+// ValidGroupBy 检查分组维度是否合法且不重复
+func ValidGroupBy(groupBy []string) bool {
+	if len(groupBy) == 0 || len(groupBy) > maxGroupByDimensions {
+		return false
+	}
+	seen := make(map[string]bool)
+	timeDims := 0
+	for _, dim := range groupBy {
+		switch dim {
+		case GroupByDay, GroupByWeek, GroupByMonth:
+			timeDims++
+		case GroupByLocation, GroupByType, GroupByGrade:
+		default:
+			return false
+		}
+		if seen[dim] {
+			return false
+		}
+		seen[dim] = true
+	}
+	return timeDims <= 1
+}
+
+// GetAnalysisSeries 按 groupBy 维度分组统计 [from, to] 内满足筛选条件的记录
+//
+// 只有一个时间维度时补齐没有记录的周期，便于直接绘制时间序列。
+func (s *AnalysisService) GetAnalysisSeries(userID uint, from, to time.Time, settings utils.TimeSettings, groupBy []string, filter AnalysisFilter) (*AnalysisSeries, error) {
+	loc := settings.Location
+	items, err := s.seriesItems(userID, from, to, loc, groupBy, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[string]*SeriesPoint)
+	for _, item := range items {
+		keys := make(map[string]string, len(groupBy))
+		parts := make([]string, len(groupBy))
+		for i, dim := range groupBy {
+			keys[dim] = seriesKey(dim, item, settings.WeekStart)
+			parts[i] = keys[dim]
+		}
+		id := strings.Join(parts, "\x00")
+		point, ok := points[id]
+		if !ok {
+			point = &SeriesPoint{Keys: keys}
+			points[id] = point
+		}
+		point.Sessions += item.Sessions
+		point.Duration += item.Duration
+		point.Calories += item.Calories
+		point.Sends += item.Sends
+	}
+
+	// 单一时间维度补齐空周期
+	if len(groupBy) == 1 {
+		if step := periodStep(groupBy[0]); step != nil {
+			start := utils.StartOfDay(from, loc)
+			if groupBy[0] == GroupByWeek {
+				start = utils.StartOfWeek(from, loc, settings.WeekStart)
+			} else if groupBy[0] == GroupByMonth {
+				start = utils.StartOfMonth(from, loc)
+			}
+			for t, n := start, 0; !t.After(to) && n < maxFilledPeriods; t, n = step(t), n+1 {
+				key := t.Format("2006-01-02")
+				if groupBy[0] == GroupByMonth {
+					key = t.Format("2006-01")
+				}
+				if _, ok := points[key]; !ok {
+					points[key] = &SeriesPoint{Keys: map[string]string{groupBy[0]: key}}
+				}
+			}
+		}
+	}
+
+	series := &AnalysisSeries{GroupBy: groupBy, Filter: filter, From: from.In(loc), To: to.In(loc), Points: []SeriesPoint{}}
+	for _, point := range points {
+		point.Calories = round1(point.Calories)
+		point.SuccessRate = round1(percentage(point.Sends, point.Sessions))
+		series.Points = append(series.Points, *point)
+	}
+	sort.Slice(series.Points, func(i, j int) bool {
+		for _, dim := range groupBy {
+			a, b := series.Points[i].Keys[dim], series.Points[j].Keys[dim]
+			if a == b {
+				continue
+			}
+			if dim == GroupByGrade {
+				return gradeKeyLess(a, b)
+			}
+			return a < b
+		}
+		return false
+	})
+
+	return series, nil
+}
+
+// seriesItems 读取分组所需的数据，条件允许时使用每日预聚合数据
+func (s *AnalysisService) seriesItems(userID uint, from, to time.Time, loc *time.Location, groupBy []string, filter AnalysisFilter) ([]seriesItem, error) {
+	byLocation := false
+	for _, dim := range groupBy {
+		byLocation = byLocation || dim == GroupByLocation
+	}
+
+	settings, err := userTimeSettings(s.db, userID)
+	if err == nil && !byLocation && settings.Location.String() == loc.String() && rollupAligned(from, to, loc) && filter.rollupCompatible() {
+		buckets, err := loadDailyRollups(s.db.Where("day BETWEEN ? AND ?", from.In(loc).Format("2006-01-02"), to.In(loc).Format("2006-01-02")), userID)
+		if err != nil {
+			return nil, err
+		}
+		var items []seriesItem
+		for _, b := range buckets {
+			if filter.matchBucket(b) {
+				items = append(items, seriesItem{day: b.Period, typ: b.Type, grade: b.Grade, RollupTotals: b.RollupTotals})
+			}
+		}
+		return items, nil
+	}
+
+	var records []models.ClimbingRecord
+	query := s.db.Select("start_time", "type", "grade", "location", "duration", "calories", "success").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to)
+	if err := filter.apply(query).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	records = filter.filterRecords(records)
+	items := make([]seriesItem, len(records))
+	for i, r := range records {
+		items[i] = seriesItem{
+			day:      r.StartTime.In(loc).Format("2006-01-02"),
+			typ:      r.Type,
+			grade:    gradeBucket(r.Type, r.Grade),
+			location: strings.TrimSpace(r.Location),
+			RollupTotals: models.RollupTotals{
+				Sessions: 1,
+				Duration: r.Duration,
+				Calories: r.Calories,
+			},
+		}
+		if r.Success {
+			items[i].Sends = 1
+		}
+	}
+	return items, nil
+}
+
+// seriesKey 单个维度上的分组键
+func seriesKey(dim string, item seriesItem, weekStart time.Weekday) string {
+	switch dim {
+	case GroupByDay:
+		return item.day
+	case GroupByWeek:
+		day, _ := time.Parse("2006-01-02", item.day)
+		return utils.StartOfWeek(day, time.UTC, weekStart).Format("2006-01-02")
+	case GroupByMonth:
+		return item.day[:7]
+	case GroupByLocation:
+		return item.location
+	case GroupByType:
+		return string(item.typ)
+	case GroupByGrade:
+		return item.grade
+	}
+	return ""
+}
+
+// periodStep 时间维度的步进函数，非时间维度返回 nil
+func periodStep(dim string) func(time.Time) time.Time {
+	switch dim {
+	case GroupByDay:
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case GroupByWeek:
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case GroupByMonth:
+		return func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+	return nil
+}
+
+// gradeKeyLess 难度键排序: 可识别的难度按类型和数值，其余按字符串排在后面
+func gradeKeyLess(a, b string) bool {
+	infoA, okA := parseGrade("", a)
+	infoB, okB := parseGrade("", b)
+	switch {
+	case okA && okB:
+		if infoA.Type != infoB.Type {
+			return infoA.Type < infoB.Type
+		}
+		if infoA.Level != infoB.Level {
+			return infoA.Level < infoB.Level
+		}
+		return a < b
+	case okA != okB:
+		return okA
+	}
+	return a < b
+}
@@ -1,8 +1,8 @@
 package services
 
 import (
-	"encoding/json"
-	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,10 +11,14 @@ import (
 
 type AnalysisService struct {
 	db *gorm.DB
+
+	// 每个用户的缓存代数，缓存失效时递增，避免计算期间失效的结果被写回缓存
+	cacheMu  sync.Mutex
+	cacheGen map[uint]uint64
 }
 
 func NewAnalysisService(db *gorm.DB) *AnalysisService {
-	return &AnalysisService{db: db}
+	return &AnalysisService{db: db, cacheGen: make(map[uint]uint64)}
 }
 
 // AnalysisData 分析结果数据结构
@@ -43,62 +47,96 @@ type MonthlyStat struct {
 	Duration int    `json:"duration"`
 }
 
-// GetClimbingAnalysis 获取用户攀岩数据分析
-func (s *AnalysisService) GetClimbingAnalysis(userID uint, from, to time.Time) (*AnalysisData, error) {
-	var records []models.ClimbingRecord
+// GetClimbingAnalysis 获取用户攀岩数据分析，月度趋势按 loc 时区划分月份
+func (s *AnalysisService) GetClimbingAnalysis(userID uint, from, to time.Time, loc *time.Location, filter AnalysisFilter) (*AnalysisData, error) {
+	return s.analyzeRange(userID, from, to, loc, filter)
+}
 
-	// 查询时间范围内的记录
-	query := s.db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to)
-	if err := query.Find(&records).Error; err != nil {
+// analyzeRange 分析时间范围内满足筛选条件的记录
+//
+// 结果按用户、时间范围、时区和筛选条件缓存。范围恰好覆盖用户时区内的整天、
+// 且只按类型和难度筛选时从每日/每月预聚合数据计算，否则 (如 tz 参数与用户设置不同) 回退到扫描记录。
+func (s *AnalysisService) analyzeRange(userID uint, from, to time.Time, loc *time.Location, filter AnalysisFilter) (*AnalysisData, error) {
+	key := analysisCacheKey(from, to, loc, filter)
+	if cached, ok := s.cachedAnalysis(userID, key); ok {
+		return cached, nil
+	}
+	generation := s.cacheGeneration(userID)
+
+	buckets, err := s.analysisBuckets(userID, from, to, loc, filter)
+	if err != nil {
 		return nil, err
 	}
 
 	// 生成分析数据
-	analysis := s.analyzeRecords(records)
+	analysis := analyzeBuckets(buckets)
 
-	// 缓存分析结果 (可选)
-	go s.cacheAnalysis(userID, analysis)
+	s.cacheAnalysis(userID, key, from, to, generation, analysis)
 
 	return analysis, nil
 }
 
-// analyzeRecords 分析记录数据
-func (s *AnalysisService) analyzeRecords(records []models.ClimbingRecord) *AnalysisData {
+// analysisBuckets 获取时间范围内按天 (或整月)、类型和难度聚合的数据
+func (s *AnalysisService) analysisBuckets(userID uint, from, to time.Time, loc *time.Location, filter AnalysisFilter) ([]rollupBucket, error) {
+	settings, err := userTimeSettings(s.db, userID)
+	if err == nil && settings.Location.String() == loc.String() && rollupAligned(from, to, loc) && filter.rollupCompatible() {
+		buckets, err := loadRollupRange(s.db, userID, from.In(loc).Format("2006-01-02"), to.In(loc).Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		filtered := buckets[:0]
+		for _, b := range buckets {
+			if filter.matchBucket(b) {
+				filtered = append(filtered, b)
+			}
+		}
+		return filtered, nil
+	}
+
+	var records []models.ClimbingRecord
+	query := s.db.Select("start_time", "type", "grade", "duration", "calories", "success").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to)
+	if err := filter.apply(query).Order("start_time ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return bucketRecords(filter.filterRecords(records), loc, "2006-01-02"), nil
+}
+
+// analyzeBuckets 分析聚合数据，每个桶的 Period 为 YYYY-MM-DD 或 YYYY-MM
+func analyzeBuckets(buckets []rollupBucket) *AnalysisData {
 	var data AnalysisData
 	gradeStats := make(map[string]GradeStats)
 	monthlyStats := make(map[string]MonthlyStat)
 
 	// 初始化分析
-	data.Summary.TotalSessions = len(records)
 	data.GradeDistribution = make(map[string]GradeStats)
 	data.SuccessRateByGrade = make(map[string]float64)
 
-	for _, record := range records {
+	for _, bucket := range buckets {
 		// 汇总统计
-		data.Summary.TotalDuration += record.Duration
-		data.Summary.TotalCalories += record.Calories
+		data.Summary.TotalSessions += bucket.Sessions
+		data.Summary.TotalDuration += bucket.Duration
+		data.Summary.TotalCalories += bucket.Calories
 
 		// 更新最高难度
-		if isHigherGrade(record.Grade, data.Summary.HighestGrade) {
-			data.Summary.HighestGrade = record.Grade
+		if isHigherGrade(bucket.Grade, data.Summary.HighestGrade) {
+			data.Summary.HighestGrade = bucket.Grade
 		}
 
-		// 按难度等级统计
-		if record.Grade != "" {
-			stats := gradeStats[record.Grade]
-			stats.Attempts++
-			if record.Success {
-				stats.Success++
-			}
-			gradeStats[record.Grade] = stats
+		// 按难度等级统计，每条记录计为一次尝试
+		if bucket.Grade != "" {
+			stats := gradeStats[bucket.Grade]
+			stats.Attempts += bucket.Sessions
+			stats.Success += bucket.Sends
+			gradeStats[bucket.Grade] = stats
 		}
 
 		// 按月统计
-		month := record.StartTime.Format("2006-01")
+		month := bucket.Period[:7]
 		stat := monthlyStats[month]
 		stat.Month = month
-		stat.Sessions++
-		stat.Duration += record.Duration
+		stat.Sessions += bucket.Sessions
+		stat.Duration += bucket.Duration
 		monthlyStats[month] = stat
 	}
 
@@ -114,33 +152,27 @@ func (s *AnalysisService) analyzeRecords(records []models.ClimbingRecord) *Analy
 	for _, stat := range monthlyStats {
 		data.MonthlyTrends = append(data.MonthlyTrends, stat)
 	}
+	sort.Slice(data.MonthlyTrends, func(i, j int) bool {
+		return data.MonthlyTrends[i].Month < data.MonthlyTrends[j].Month
+	})
 
 	return &data
 }
 
-// cacheAnalysis 缓存分析结果
-func (s *AnalysisService) cacheAnalysis(userID uint, data *AnalysisData) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		fmt.Printf("Failed to marshal analysis data: %v\n", err)
-		return
-	}
-
-	analysis := models.ClimbingAnalysis{
-		UserID: userID,
-		Date:   time.Now(),
-		Data:   string(jsonData),
-	}
-
-	s.db.Create(&analysis)
-}
-
 // isHigherGrade 比较两个难度等级
 func isHigherGrade(grade1, grade2 string) bool {
-	// 这里需要实现攀岩难度的比较逻辑
-	// 简化处理: 只比较字符串长度 (实际应解析难度等级)
 	if grade2 == "" {
 		return true
 	}
+
+	// 能识别的难度按难度数值比较，抱石与难度攀登之间不可比，保持原值
+	info1, ok1 := parseGrade("", grade1)
+	info2, ok2 := parseGrade("", grade2)
+	if ok1 && ok2 {
+		return info1.Type == info2.Type && info1.Level > info2.Level
+	}
+	if ok1 != ok2 {
+		return ok1
+	}
 	return len(grade1) > len(grade2)
 }
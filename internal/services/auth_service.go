@@ -47,6 +47,27 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		}
 	}
 
+	// 时间设置，未填写时使用默认值
+	if req.Timezone == "" {
+		req.Timezone = utils.DefaultTimezone
+	}
+	if _, err := utils.LoadLocation(req.Timezone); err != nil {
+		return nil, errors.New("无效的时区")
+	}
+	weekStart := int(utils.DefaultWeekStart)
+	if req.WeekStart != nil {
+		if *req.WeekStart < 0 || *req.WeekStart > 6 {
+			return nil, errors.New("每周起始日必须为 0-6")
+		}
+		weekStart = *req.WeekStart
+	}
+	if req.Locale == "" {
+		req.Locale = utils.DefaultLocale
+	}
+	if !utils.ValidLocale(req.Locale) {
+		return nil, errors.New("无效的语言区域")
+	}
+
 	// 创建用户
 	user := models.User{
 		Username:  req.Username,
@@ -59,27 +80,31 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		AvatarURL:    "",
 		Bio:          "",
 		Achievements: "[]", // 默认空数组的 JSON 字符串
+		Timezone:     req.Timezone,
+		WeekStart:    weekStart,
+		Locale:       req.Locale,
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
-		return nil, err
-	}
+	// 创建用户和分配默认角色在同一事务中，避免留下没有角色的账号
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// week_start 列有默认值，零值 (周日) 在创建时会被忽略，需要单独写入
+		if weekStart == int(time.Sunday) {
+			if err := tx.Model(&user).Update("week_start", weekStart).Error; err != nil {
+				return err
+			}
+		}
 
-	// 生成JWT令牌
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Email)
+		// 新用户默认为攀岩者
+		return assignRole(tx, &user, models.RoleAthlete)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 返回认证响应
-	response := &models.AuthResponse{
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Token:    token,
-	}
-
-	return response, nil
+	return s.authResponse(&user)
 }
 
 // Login 用户登录
@@ -98,8 +123,18 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, err
 		return nil, errors.New("邮箱或密码错误")
 	}
 
+	return s.authResponse(&user)
+}
+
+// authResponse 签发携带角色和权限的JWT令牌并返回用户当前的角色
+func (s *AuthService) authResponse(user *models.User) (*models.AuthResponse, error) {
+	roles, permissions, err := userAccess(s.db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成JWT令牌
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Email)
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Email, roles, permissions)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +144,7 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, err
 		UserID:   user.ID,
 		Username: user.Username,
 		Email:    user.Email,
+		Roles:    roles,
 		Token:    token,
 	}
 
@@ -1,13 +1,20 @@
 package services
 
 import (
-	_ "encoding/json"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
+	"movePoint/internal/events"
 	"movePoint/internal/models"
+	"movePoint/pkg/utils"
 )
 
 type ClimbingService struct {
@@ -20,19 +27,51 @@ func NewClimbingService(db *gorm.DB) *ClimbingService {
 
 // CreateRecord 创建攀岩记录
 func (s *ClimbingService) CreateRecord(userID uint, record *models.ClimbingRecord) error {
+	// 手动录入的记录来源固定为 manual，热量由系统估算
+	record.Source = models.SourceManual
+	record.ExternalID = ""
+	if err := validateTags(record); err != nil {
+		return err
+	}
+
+	return s.createRecord(userID, record)
+}
+
+// createRecord 保存记录，穿戴设备导入的记录保留实测热量
+func (s *ClimbingService) createRecord(userID uint, record *models.ClimbingRecord) error {
+	return s.createRecordFrom(userID, "", record)
+}
+
+// createRecordFrom 保存记录，device 为通过离线同步创建记录的设备
+func (s *ClimbingService) createRecordFrom(userID uint, device string, record *models.ClimbingRecord) error {
+	if err := s.insertRecord(s.db, userID, record); err != nil {
+		return err
+	}
+	s.recordCreated(userID, device, record)
+	return nil
+}
+
+// insertRecord 计算时长和热量后通过 db 插入记录，不发布事件；在事务中插入时应在提交后调用 recordCreated
+func (s *ClimbingService) insertRecord(db *gorm.DB, userID uint, record *models.ClimbingRecord) error {
 	// 计算持续时间和热量消耗
 	duration := record.EndTime.Sub(record.StartTime)
 	record.Duration = int(duration.Minutes())
-	record.Calories = s.calculateCalories(userID, record.Type, record.Duration)
+	if record.Source == models.SourceManual || record.Calories <= 0 {
+		record.Calories = s.calculateCalories(userID, record.Type, record.Duration)
+	}
 
 	// 设置用户ID
 	record.UserID = userID
+	record.Version = 1
+	record.Tags = record.Tags.Normalize()
 
 	// 保存到数据库
-	result := s.db.Create(record)
-	if result.Error != nil {
-		return result.Error
-	}
+	return db.Create(record).Error
+}
+
+// recordCreated 发布记录创建事件并检查成就
+func (s *ClimbingService) recordCreated(userID uint, device string, record *models.ClimbingRecord) {
+	publishRecordChangeFrom(events.RecordCreated, userID, events.RecordChange{Device: device}, nil, record)
 
 	// 创建记录后检查成就
 	userService := NewUserService(s.db)
@@ -42,8 +81,6 @@ func (s *ClimbingService) CreateRecord(userID uint, record *models.ClimbingRecor
 			log.Println(err)
 		}
 	}()
-
-	return nil
 }
 
 // GetUserRecords 获取用户的攀岩记录
@@ -81,51 +118,360 @@ func (s *ClimbingService) GetRecordByID(userID, recordID uint) (*models.Climbing
 	result := s.db.Where("user_id = ? AND id = ?", userID, recordID).First(&record)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("record not found")
+			return nil, ErrRecordNotFound
 		}
 		return nil, result.Error
 	}
 	return &record, nil
 }
 
-// UpdateRecord 更新记录
-func (s *ClimbingService) UpdateRecord(userID uint, record *models.ClimbingRecord) error {
-	// 验证记录属于该用户
-	var existing models.ClimbingRecord
-	result := s.db.Where("user_id = ? AND id = ?", userID, record.ID).First(&existing)
+// editableRecordFields 客户端可以修改的记录字段 (JSON 字段名与列名一致)，时长和热量由服务端计算，
+// 来源和心率只能由导入流程维护
+var editableRecordFields = []string{
+	"type", "start_time", "end_time", "grade", "color", "attempts", "success", "style",
+	"rating", "rpe", "location", "notes", "media_urls", "tags",
+}
+
+// UpdateRecord 整体替换记录的可编辑字段，未提供的字段置为零值
+//
+// expectedVersion 不为空时 (If-Match)，记录版本不一致返回 ErrVersionConflict。
+func (s *ClimbingService) UpdateRecord(userID uint, record *models.ClimbingRecord, expectedVersion *uint) error {
+	existing, err := s.GetRecordByID(userID, record.ID)
+	if err != nil {
+		return err
+	}
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return ErrVersionConflict
+	}
+
+	updated := *existing
+	if err := copyRecordFields(record, &updated, editableRecordFields); err != nil {
+		return err
+	}
+	if err := s.saveRecordFields(s.db, userID, events.RecordChange{}, existing, &updated, editableRecordFields); err != nil {
+		return err
+	}
+	*record = updated
+	return nil
+}
+
+// PatchRecord 按 JSON Merge Patch (RFC 7396) 修改记录，可以显式设置 false、0 或 null (恢复为零值)
+func (s *ClimbingService) PatchRecord(userID, recordID uint, patch map[string]interface{}, expectedVersion *uint) (*models.ClimbingRecord, error) {
+	existing, err := s.GetRecordByID(userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return nil, ErrVersionConflict
+	}
+
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		if !editableRecordField(field) {
+			return nil, fmt.Errorf("%w: 字段 %s 不可修改", ErrInvalidRecord, field)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return existing, nil
+	}
+
+	var target map[string]interface{}
+	if err := remarshal(existing, &target); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(utils.MergePatch(target, patch))
+	if err != nil {
+		return nil, err
+	}
+	var patched models.ClimbingRecord
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return nil, fmt.Errorf("%w: 无效的字段值", ErrInvalidRecord)
+	}
+
+	updated := *existing
+	if err := copyRecordFields(&patched, &updated, fields); err != nil {
+		return nil, err
+	}
+	if err := s.saveRecordFields(s.db, userID, events.RecordChange{}, existing, &updated, fields); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// saveRecordFields 校验并保存 updated 的 fields 字段，重新计算时长和热量 (穿戴设备实测的热量不覆盖)
+//
+// 仅当数据库中的版本仍为 before.Version 时才写入，并将版本加一；期间被其他请求修改时返回 ErrVersionConflict。
+// 记录未删除时发布 RecordUpdated 事件，origin 为变更来源。
+func (s *ClimbingService) saveRecordFields(db *gorm.DB, userID uint, origin events.RecordChange, before, updated *models.ClimbingRecord, fields []string) error {
+	if err := validateRecord(updated); err != nil {
+		return err
+	}
+	updated.Duration = int(updated.EndTime.Sub(updated.StartTime).Minutes())
+	if before.Source == models.SourceManual || before.Source == "" {
+		updated.Calories = s.calculateCalories(userID, updated.Type, updated.Duration)
+	}
+	updated.Version = before.Version + 1
+	updated.UpdatedAt = time.Now()
+
+	columns := append([]string{"duration", "calories", "version", "updated_at"}, fields...)
+	result := db.Model(updated).Where("version = ?", before.Version).Select(columns).Updates(updated)
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
 
-	// 重新计算持续时间和热量消耗
-	if !record.StartTime.IsZero() && !record.EndTime.IsZero() {
-		duration := record.EndTime.Sub(record.StartTime)
-		record.Duration = int(duration.Minutes())
-		record.Calories = s.calculateCalories(userID, record.Type, record.Duration)
+	if err := db.First(updated, updated.ID).Error; err != nil {
+		return err
+	}
+	if !updated.DeletedAt.Valid {
+		publishRecordChangeFrom(events.RecordUpdated, userID, origin, before, updated)
+	}
+	return nil
+}
+
+// DeleteRecord 删除记录，expectedVersion 不为空时 (If-Match) 版本不一致返回 ErrVersionConflict
+func (s *ClimbingService) DeleteRecord(userID, recordID uint, expectedVersion *uint) error {
+	existing, err := s.GetRecordByID(userID, recordID)
+	if err != nil {
+		return err
+	}
+
+	query := s.db
+	if expectedVersion != nil {
+		query = query.Where("version = ?", *expectedVersion)
+	}
+	result := query.Delete(existing)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
 	}
 
-	result = s.db.Model(&existing).Updates(record)
-	return result.Error
+	publishRecordChange(events.RecordDeleted, userID, existing, nil)
+	return nil
 }
 
-// DeleteRecord 删除记录
-func (s *ClimbingService) DeleteRecord(userID, recordID uint) error {
-	result := s.db.Where("user_id = ? AND id = ?", userID, recordID).Delete(&models.ClimbingRecord{})
-	return result.Error
+// 回收站默认保留天数
+const defaultTrashRetentionDays = 30
+
+// trashRetention 删除的记录在回收站中保留的时长，可通过环境变量 TRASH_RETENTION_DAYS 覆盖
+func trashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
-// calculateCalories 估算热量消耗 (简化算法)
-func (s *ClimbingService) calculateCalories(userID uint, climbingType models.ClimbingType, duration int) float64 {
-	// 在实际应用中，这里应该查询用户体重并结合运动类型计算
-	// 简化处理: 假设平均消耗为 8-12 kcal/min
-	baseRate := 10.0 // 平均10 kcal/min
+// TrashRecord 回收站中的记录，PurgeAt 之后将被彻底删除
+type TrashRecord struct {
+	models.ClimbingRecord
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// GetTrash 获取回收站中的记录，按删除时间倒序
+func (s *ClimbingService) GetTrash(userID uint, page, limit int) ([]TrashRecord, int64, error) {
+	var records []models.ClimbingRecord
+	var total int64
+
+	query := s.db.Unscoped().Model(&models.ClimbingRecord{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("deleted_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+
+	retention := trashRetention()
+	trash := make([]TrashRecord, len(records))
+	for i, record := range records {
+		trash[i] = TrashRecord{ClimbingRecord: record, PurgeAt: record.DeletedAt.Time.Add(retention)}
+	}
+	return trash, total, nil
+}
+
+// UndeleteRecord 从回收站恢复记录
+func (s *ClimbingService) UndeleteRecord(userID, recordID uint) (*models.ClimbingRecord, error) {
+	var record models.ClimbingRecord
+	err := s.db.Unscoped().Where("user_id = ? AND id = ? AND deleted_at IS NOT NULL", userID, recordID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	err = s.db.Unscoped().Model(&record).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"version":    gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&record, record.ID).Error; err != nil {
+		return nil, err
+	}
+
+	publishRecordChangeFrom(events.RecordCreated, userID, events.RecordChange{Action: events.RecordActionUndelete}, nil, &record)
+	return &record, nil
+}
+
+// RestoreRecordFields 将记录的可编辑字段恢复为 snapshot 中的值，版本加一
+func (s *ClimbingService) RestoreRecordFields(userID, recordID uint, snapshot map[string]json.RawMessage, expectedVersion *uint) (*models.ClimbingRecord, error) {
+	existing, err := s.GetRecordByID(userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return nil, ErrVersionConflict
+	}
+
+	fields := make(map[string]json.RawMessage, len(editableRecordFields))
+	for _, field := range editableRecordFields {
+		if value, ok := snapshot[field]; ok {
+			fields[field] = value
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	updated := *existing
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return nil, fmt.Errorf("%w: 历史版本数据无效", ErrInvalidRecord)
+	}
+
+	origin := events.RecordChange{Action: events.RecordActionRestore}
+	if err := s.saveRecordFields(s.db, userID, origin, existing, &updated, editableRecordFields); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RunTrashPurge 按 interval 定期彻底删除回收站中超过保留期的记录，
+// 阻塞运行，应在单独的 goroutine 中调用
+func (s *ClimbingService) RunTrashPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if n, err := s.PurgeTrash(time.Now()); err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d records from trash", n)
+		}
+	}
+}
+
+// PurgeTrash 彻底删除 now 之前已超过保留期的记录及其心率数据
+func (s *ClimbingService) PurgeTrash(now time.Time) (int, error) {
+	var records []models.ClimbingRecord
+	err := s.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", now.Add(-trashRetention())).
+		Limit(500).
+		Find(&records).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range records {
+		record := &records[i]
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("record_id = ?", record.ID).Delete(&models.HeartRateSeries{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(record).Error
+		})
+		if err != nil {
+			return i, err
+		}
+		publishRecordChange(events.RecordPurged, record.UserID, record, nil)
+	}
+	return len(records), nil
+}
+
+// validateRecord 校验记录的可编辑字段并规范化标签
+func validateRecord(record *models.ClimbingRecord) error {
+	switch {
+	case record.Type != models.Bouldering && record.Type != models.SportClimbing:
+		return fmt.Errorf("%w: 无效的攀岩类型", ErrInvalidRecord)
+	case record.StartTime.IsZero() || record.EndTime.Before(record.StartTime):
+		return fmt.Errorf("%w: 无效的开始或结束时间", ErrInvalidRecord)
+	case record.Rating < 1 || record.Rating > 5:
+		return fmt.Errorf("%w: 评分应在 1-5 之间", ErrInvalidRecord)
+	case record.RPE < 0 || record.RPE > 10:
+		return fmt.Errorf("%w: RPE 应在 0-10 之间", ErrInvalidRecord)
+	}
+	return validateTags(record)
+}
+
+// validateTags 规范化记录标签并检查数量和长度 (按字符计)
+func validateTags(record *models.ClimbingRecord) error {
+	record.Tags = record.Tags.Normalize()
+	if len(record.Tags) > models.MaxRecordTags {
+		return fmt.Errorf("%w: 标签不能超过 %d 个", ErrInvalidRecord, models.MaxRecordTags)
+	}
+	for _, tag := range record.Tags {
+		if utf8.RuneCountInString(tag) > models.MaxRecordTagLength {
+			return fmt.Errorf("%w: 标签 %q 超过 %d 个字符", ErrInvalidRecord, tag, models.MaxRecordTagLength)
+		}
+	}
+	if utf8.RuneCountInString(strings.Join(record.Tags, ",")) > models.MaxRecordTagsTotal {
+		return fmt.Errorf("%w: 标签总长度不能超过 %d 个字符", ErrInvalidRecord, models.MaxRecordTagsTotal)
+	}
+	return nil
+}
 
-	// 根据攀岩类型调整
-	if climbingType == models.Bouldering {
-		baseRate = 12.0 // 抱石强度更大
-	} else if climbingType == models.SportClimbing {
-		baseRate = 8.0 // 难度攀登更持久但强度略低
+func editableRecordField(field string) bool {
+	for _, f := range editableRecordFields {
+		if f == field {
+			return true
+		}
 	}
+	return false
+}
+
+// copyRecordFields 将 src 的 fields 字段复制到 dst
+func copyRecordFields(src, dst *models.ClimbingRecord, fields []string) error {
+	var values map[string]json.RawMessage
+	if err := remarshal(src, &values); err != nil {
+		return err
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		selected[field] = values[field]
+	}
+	data, err := json.Marshal(selected)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
 
-	return baseRate * float64(duration)
+// publishRecordChange 发布攀岩记录变更事件，事件中的记录为副本
+func publishRecordChange(topic events.Topic, userID uint, before, after *models.ClimbingRecord) {
+	publishRecordChangeFrom(topic, userID, events.RecordChange{}, before, after)
+}
+
+// publishRecordChangeFrom 发布攀岩记录变更事件，origin 提供变更来源 (同步设备、特殊操作)
+func publishRecordChangeFrom(topic events.Topic, userID uint, origin events.RecordChange, before, after *models.ClimbingRecord) {
+	change := events.RecordChange{Device: origin.Device, Action: origin.Action}
+	if before != nil {
+		copied := *before
+		change.Before = &copied
+	}
+	if after != nil {
+		copied := *after
+		change.After = &copied
+	}
+	events.Publish(events.Event{Topic: topic, UserID: userID, Payload: change})
+}
+
+// calculateCalories 估算热量消耗 (简化算法)
+func (s *ClimbingService) calculateCalories(userID uint, climbingType models.ClimbingType, duration int) float64 {
+	return estimateCalories(models.ActivityClimbing, climbingType, duration)
 }
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"movePoint/internal/models"
+)
+
+func TestValidateTags(t *testing.T) {
+	many := make(models.TagList, models.MaxRecordTags+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag%d", i)
+	}
+	// 16 个 31 字符的标签，连接后 16*31 + 15 = 511 个字符
+	long := make(models.TagList, 16)
+	for i := range long {
+		long[i] = fmt.Sprintf("%02d", i) + strings.Repeat("a", 29)
+	}
+
+	tests := []struct {
+		name    string
+		tags    models.TagList
+		want    models.TagList
+		wantErr bool
+	}{
+		{"normalized", models.TagList{" 室外 ", "Comp", "comp"}, models.TagList{"室外", "comp"}, false},
+		{"duplicates count once", append(many[:models.MaxRecordTags:models.MaxRecordTags], "TAG0"), many[:models.MaxRecordTags], false},
+		{"too many tags", many, nil, true},
+		{"tag length counts characters", models.TagList{strings.Repeat("岩", models.MaxRecordTagLength)}, models.TagList{strings.Repeat("岩", models.MaxRecordTagLength)}, false},
+		{"tag too long", models.TagList{strings.Repeat("a", models.MaxRecordTagLength+1)}, nil, true},
+		{"total within column", long, long, false},
+		{"total too long", append(long[:16:16], "x"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &models.ClimbingRecord{Tags: tt.tags}
+			err := validateTags(record)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecord) {
+					t.Errorf("validateTags error = %v, want ErrInvalidRecord", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateTags error = %v", err)
+			}
+			if strings.Join(record.Tags, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("tags = %v, want %v", record.Tags, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+var (
+	ErrInvalidCoachLink = errors.New("invalid coach link")
+	ErrScopeDenied      = errors.New("scope not granted") // 攀岩者未授予教练该访问范围
+)
+
+// CoachPeer 教练关系中对方的公开信息
+type CoachPeer struct {
+	ID        uint   `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// CoachLinkView 教练关系及对方的信息
+type CoachLinkView struct {
+	models.CoachLink
+	Peer CoachPeer `json:"peer"`
+}
+
+// AthleteSummary 教练看板中单个攀岩者的数据
+type AthleteSummary struct {
+	Athlete  CoachPeer     `json:"athlete"`
+	Scopes   []string      `json:"scopes"`
+	Analysis *AnalysisData `json:"analysis"` // 未授权 analysis:read 时为空
+}
+
+// CoachDashboard 教练看板，汇总已授权查看分析的攀岩者
+type CoachDashboard struct {
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Athletes []AthleteSummary `json:"athletes"`
+	Totals   struct {
+		Athletes      int     `json:"athletes"`        // 全部攀岩者
+		SharedCount   int     `json:"shared_athletes"` // 授权查看分析的攀岩者
+		TotalSessions int     `json:"total_sessions"`
+		TotalDuration int     `json:"total_duration"` // 分钟
+		TotalCalories float64 `json:"total_calories"`
+	} `json:"totals"`
+	GradeDistribution map[string]GradeStats `json:"grade_distribution"`
+}
+
+type CoachService struct {
+	db       *gorm.DB
+	analysis *AnalysisService
+	climbing *ClimbingService
+	plans    *TrainingPlanService
+}
+
+func NewCoachService(db *gorm.DB, analysis *AnalysisService, climbing *ClimbingService, plans *TrainingPlanService) *CoachService {
+	return &CoachService{db: db, analysis: analysis, climbing: climbing, plans: plans}
+}
+
+// Subscribe 订阅记录彻底删除事件，清理记录的评论
+func (s *CoachService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.RecordPurged, s.HandleRecordPurged)
+}
+
+// HandleRecordPurged 删除被彻底删除记录的评论
+func (s *CoachService) HandleRecordPurged(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok || change.Before == nil {
+		return nil
+	}
+	return s.db.Where("record_id = ?", change.Before.ID).Delete(&models.RecordComment{}).Error
+}
+
+// Invite 教练邀请攀岩者，申请访问范围 scopes；已解除或被拒绝的关系重新变为待接受
+func (s *CoachService) Invite(coachID, athleteID uint, scopes []string, message string) (*models.CoachLink, error) {
+	if coachID == athleteID {
+		return nil, fmt.Errorf("%w: 不能邀请自己", ErrInvalidCoachLink)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: 至少申请一个访问范围", ErrInvalidCoachLink)
+	}
+	if err := s.db.First(&models.User{}, athleteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	link := models.CoachLink{CoachID: coachID, AthleteID: athleteID}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(link).Attrs(models.CoachLink{Status: models.CoachLinkPending}).FirstOrCreate(&link).Error; err != nil {
+			return err
+		}
+		if link.Status == models.CoachLinkActive {
+			return fmt.Errorf("%w: 已是该用户的教练", ErrInvalidCoachLink)
+		}
+		link.Status = models.CoachLinkPending
+		link.Scopes = scopes
+		link.Message = strings.TrimSpace(message)
+		link.RespondedAt = nil
+		return tx.Save(&link).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetCoaches 获取攀岩者的教练和待处理的邀请
+func (s *CoachService) GetCoaches(athleteID uint) ([]CoachLinkView, error) {
+	return s.links(athleteID, false)
+}
+
+// GetAthletes 获取教练的攀岩者和尚未接受的邀请
+func (s *CoachService) GetAthletes(coachID uint) ([]CoachLinkView, error) {
+	return s.links(coachID, true)
+}
+
+// Accept 攀岩者接受邀请，scopes 为空时授予教练申请的全部范围
+func (s *CoachService) Accept(athleteID, coachID uint, scopes []string) (*models.CoachLink, error) {
+	link, err := s.link(coachID, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != models.CoachLinkPending {
+		return nil, fmt.Errorf("%w: 没有待接受的邀请", ErrInvalidCoachLink)
+	}
+	if scopes != nil {
+		if link.Scopes, err = normalizeScopes(scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	link.Status = models.CoachLinkActive
+	link.RespondedAt = &now
+	if err := s.db.Save(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Decline 攀岩者拒绝邀请
+func (s *CoachService) Decline(athleteID, coachID uint) error {
+	link, err := s.link(coachID, athleteID)
+	if err != nil {
+		return err
+	}
+	if link.Status != models.CoachLinkPending {
+		return fmt.Errorf("%w: 没有待接受的邀请", ErrInvalidCoachLink)
+	}
+
+	now := time.Now()
+	link.Status = models.CoachLinkDeclined
+	link.RespondedAt = &now
+	return s.db.Save(link).Error
+}
+
+// UpdateScopes 攀岩者修改授予教练的访问范围，立即生效
+func (s *CoachService) UpdateScopes(athleteID, coachID uint, scopes []string) (*models.CoachLink, error) {
+	link, err := s.link(coachID, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != models.CoachLinkActive {
+		return nil, fmt.Errorf("%w: 该用户不是你的教练", ErrInvalidCoachLink)
+	}
+	if link.Scopes, err = normalizeScopes(scopes); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// EndLink 解除教练关系或撤回邀请，攀岩者和教练均可操作
+func (s *CoachService) EndLink(coachID, athleteID uint) error {
+	result := s.db.Model(&models.CoachLink{}).
+		Where("coach_id = ? AND athlete_id = ? AND status IN ?", coachID, athleteID,
+			[]models.CoachLinkStatus{models.CoachLinkPending, models.CoachLinkActive}).
+		Update("status", models.CoachLinkEnded)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Authorize 检查教练是否有攀岩者授予的访问范围 scope，没有时返回 ErrScopeDenied
+func (s *CoachService) Authorize(coachID, athleteID uint, scope string) error {
+	link, err := s.link(coachID, athleteID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrScopeDenied
+		}
+		return err
+	}
+	if !link.HasScope(scope) {
+		return ErrScopeDenied
+	}
+	return nil
+}
+
+// GetAthleteRecords 教练查看攀岩者的记录，需要 records:read
+func (s *CoachService) GetAthleteRecords(coachID, athleteID uint, page, limit int, from, to time.Time) ([]models.ClimbingRecord, int64, error) {
+	if err := s.Authorize(coachID, athleteID, models.CoachScopeRecords); err != nil {
+		return nil, 0, err
+	}
+	return s.climbing.GetUserRecords(athleteID, page, limit, from, to)
+}
+
+// GetAthleteAnalysis 教练查看攀岩者的数据分析，需要 analysis:read
+func (s *CoachService) GetAthleteAnalysis(coachID, athleteID uint, from, to time.Time, loc *time.Location, filter AnalysisFilter) (*AnalysisData, error) {
+	if err := s.Authorize(coachID, athleteID, models.CoachScopeAnalysis); err != nil {
+		return nil, err
+	}
+	return s.analysis.GetClimbingAnalysis(athleteID, from, to, loc, filter)
+}
+
+// AssignPlan 教练为攀岩者布置训练计划，需要 plans:assign
+func (s *CoachService) AssignPlan(coachID, athleteID uint, plan *models.TrainingPlan) (*models.TrainingPlan, error) {
+	if err := s.Authorize(coachID, athleteID, models.CoachScopePlans); err != nil {
+		return nil, err
+	}
+	if err := s.plans.AssignPlan(coachID, athleteID, plan); err != nil {
+		return nil, err
+	}
+	return s.plans.GetPlan(athleteID, plan.ID)
+}
+
+// GetDashboard 汇总教练全部攀岩者在时间范围内的分析数据，未授权 analysis:read 的攀岩者只列出不统计
+func (s *CoachService) GetDashboard(coachID uint, from, to time.Time, loc *time.Location) (*CoachDashboard, error) {
+	links, err := s.GetAthletes(coachID)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &CoachDashboard{
+		From:              from,
+		To:                to,
+		Athletes:          []AthleteSummary{},
+		GradeDistribution: make(map[string]GradeStats),
+	}
+	for _, link := range links {
+		if link.Status != models.CoachLinkActive {
+			continue
+		}
+		summary := AthleteSummary{Athlete: link.Peer, Scopes: link.Scopes}
+		if link.HasScope(models.CoachScopeAnalysis) {
+			analysis, err := s.analysis.GetClimbingAnalysis(link.AthleteID, from, to, loc, AnalysisFilter{})
+			if err != nil {
+				return nil, err
+			}
+			summary.Analysis = analysis
+
+			dashboard.Totals.SharedCount++
+			dashboard.Totals.TotalSessions += analysis.Summary.TotalSessions
+			dashboard.Totals.TotalDuration += analysis.Summary.TotalDuration
+			dashboard.Totals.TotalCalories += analysis.Summary.TotalCalories
+			for grade, stats := range analysis.GradeDistribution {
+				total := dashboard.GradeDistribution[grade]
+				total.Attempts += stats.Attempts
+				total.Success += stats.Success
+				dashboard.GradeDistribution[grade] = total
+			}
+		}
+		dashboard.Athletes = append(dashboard.Athletes, summary)
+	}
+	dashboard.Totals.Athletes = len(dashboard.Athletes)
+	return dashboard, nil
+}
+
+// AddComment 评论攀岩记录，作者为记录本人或有 comment 范围的教练
+func (s *CoachService) AddComment(authorID, recordID uint, body string) (*models.RecordComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len([]rune(body)) > 2000 {
+		return nil, fmt.Errorf("%w: 评论不能为空且不超过 2000 个字符", ErrInvalidCoachLink)
+	}
+	ownerID, err := s.commentAccess(authorID, recordID, models.CoachScopeComment)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.RecordComment{RecordID: recordID, AthleteID: ownerID, AuthorID: authorID, Body: body}
+	if err := s.db.Create(&comment).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetComments 获取记录的评论，查看者为记录本人或有 records:read 或 comment 范围的教练
+func (s *CoachService) GetComments(viewerID, recordID uint) ([]models.RecordComment, error) {
+	if _, err := s.commentAccess(viewerID, recordID, models.CoachScopeRecords, models.CoachScopeComment); err != nil {
+		return nil, err
+	}
+
+	var comments []models.RecordComment
+	err := s.db.Table("record_comments").
+		Select("record_comments.*, users.username AS author").
+		Joins("LEFT JOIN users ON users.id = record_comments.author_id").
+		Where("record_comments.record_id = ?", recordID).
+		Order("record_comments.created_at, record_comments.id").
+		Scan(&comments).Error
+	return comments, err
+}
+
+// DeleteComment 删除评论，评论作者和记录本人可以删除
+func (s *CoachService) DeleteComment(userID, recordID, commentID uint) error {
+	result := s.db.Where("id = ? AND record_id = ? AND (author_id = ? OR athlete_id = ?)", commentID, recordID, userID, userID).
+		Delete(&models.RecordComment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// commentAccess 检查用户对记录评论的访问权限并返回记录所属用户，需要 scopes 之一；
+// 与记录所属用户没有教练关系时返回 ErrRecordNotFound，避免泄露记录是否存在
+func (s *CoachService) commentAccess(userID, recordID uint, scopes ...string) (uint, error) {
+	var record models.ClimbingRecord
+	if err := s.db.Select("id", "user_id").First(&record, recordID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, err
+	}
+	if record.UserID == userID {
+		return record.UserID, nil
+	}
+
+	link, err := s.link(userID, record.UserID)
+	if err != nil {
+		return 0, err
+	}
+	for _, scope := range scopes {
+		if link.HasScope(scope) {
+			return record.UserID, nil
+		}
+	}
+	if link.Status != models.CoachLinkActive {
+		return 0, ErrRecordNotFound
+	}
+	return 0, ErrScopeDenied
+}
+
+func (s *CoachService) link(coachID, athleteID uint) (*models.CoachLink, error) {
+	var link models.CoachLink
+	if err := s.db.Where("coach_id = ? AND athlete_id = ?", coachID, athleteID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// links 获取用户作为教练 (asCoach) 或攀岩者的待接受和有效关系，附带对方的信息
+func (s *CoachService) links(userID uint, asCoach bool) ([]CoachLinkView, error) {
+	column := "athlete_id"
+	if asCoach {
+		column = "coach_id"
+	}
+	var links []models.CoachLink
+	err := s.db.Where(column+" = ?", userID).
+		Where("status IN ?", []models.CoachLinkStatus{models.CoachLinkPending, models.CoachLinkActive}).
+		Order("status, updated_at DESC").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	peerOf := func(link models.CoachLink) uint {
+		if asCoach {
+			return link.AthleteID
+		}
+		return link.CoachID
+	}
+	ids := make([]uint, 0, len(links))
+	for _, link := range links {
+		ids = append(ids, peerOf(link))
+	}
+	var peers []CoachPeer
+	if len(ids) > 0 {
+		if err := s.db.Model(&models.User{}).Select("id, username, avatar_url").Where("id IN ?", ids).Scan(&peers).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]CoachPeer, len(peers))
+	for _, p := range peers {
+		byID[p.ID] = p
+	}
+
+	views := make([]CoachLinkView, 0, len(links))
+	for _, link := range links {
+		peer, ok := byID[peerOf(link)]
+		if !ok {
+			continue // 对方已注销
+		}
+		views = append(views, CoachLinkView{CoachLink: link, Peer: peer})
+	}
+	return views, nil
+}
+
+// normalizeScopes 校验并去重访问范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range models.CoachScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: 未知的访问范围 %q", ErrInvalidCoachLink, scope)
+		}
+	}
+	return uniqueStrings(scopes), nil
+}
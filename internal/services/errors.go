@@ -0,0 +1,11 @@
+package services
+
+import "errors"
+
+// 服务层通用错误，处理器据此返回对应的 HTTP 状态码
+var (
+	ErrRecordNotFound  = errors.New("record not found")
+	ErrInvalidProfile  = errors.New("invalid profile")
+	ErrInvalidRecord   = errors.New("invalid record")
+	ErrVersionConflict = errors.New("version conflict") // 记录已被其他请求修改
+)
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+var ErrInvalidFollow = errors.New("invalid follow")
+
+// FollowUser 关注列表中的用户，只包含公开信息
+type FollowUser struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	AvatarURL  string    `json:"avatar_url"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowService struct {
+	db  *gorm.DB
+	hub *events.Hub
+}
+
+func NewFollowService(db *gorm.DB, hub *events.Hub) *FollowService {
+	return &FollowService{db: db, hub: hub}
+}
+
+// Follow 关注用户，重复关注不报错
+func (s *FollowService) Follow(followerID, followeeID uint) error {
+	if followerID == followeeID {
+		return fmt.Errorf("%w: 不能关注自己", ErrInvalidFollow)
+	}
+	if err := s.db.First(&models.User{}, followeeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	follow := models.Follow{FollowerID: followerID, FolloweeID: followeeID}
+	return s.db.Where(follow).FirstOrCreate(&follow).Error
+}
+
+// Unfollow 取消关注
+func (s *FollowService) Unfollow(followerID, followeeID uint) error {
+	return s.remove(followerID, followeeID)
+}
+
+// RemoveFollower 被关注者移除关注者，对方不能再观看自己的实时攀岩
+func (s *FollowService) RemoveFollower(followeeID, followerID uint) error {
+	return s.remove(followerID, followeeID)
+}
+
+// remove 删除关注关系，并通知被关注者的实时推送连接重新检查观看权限
+func (s *FollowService) remove(followerID, followeeID uint) error {
+	result := s.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&models.Follow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	s.hub.Publish(events.Event{Topic: events.FollowRemoved, UserID: followeeID, Payload: followerID})
+	return nil
+}
+
+// GetFollowing 获取用户关注的人
+func (s *FollowService) GetFollowing(userID uint) ([]FollowUser, error) {
+	return s.list("follows.followee_id", "follows.follower_id = ?", userID)
+}
+
+// GetFollowers 获取用户的关注者
+func (s *FollowService) GetFollowers(userID uint) ([]FollowUser, error) {
+	return s.list("follows.follower_id", "follows.followee_id = ?", userID)
+}
+
+func (s *FollowService) list(join, where string, userID uint) ([]FollowUser, error) {
+	var users []FollowUser
+	err := s.db.Table("follows").
+		Select("users.id, users.username, users.avatar_url, follows.created_at AS followed_at").
+		Joins("JOIN users ON users.id = "+join+" AND users.deleted_at IS NULL").
+		Where(where, userID).
+		Order("follows.created_at DESC").
+		Scan(&users).Error
+	return users, err
+}
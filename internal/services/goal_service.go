@@ -0,0 +1,462 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// ErrInvalidGoal 目标设置无效
+var ErrInvalidGoal = errors.New("invalid goal")
+
+const (
+	// 数值目标在周期过去这一比例之后才会因预计无法完成而标记为有风险，避免周期刚开始时误报
+	goalRiskMinElapsed = 0.25
+	// 难度目标无法预测进度，周期剩余不足这一比例仍未完成时标记为有风险
+	goalGradeRiskRemaining = 0.2
+)
+
+type GoalService struct {
+	db *gorm.DB
+}
+
+func NewGoalService(db *gorm.DB) *GoalService {
+	return &GoalService{db: db}
+}
+
+// GoalProgress 目标在当前周期的进度和预测
+type GoalProgress struct {
+	Goal                models.Goal      `json:"goal"`
+	PeriodKey           string           `json:"period_key"`
+	PeriodStart         time.Time        `json:"period_start"`
+	PeriodEnd           time.Time        `json:"period_end"`
+	Current             float64          `json:"current"`
+	BestGrade           string           `json:"best_grade,omitempty"` // 难度目标: 周期内完成的最高同体系难度
+	Target              float64          `json:"target"`
+	Percent             float64          `json:"percent"`
+	Projected           *float64         `json:"projected"`            // 按当前速度到周期结束时的预计值，难度目标为空
+	ProjectedCompletion *time.Time       `json:"projected_completion"` // 按当前速度预计完成的时间，已完成或无进展时为空
+	MetAt               *time.Time       `json:"met_at"`
+	State               models.GoalState `json:"state"`
+}
+
+// GoalEvent 目标完成或有风险事件内容
+type GoalEvent struct {
+	Progress GoalProgress `json:"progress"`
+}
+
+// Subscribe 订阅攀岩记录和时间设置变更事件，重新评估用户的目标
+func (s *GoalService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted, events.TimeSettingsChanged} {
+		bus.Subscribe(topic, func(e events.Event) error {
+			return s.EvaluateUser(e.UserID, time.Now())
+		})
+	}
+}
+
+// RunChecker 按 interval 定期评估全部目标，使没有新记录时也能发现目标有风险或已错过，
+// 阻塞运行，应在单独的 goroutine 中调用
+func (s *GoalService) RunChecker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var userIDs []uint
+		if err := s.db.Model(&models.Goal{}).Where("archived = ?", false).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+			log.Printf("Failed to load goals: %v", err)
+			continue
+		}
+		for _, userID := range userIDs {
+			if err := s.EvaluateUser(userID, time.Now()); err != nil {
+				log.Printf("Failed to evaluate goals for user %d: %v", userID, err)
+			}
+		}
+	}
+}
+
+// CreateGoal 创建目标
+func (s *GoalService) CreateGoal(userID uint, goal *models.Goal) error {
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+	goal.ID = 0
+	goal.UserID = userID
+	if err := normalizeGoal(goal, settings); err != nil {
+		return err
+	}
+	if err := s.db.Create(goal).Error; err != nil {
+		return err
+	}
+	_, err = s.evaluate(goal, time.Now(), settings)
+	return err
+}
+
+// UpdateGoal 修改目标设置，当前周期的状态重新评估
+func (s *GoalService) UpdateGoal(userID, goalID uint, goal *models.Goal) error {
+	existing, err := s.findGoal(userID, goalID)
+	if err != nil {
+		return err
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+
+	goal.ID = existing.ID
+	goal.UserID = userID
+	goal.CreatedAt = existing.CreatedAt
+	goal.PeriodKey, goal.State, goal.MetAt = existing.PeriodKey, existing.State, existing.MetAt
+	if err := normalizeGoal(goal, settings); err != nil {
+		return err
+	}
+	if err := s.db.Save(goal).Error; err != nil {
+		return err
+	}
+	_, err = s.evaluate(goal, time.Now(), settings)
+	return err
+}
+
+// DeleteGoal 删除目标
+func (s *GoalService) DeleteGoal(userID, goalID uint) error {
+	result := s.db.Where("user_id = ? AND id = ?", userID, goalID).Delete(&models.Goal{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetGoal 获取目标及其当前进度
+func (s *GoalService) GetGoal(userID, goalID uint) (*GoalProgress, error) {
+	goal, err := s.findGoal(userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.progress(goal, time.Now(), settings)
+}
+
+// ListGoals 获取目标及其当前进度，默认不含已归档的目标
+func (s *GoalService) ListGoals(userID uint, includeArchived bool) ([]GoalProgress, error) {
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("user_id = ?", userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	var goals []models.Goal
+	if err := query.Order("created_at ASC").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := make([]GoalProgress, 0, len(goals))
+	for i := range goals {
+		p, err := s.progress(&goals[i], now, settings)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, nil
+}
+
+// EvaluateUser 评估用户全部未归档的目标，状态变化时保存并发布事件
+func (s *GoalService) EvaluateUser(userID uint, now time.Time) error {
+	var goals []models.Goal
+	if err := s.db.Where("user_id = ? AND archived = ?", userID, false).Find(&goals).Error; err != nil {
+		return err
+	}
+	if len(goals) == 0 {
+		return nil
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range goals {
+		if _, err := s.evaluate(&goals[i], now, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluate 计算进度，与上次评估的周期或状态不同时保存；进入完成或有风险状态时发布事件
+func (s *GoalService) evaluate(goal *models.Goal, now time.Time, settings utils.TimeSettings) (*GoalProgress, error) {
+	p, err := s.progress(goal, now, settings)
+	if err != nil {
+		return nil, err
+	}
+	if p.PeriodKey == goal.PeriodKey && p.State == goal.State {
+		return p, nil
+	}
+
+	err = s.db.Model(goal).Updates(map[string]interface{}{
+		"period_key": p.PeriodKey,
+		"state":      p.State,
+		"met_at":     p.MetAt,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	goal.PeriodKey, goal.State, goal.MetAt = p.PeriodKey, p.State, p.MetAt
+	p.Goal.PeriodKey, p.Goal.State, p.Goal.MetAt = p.PeriodKey, p.State, p.MetAt
+
+	switch p.State {
+	case models.GoalMet:
+		events.Publish(events.Event{Topic: events.GoalMet, UserID: goal.UserID, Payload: GoalEvent{Progress: *p}})
+	case models.GoalAtRisk:
+		events.Publish(events.Event{Topic: events.GoalAtRisk, UserID: goal.UserID, Payload: GoalEvent{Progress: *p}})
+	}
+	return p, nil
+}
+
+// progress 计算目标在 now 所在周期的进度、预测和状态
+func (s *GoalService) progress(goal *models.Goal, now time.Time, settings utils.TimeSettings) (*GoalProgress, error) {
+	key, start, end := goalPeriod(goal, now, settings)
+	p := &GoalProgress{
+		Goal:        *goal,
+		PeriodKey:   key,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Target:      goal.Target,
+	}
+
+	until := end
+	if now.Before(until) {
+		until = now
+	}
+	if err := s.measure(goal, p, start, until, settings.Location); err != nil {
+		return nil, err
+	}
+	p.Percent = round1(math.Min(100, p.Current/goal.Target*100))
+
+	total := end.Sub(start)
+	elapsed := now.Sub(start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	fraction := math.Min(1, float64(elapsed)/float64(total))
+
+	if goal.Metric != models.GoalGrade && p.Current < goal.Target && elapsed > 0 {
+		// 按已经过的天数 (至少一天) 估算速度
+		days := math.Max(1, elapsed.Hours()/24)
+		rate := p.Current / days
+		projected := round1(p.Current + rate*math.Max(0, end.Sub(now).Hours()/24))
+		p.Projected = &projected
+		if rate > 0 {
+			completion := now.Add(time.Duration((goal.Target - p.Current) / rate * float64(24*time.Hour)))
+			p.ProjectedCompletion = &completion
+		}
+	}
+
+	switch {
+	case p.Current >= goal.Target:
+		p.State = models.GoalMet
+	case !now.Before(end):
+		p.State = models.GoalMissed
+	case goal.Metric == models.GoalGrade && 1-fraction < goalGradeRiskRemaining:
+		p.State = models.GoalAtRisk
+	case p.Projected != nil && *p.Projected < goal.Target && fraction >= goalRiskMinElapsed:
+		p.State = models.GoalAtRisk
+	default:
+		p.State = models.GoalOnTrack
+	}
+
+	loc := settings.Location
+	p.PeriodStart, p.PeriodEnd = start.In(loc), end.In(loc)
+	if p.ProjectedCompletion != nil {
+		t := p.ProjectedCompletion.In(loc)
+		p.ProjectedCompletion = &t
+	}
+	if p.MetAt != nil {
+		t := p.MetAt.In(loc)
+		p.MetAt = &t
+	}
+	p.Goal.LocalizeTimes(loc)
+	return p, nil
+}
+
+// measure 统计 [from, to] 内的目标指标，达到目标时记录完成时间
+func (s *GoalService) measure(goal *models.Goal, p *GoalProgress, from, to time.Time, loc *time.Location) error {
+	if to.Before(from) {
+		return nil
+	}
+
+	query := s.db.Select("start_time", "type", "grade", "duration", "success").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", goal.UserID, from, to)
+	if goal.Type != "" {
+		query = query.Where("type = ?", goal.Type)
+	}
+	var records []models.ClimbingRecord
+	if err := query.Order("start_time ASC").Find(&records).Error; err != nil {
+		return err
+	}
+
+	var target gradeInfo
+	if goal.Metric == models.GoalGrade {
+		target, _ = parseGrade(goal.Type, goal.TargetGrade)
+	}
+	var best *gradeInfo
+	days := make(map[string]bool)
+	for _, r := range records {
+		switch goal.Metric {
+		case models.GoalSessions:
+			p.Current++
+		case models.GoalDuration:
+			p.Current += float64(r.Duration)
+		case models.GoalSends:
+			if r.Success {
+				p.Current++
+			}
+		case models.GoalActiveDays:
+			day := r.StartTime.In(loc).Format("2006-01-02")
+			if !days[day] {
+				days[day] = true
+				p.Current++
+			}
+		case models.GoalGrade:
+			info, ok := parseGrade(r.Type, r.Grade)
+			if !r.Success || !ok || info.Type != target.Type {
+				continue
+			}
+			if best == nil || info.Level > best.Level {
+				best = &info
+				p.BestGrade = info.Label
+			}
+			if info.Level >= target.Level && p.Current == 0 {
+				p.Current = 1
+			}
+		}
+
+		if p.MetAt == nil && p.Current >= goal.Target {
+			metAt := r.StartTime
+			p.MetAt = &metAt
+		}
+	}
+	return nil
+}
+
+// goalPeriod now 所在的目标周期 [start, end] 及其标识
+//
+// 一次性目标的周期为开始日期到截止日期；重复目标在开始日期之前取第一个周期，
+// 截止日期之后停留在最后一个周期。
+func goalPeriod(goal *models.Goal, now time.Time, settings utils.TimeSettings) (string, time.Time, time.Time) {
+	loc := settings.Location
+	if goal.Recurrence == models.RecurrenceNone || goal.Recurrence == "" {
+		end := goal.StartDate
+		if goal.Deadline != nil {
+			end = *goal.Deadline
+		}
+		return "once", goal.StartDate, end
+	}
+
+	if now.Before(goal.StartDate) {
+		now = goal.StartDate
+	}
+	if goal.Deadline != nil && now.After(*goal.Deadline) {
+		now = *goal.Deadline
+	}
+
+	var start, next time.Time
+	var key string
+	switch goal.Recurrence {
+	case models.RecurrenceWeekly:
+		start = utils.StartOfWeek(now, loc, settings.WeekStart)
+		next = start.AddDate(0, 0, 7)
+		key = start.Format("2006-01-02")
+	case models.RecurrenceMonthly:
+		start = utils.StartOfMonth(now, loc)
+		next = start.AddDate(0, 1, 0)
+		key = start.Format("2006-01")
+	case models.RecurrenceQuarterly:
+		month := utils.StartOfMonth(now, loc)
+		start = month.AddDate(0, -int(month.Month()-1)%3, 0)
+		next = start.AddDate(0, 3, 0)
+		key = fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	default:
+		start = time.Date(now.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+		next = start.AddDate(1, 0, 0)
+		key = start.Format("2006")
+	}
+	return key, start, next.Add(-time.Microsecond)
+}
+
+// normalizeGoal 校验目标设置并补全默认值
+func normalizeGoal(goal *models.Goal, settings utils.TimeSettings) error {
+	goal.Title = strings.TrimSpace(goal.Title)
+	if goal.Title == "" || len([]rune(goal.Title)) > 100 {
+		return fmt.Errorf("%w: 目标名称不能为空且不超过 100 个字符", ErrInvalidGoal)
+	}
+
+	switch goal.Type {
+	case "", models.Bouldering, models.SportClimbing:
+	default:
+		return fmt.Errorf("%w: 无效的攀岩类型 %s", ErrInvalidGoal, goal.Type)
+	}
+
+	switch goal.Metric {
+	case models.GoalSessions, models.GoalDuration, models.GoalSends, models.GoalActiveDays:
+		if goal.Target <= 0 {
+			return fmt.Errorf("%w: 目标值必须大于 0", ErrInvalidGoal)
+		}
+		goal.TargetGrade = ""
+	case models.GoalGrade:
+		if _, ok := parseGrade(goal.Type, goal.TargetGrade); !ok {
+			return fmt.Errorf("%w: 无法识别的难度 %s", ErrInvalidGoal, goal.TargetGrade)
+		}
+		goal.Target = 1
+	default:
+		return fmt.Errorf("%w: 无效的目标指标 %s", ErrInvalidGoal, goal.Metric)
+	}
+
+	switch goal.Recurrence {
+	case "":
+		goal.Recurrence = models.RecurrenceNone
+	case models.RecurrenceNone, models.RecurrenceWeekly, models.RecurrenceMonthly, models.RecurrenceQuarterly, models.RecurrenceYearly:
+	default:
+		return fmt.Errorf("%w: 无效的重复周期 %s", ErrInvalidGoal, goal.Recurrence)
+	}
+
+	if goal.StartDate.IsZero() {
+		goal.StartDate = utils.StartOfDay(time.Now(), settings.Location)
+	}
+	if goal.Recurrence == models.RecurrenceNone && goal.Deadline == nil {
+		return fmt.Errorf("%w: 一次性目标需要截止日期", ErrInvalidGoal)
+	}
+	if goal.Deadline != nil && !goal.Deadline.After(goal.StartDate) {
+		return fmt.Errorf("%w: 截止日期必须晚于开始日期", ErrInvalidGoal)
+	}
+	return nil
+}
+
+func (s *GoalService) findGoal(userID, goalID uint) (*models.Goal, error) {
+	var goal models.Goal
+	err := s.db.Where("user_id = ? AND id = ?", userID, goalID).First(&goal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"movePoint/internal/models"
+)
+
+// 难度体系
+const (
+	GradeSystemVScale = "v_scale" // 抱石 V 级
+	GradeSystemFont   = "font"    // 抱石 Fontainebleau
+	GradeSystemYDS    = "yds"     // 难度攀登 优胜美地 5.x
+	GradeSystemFrench = "french"  // 难度攀登 法国级
+)
+
+// gradeInfo 解析后的难度
+//
+// Level 在同一攀岩类型内可比较: 抱石统一换算到 V 级数值 (VB = -1)，
+// 难度攀登统一换算到 YDS 数值 (5.9 = 9, 5.10a = 10, 5.10b = 10.25 ...)。
+type gradeInfo struct {
+	Label  string
+	Level  float64
+	System string
+	Type   models.ClimbingType
+}
+
+var (
+	vScalePattern = regexp.MustCompile(`^V(B|\d{1,2})(?:[+-])?$`)
+	ydsPattern    = regexp.MustCompile(`^5\.(\d{1,2})([ABCD])?([+-])?$`)
+	fontPattern   = regexp.MustCompile(`^([3-9])([ABC])?(\+)?$`)
+)
+
+// fontToV Fontainebleau 抱石难度到 V 级的换算
+var fontToV = map[string]float64{
+	"3": -1, "4": 0, "4+": 0, "5": 1, "5+": 2,
+	"6A": 3, "6A+": 3.5, "6B": 4, "6B+": 4.5, "6C": 5, "6C+": 5.5,
+	"7A": 6, "7A+": 7, "7B": 8, "7B+": 8.5, "7C": 9, "7C+": 10,
+	"8A": 11, "8A+": 12, "8B": 13, "8B+": 14, "8C": 15, "8C+": 16, "9A": 17,
+}
+
+// frenchToYDS 法国级到 YDS 数值的换算
+var frenchToYDS = map[string]float64{
+	"3": 4, "4": 5, "4A": 5, "4B": 5.5, "4C": 6,
+	"5": 7, "5A": 7, "5B": 8, "5C": 9, "5+": 8.5,
+	"6A": 10, "6A+": 10.25, "6B": 10.5, "6B+": 10.75, "6C": 11.125, "6C+": 11.5,
+	"7A": 11.75, "7A+": 12, "7B": 12.25, "7B+": 12.5, "7C": 12.75, "7C+": 13,
+	"8A": 13.25, "8A+": 13.5, "8B": 13.75, "8B+": 14, "8C": 14.25, "8C+": 14.5,
+	"9A": 14.75, "9A+": 15, "9B": 15.25, "9B+": 15.5, "9C": 15.75,
+}
+
+// parseGrade 解析难度字符串，climbingType 为空时根据写法推断体系
+func parseGrade(climbingType models.ClimbingType, grade string) (gradeInfo, bool) {
+	g := strings.ToUpper(strings.TrimSpace(grade))
+	g = strings.ReplaceAll(g, " ", "")
+	if g == "" {
+		return gradeInfo{}, false
+	}
+
+	if m := vScalePattern.FindStringSubmatch(g); m != nil {
+		level := -1.0
+		if m[1] != "B" {
+			n, _ := strconv.Atoi(m[1])
+			level = float64(n)
+		}
+		return gradeInfo{Label: g, Level: level, System: GradeSystemVScale, Type: models.Bouldering}, true
+	}
+
+	if m := ydsPattern.FindStringSubmatch(g); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		level := float64(n)
+		if n >= 10 {
+			switch {
+			case m[2] != "":
+				level += float64(m[2][0]-'A') * 0.25
+			case m[3] == "+":
+				level += 0.75
+			case m[3] == "-":
+				// 5.10- 视同 5.10a
+			default:
+				level += 0.375 // 未标注字母时取中间值
+			}
+		}
+		label := "5." + m[1] + strings.ToLower(m[2]) + m[3]
+		return gradeInfo{Label: label, Level: level, System: GradeSystemYDS, Type: models.SportClimbing}, true
+	}
+
+	if m := fontPattern.FindStringSubmatch(g); m != nil {
+		key := m[1] + m[2] + m[3]
+		// 抱石使用 Font 级 (大写)，难度攀登使用法国级 (小写)
+		isBoulder := climbingType == models.Bouldering ||
+			(climbingType == "" && strings.ContainsAny(strings.TrimSpace(grade), "ABC"))
+		if isBoulder {
+			if level, ok := fontToV[key]; ok {
+				return gradeInfo{Label: key, Level: level, System: GradeSystemFont, Type: models.Bouldering}, true
+			}
+			return gradeInfo{}, false
+		}
+		if level, ok := frenchToYDS[key]; ok {
+			return gradeInfo{Label: strings.ToLower(key), Level: level, System: GradeSystemFrench, Type: models.SportClimbing}, true
+		}
+	}
+
+	return gradeInfo{}, false
+}
+
+// gradeDifficulty 将难度归一化到 0-1 (抱石 VB-V12，难度攀登 5.5-5.15)
+func gradeDifficulty(info gradeInfo) float64 {
+	var d float64
+	if info.Type == models.Bouldering {
+		d = (info.Level + 1) / 13
+	} else {
+		d = (info.Level - 5) / 10
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > 1 {
+		return 1
+	}
+	return d
+}
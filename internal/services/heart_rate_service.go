@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+)
+
+type HeartRateService struct {
+	db *gorm.DB
+}
+
+func NewHeartRateService(db *gorm.DB) *HeartRateService {
+	return &HeartRateService{db: db}
+}
+
+// SaveSeries 保存 (覆盖) 攀岩记录的心率序列，并同步记录上的平均/最大心率
+func (s *HeartRateService) SaveSeries(userID, recordID uint, points []models.HeartRatePoint) (*models.HeartRateSeries, error) {
+	var record models.ClimbingRecord
+	if err := s.db.Where("user_id = ? AND id = ?", userID, recordID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+
+	var series models.HeartRateSeries
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("record_id = ?", recordID).First(&series).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		series.UserID = userID
+		series.RecordID = recordID
+		series.SetPoints(points)
+		if err := tx.Save(&series).Error; err != nil {
+			return err
+		}
+
+		if series.SampleCount == 0 {
+			return nil
+		}
+		return tx.Model(&record).Updates(map[string]interface{}{
+			"avg_heart_rate": series.AvgBPM,
+			"max_heart_rate": series.MaxBPM,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// GetSeries 获取攀岩记录的心率序列
+func (s *HeartRateService) GetSeries(userID, recordID uint) (*models.HeartRateSeries, []models.HeartRatePoint, error) {
+	var series models.HeartRateSeries
+	if err := s.db.Where("user_id = ? AND record_id = ?", userID, recordID).First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRecordNotFound
+		}
+		return nil, nil, err
+	}
+
+	points, err := series.Points()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &series, points, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+type HistoryService struct {
+	db       *gorm.DB
+	climbing *ClimbingService
+	users    *UserService
+}
+
+func NewHistoryService(db *gorm.DB, climbing *ClimbingService, users *UserService) *HistoryService {
+	return &HistoryService{db: db, climbing: climbing, users: users}
+}
+
+// Subscribe 订阅攀岩记录和个人信息的变更事件，追加历史记录
+func (s *HistoryService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted, events.RecordPurged} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+	bus.Subscribe(events.ProfileUpdated, s.HandleProfileEvent)
+}
+
+// HandleRecordEvent 记录攀岩记录的变更及字段差异
+func (s *HistoryService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok {
+		return nil
+	}
+
+	var action models.HistoryAction
+	switch {
+	case e.Topic == events.RecordCreated && change.Action == events.RecordActionUndelete:
+		action = models.HistoryUndelete
+	case e.Topic == events.RecordCreated:
+		action = models.HistoryCreate
+	case e.Topic == events.RecordUpdated && change.Action == events.RecordActionRestore:
+		action = models.HistoryRestore
+	case e.Topic == events.RecordUpdated:
+		action = models.HistoryUpdate
+	case e.Topic == events.RecordDeleted:
+		action = models.HistoryDelete
+	case e.Topic == events.RecordPurged:
+		action = models.HistoryPurge
+	default:
+		return nil
+	}
+
+	current := change.After
+	if current == nil {
+		current = change.Before
+	}
+	if current == nil {
+		return nil
+	}
+
+	before, err := pickFields(change.Before, editableRecordFields)
+	if err != nil {
+		return err
+	}
+	snapshot, err := pickFields(current, editableRecordFields)
+	if err != nil {
+		return err
+	}
+
+	entry := models.HistoryEntry{
+		CreatedAt: e.OccurredAt,
+		UserID:    e.UserID,
+		Entity:    models.HistoryEntityRecord,
+		EntityID:  current.ID,
+		Action:    action,
+		Version:   current.Version,
+		ActorID:   e.UserID,
+		Device:    change.Device,
+		Changes:   []models.FieldChange{},
+		Snapshot:  snapshot,
+	}
+	if change.After != nil && action != models.HistoryUndelete {
+		entry.Changes = diffFields(before, snapshot, editableRecordFields)
+		if action == models.HistoryUpdate && len(entry.Changes) == 0 {
+			return nil // 只有服务端计算的字段变化 (如导入心率)
+		}
+	}
+	return s.db.Create(&entry).Error
+}
+
+// HandleProfileEvent 记录个人信息的字段差异
+func (s *HistoryService) HandleProfileEvent(e events.Event) error {
+	change, ok := e.Payload.(events.ProfileChange)
+	if !ok || change.Before == nil || change.After == nil {
+		return nil
+	}
+
+	before, err := pickFields(change.Before, profileFields)
+	if err != nil {
+		return err
+	}
+	after, err := pickFields(change.After, profileFields)
+	if err != nil {
+		return err
+	}
+	changes := diffFields(before, after, profileFields)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return s.db.Create(&models.HistoryEntry{
+		CreatedAt: e.OccurredAt,
+		UserID:    e.UserID,
+		Entity:    models.HistoryEntityProfile,
+		EntityID:  e.UserID,
+		Action:    models.HistoryUpdate,
+		ActorID:   e.UserID,
+		Changes:   changes,
+		Snapshot:  after,
+	}).Error
+}
+
+// GetRecordHistory 获取记录的变更历史 (含已删除的记录)，按时间倒序
+func (s *HistoryService) GetRecordHistory(userID, recordID uint) ([]models.HistoryEntry, error) {
+	entries, err := s.entries(userID, models.HistoryEntityRecord, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		// 启用历史记录前创建且未修改过的记录没有历史
+		var count int64
+		if err := s.db.Unscoped().Model(&models.ClimbingRecord{}).Where("user_id = ? AND id = ?", userID, recordID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrRecordNotFound
+		}
+	}
+	return entries, nil
+}
+
+// GetProfileHistory 获取个人信息的变更历史，按时间倒序
+func (s *HistoryService) GetProfileHistory(userID uint) ([]models.HistoryEntry, error) {
+	return s.entries(userID, models.HistoryEntityProfile, userID)
+}
+
+// RestoreRecord 将记录恢复到历史记录 entryID 对应的版本，恢复本身也会追加一条历史
+func (s *HistoryService) RestoreRecord(userID, recordID, entryID uint, expectedVersion *uint) (*models.ClimbingRecord, error) {
+	entry, err := s.entry(userID, models.HistoryEntityRecord, recordID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	return s.climbing.RestoreRecordFields(userID, recordID, entry.Snapshot, expectedVersion)
+}
+
+// RestoreProfile 将个人信息恢复到历史记录 entryID 对应的版本
+func (s *HistoryService) RestoreProfile(userID, entryID uint) error {
+	entry, err := s.entry(userID, models.HistoryEntityProfile, userID, entryID)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{}, len(entry.Snapshot))
+	for _, field := range profileFields {
+		raw, ok := entry.Snapshot[field]
+		if !ok {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		updates[field] = value
+	}
+	// 日期以 RFC 3339 字符串保存，转换为时间后写入
+	if birth, ok := updates["birth_date"].(string); ok {
+		t, err := time.Parse(time.RFC3339, birth)
+		if err != nil {
+			return err
+		}
+		updates["birth_date"] = t
+	}
+
+	return s.users.UpdateUserProfile(userID, updates)
+}
+
+func (s *HistoryService) entries(userID uint, entity string, entityID uint) ([]models.HistoryEntry, error) {
+	var entries []models.HistoryEntry
+	err := s.db.Where("user_id = ? AND entity = ? AND entity_id = ?", userID, entity, entityID).
+		Order("created_at DESC, id DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (s *HistoryService) entry(userID uint, entity string, entityID, entryID uint) (*models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+	err := s.db.Where("user_id = ? AND entity = ? AND entity_id = ? AND id = ?", userID, entity, entityID, entryID).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// pickFields 取出 v 的 JSON 字段，v 为 nil 时返回 nil
+func pickFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var all map[string]json.RawMessage
+	if err := remarshal(v, &all); err != nil {
+		return nil, err
+	}
+	if all == nil {
+		return nil, nil
+	}
+	picked := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			picked[field] = value
+		}
+	}
+	return picked, nil
+}
+
+// diffFields 按 fields 顺序返回发生变化的字段，before 为空时 (创建) 返回全部字段
+func diffFields(before, after map[string]json.RawMessage, fields []string) []models.FieldChange {
+	changes := []models.FieldChange{}
+	for _, field := range fields {
+		old, current := before[field], after[field]
+		if bytes.Equal(old, current) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: field, Old: old, New: current})
+	}
+	return changes
+}
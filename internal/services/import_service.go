@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/wearable"
+)
+
+// 导入结果动作
+const (
+	ImportActionCreated  = "created"  // 新建记录
+	ImportActionEnriched = "enriched" // 补充到已有记录
+)
+
+type ImportService struct {
+	db       *gorm.DB
+	climbing *ClimbingService
+}
+
+func NewImportService(db *gorm.DB, climbing *ClimbingService) *ImportService {
+	return &ImportService{db: db, climbing: climbing}
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	DefaultType models.ClimbingType // 无法从文件判断攀岩类型时使用
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Parsed   int              `json:"parsed"`
+	Created  int              `json:"created"`
+	Enriched int              `json:"enriched"`
+	Items    []ImportedRecord `json:"items"`
+}
+
+// ImportedRecord 单次训练的导入情况
+type ImportedRecord struct {
+	ExternalID string    `json:"external_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Action     string    `json:"action"`
+	RecordIDs  []uint    `json:"record_ids"`
+}
+
+// ImportWorkouts 将解析出的攀岩训练写入用户的攀岩记录
+//
+// 匹配规则:
+//   - 已导入过同一训练 (ExternalID 相同) 的记录直接更新，保证重复导入幂等
+//   - 与一条手动记录时间重叠时，用设备数据补充该记录的起止时间、心率和热量
+//   - 与多条记录重叠时 (一次训练内记录了多条线路)，只补充心率，
+//     热量按各记录的重叠时长分摊，不改动起止时间
+//   - 没有重叠记录时新建记录
+//
+// 每次训练的合并或新建在一个事务中完成，记录变更事件在事务提交后发布。
+func (s *ImportService) ImportWorkouts(userID uint, workouts []wearable.Workout, opts ImportOptions) (*ImportResult, error) {
+	if opts.DefaultType == "" {
+		opts.DefaultType = models.Bouldering
+	}
+
+	result := &ImportResult{Parsed: len(workouts), Items: []ImportedRecord{}}
+	for i := range workouts {
+		var item *ImportedRecord
+		var changes []func()
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			item, changes, err = s.importWorkout(tx, userID, &workouts[i], opts)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, publish := range changes {
+			publish()
+		}
+		if item.Action == ImportActionCreated {
+			result.Created++
+		} else {
+			result.Enriched++
+		}
+		result.Items = append(result.Items, *item)
+	}
+
+	return result, nil
+}
+
+// importWorkout 在事务 tx 中导入一次训练，返回提交后需要发布的记录变更事件
+func (s *ImportService) importWorkout(tx *gorm.DB, userID uint, w *wearable.Workout, opts ImportOptions) (*ImportedRecord, []func(), error) {
+	item := &ImportedRecord{
+		ExternalID: w.ExternalID,
+		StartTime:  w.StartTime,
+		EndTime:    w.EndTime,
+	}
+	var changes []func()
+
+	// 重复导入同一训练
+	var previous models.ClimbingRecord
+	err := tx.Where("user_id = ? AND external_id = ?", userID, w.ExternalID).First(&previous).Error
+	if err == nil {
+		change, err := s.enrichRecord(tx, &previous, w, true, w.Calories)
+		if err != nil {
+			return nil, nil, err
+		}
+		item.Action = ImportActionEnriched
+		item.RecordIDs = []uint{previous.ID}
+		return item, appendChange(changes, change), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	// 查找时间重叠的手动记录，已从其他文件导入的记录不参与合并，否则会覆盖其外部标识导致重复导入
+	var overlapping []models.ClimbingRecord
+	if err := tx.Where("user_id = ? AND start_time < ? AND end_time > ?", userID, w.EndTime, w.StartTime).
+		Where("(external_id IS NULL OR external_id = '')").
+		Order("start_time ASC").
+		Find(&overlapping).Error; err != nil {
+		return nil, nil, err
+	}
+
+	switch len(overlapping) {
+	case 0:
+		record, err := s.createFromWorkout(tx, userID, w, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := saveHeartRate(tx, userID, record, w.HeartRate); err != nil {
+			return nil, nil, err
+		}
+		// 重新读取心率序列写入的平均/最大心率
+		if err := tx.First(record, record.ID).Error; err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, func() { s.climbing.recordCreated(userID, "", record) })
+		item.Action = ImportActionCreated
+		item.RecordIDs = []uint{record.ID}
+	case 1:
+		change, err := s.enrichRecord(tx, &overlapping[0], w, true, w.Calories)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = appendChange(changes, change)
+		item.Action = ImportActionEnriched
+		item.RecordIDs = []uint{overlapping[0].ID}
+	default:
+		var totalOverlap time.Duration
+		for _, record := range overlapping {
+			totalOverlap += overlapDuration(record.StartTime, record.EndTime, w.StartTime, w.EndTime)
+		}
+		for i := range overlapping {
+			share := 0.0
+			if totalOverlap > 0 {
+				share = float64(overlapDuration(overlapping[i].StartTime, overlapping[i].EndTime, w.StartTime, w.EndTime)) / float64(totalOverlap)
+			}
+			change, err := s.enrichRecord(tx, &overlapping[i], w, false, w.Calories*share)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = appendChange(changes, change)
+			item.RecordIDs = append(item.RecordIDs, overlapping[i].ID)
+		}
+		item.Action = ImportActionEnriched
+	}
+
+	return item, changes, nil
+}
+
+func appendChange(changes []func(), change func()) []func() {
+	if change == nil {
+		return changes
+	}
+	return append(changes, change)
+}
+
+// createFromWorkout 根据设备训练在事务 tx 中新建攀岩记录
+func (s *ImportService) createFromWorkout(tx *gorm.DB, userID uint, w *wearable.Workout, opts ImportOptions) (*models.ClimbingRecord, error) {
+	climbingType := opts.DefaultType
+	if w.Bouldering {
+		climbingType = models.Bouldering
+	}
+
+	record := &models.ClimbingRecord{
+		Type:         climbingType,
+		StartTime:    w.StartTime,
+		EndTime:      w.EndTime,
+		Rating:       3, // 设备数据没有评分，使用中性评分以满足 rating 检查约束
+		AvgHeartRate: w.AvgHeartRate,
+		MaxHeartRate: w.MaxHeartRate,
+		Calories:     w.Calories,
+		Source:       recordSource(w.Format),
+		ExternalID:   w.ExternalID,
+	}
+
+	if err := s.climbing.insertRecord(tx, userID, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// enrichRecord 在事务 tx 中用设备数据补充已有记录，记录有变化时返回提交后发布更新事件的函数
+func (s *ImportService) enrichRecord(tx *gorm.DB, record *models.ClimbingRecord, w *wearable.Workout, replaceTimes bool, calories float64) (func(), error) {
+	updates := make(map[string]interface{})
+	if w.AvgHeartRate > 0 {
+		updates["avg_heart_rate"] = w.AvgHeartRate
+	}
+	if w.MaxHeartRate > 0 {
+		updates["max_heart_rate"] = w.MaxHeartRate
+	}
+
+	// 多条记录共享一次训练时，按记录自身时间窗口计算心率
+	if !replaceTimes {
+		if samples := w.SamplesBetween(record.StartTime, record.EndTime); len(samples) > 0 {
+			sum, maxBPM := 0, 0
+			for _, sample := range samples {
+				sum += sample.BPM
+				if sample.BPM > maxBPM {
+					maxBPM = sample.BPM
+				}
+			}
+			updates["avg_heart_rate"] = sum / len(samples)
+			updates["max_heart_rate"] = maxBPM
+		}
+	}
+
+	if replaceTimes {
+		updates["start_time"] = w.StartTime
+		updates["end_time"] = w.EndTime
+		updates["duration"] = int(w.EndTime.Sub(w.StartTime).Minutes())
+		updates["external_id"] = w.ExternalID
+		updates["source"] = recordSource(w.Format)
+	}
+	if calories > 0 {
+		updates["calories"] = calories
+	}
+
+	before := *record
+	if len(updates) > 0 {
+		updates["version"] = gorm.Expr("version + 1")
+		if err := tx.Model(record).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 保存记录时间窗口内的心率采样
+	samples := w.HeartRate
+	if !replaceTimes {
+		samples = w.SamplesBetween(record.StartTime, record.EndTime)
+	}
+	if err := saveHeartRate(tx, record.UserID, record, samples); err != nil {
+		return nil, err
+	}
+
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	if err := tx.First(record, record.ID).Error; err != nil {
+		return nil, err
+	}
+	after := *record
+	return func() { publishRecordChange(events.RecordUpdated, after.UserID, &before, &after) }, nil
+}
+
+// saveHeartRate 在事务 tx 中保存设备心率采样为记录的心率序列
+func saveHeartRate(tx *gorm.DB, userID uint, record *models.ClimbingRecord, samples []wearable.HeartRateSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	points := make([]models.HeartRatePoint, len(samples))
+	for i, sample := range samples {
+		points[i] = models.HeartRatePoint{Time: sample.Time, BPM: sample.BPM}
+	}
+	_, err := NewHeartRateService(tx).SaveSeries(userID, record.ID, points)
+	return err
+}
+
+// overlapDuration 计算两个时间段的重叠时长
+func overlapDuration(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start := aStart
+	if bStart.After(start) {
+		start = bStart
+	}
+	end := aEnd
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// recordSource 将文件格式映射为记录来源
+func recordSource(format wearable.Format) models.RecordSource {
+	switch format {
+	case wearable.FormatFIT:
+		return models.SourceGarminFIT
+	case wearable.FormatTCX:
+		return models.SourceTCX
+	}
+	return models.SourceAppleHealth
+}
@@ -0,0 +1,805 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard")
+
+// 排行榜指标
+const (
+	MetricSessions = "sessions" // 攀岩次数
+	MetricDuration = "duration" // 攀岩时长 (分钟)
+	MetricSends    = "sends"    // 完攀线路数
+	MetricCalories = "calories" // 消耗热量
+	MetricPoints   = "points"   // 8a 风格积分: 周期内最好的 10 次完攀得分之和
+	MetricHardest  = "hardest"  // 最高完攀难度，按难度得分比较
+)
+
+// 排行榜周期，按用户时区划分；周为 ISO 周 (周一开始)，保证全站排行榜的周期一致
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+)
+
+// 排行榜范围
+const (
+	ScopeGlobal   = "global"   // 全站
+	ScopeLocation = "location" // 同一地点 (岩馆)
+	ScopeTeam     = "team"     // 团队成员
+)
+
+// LeaderboardMetrics 全部排行榜指标
+var LeaderboardMetrics = []string{MetricSessions, MetricDuration, MetricSends, MetricCalories, MetricPoints, MetricHardest}
+
+// LeaderboardPeriods 全部排行榜周期
+var LeaderboardPeriods = []string{PeriodWeek, PeriodMonth, PeriodAll}
+
+const (
+	pointsTopAscents        = 10 // 积分只计算周期内最好的若干次完攀
+	defaultLeaderboardLimit = 20
+	maxLeaderboardLimit     = 100
+)
+
+var (
+	weekKeyPattern  = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+	monthKeyPattern = regexp.MustCompile(`^\d{4}-\d{2}$`)
+)
+
+// LeaderboardQuery 排行榜查询条件
+type LeaderboardQuery struct {
+	Metric   string
+	Period   string
+	Key      string // 周期键 (2026-W42、2026-10)，为空时取查看者时区的当前周期
+	Scope    string
+	Location string // 地点排行榜的地点
+	TeamID   uint   // 团队排行榜的团队
+}
+
+// LeaderboardEntry 排行榜中的一名用户
+type LeaderboardEntry struct {
+	Rank       int       `json:"rank"` // 成绩相同时先达到者靠前，名次不并列
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	AvatarURL  string    `json:"avatar_url"`
+	Value      float64   `json:"value"`
+	Grade      string    `json:"grade,omitempty"`
+	AchievedAt time.Time `json:"achieved_at"`
+}
+
+// Leaderboard 排行榜的一页
+type Leaderboard struct {
+	Metric   string             `json:"metric"`
+	Period   string             `json:"period"`
+	Key      string             `json:"key"`
+	Scope    string             `json:"scope"`
+	Location string             `json:"location,omitempty"`
+	TeamID   uint               `json:"team_id,omitempty"`
+	Total    int64              `json:"total"`  // 上榜人数
+	Offset   int                `json:"offset"` // 本页第一名的位置 (从 0 开始)
+	Entries  []LeaderboardEntry `json:"entries"`
+}
+
+// scoreBucket 用户成绩的一组排行榜 (周期键和地点)
+type scoreBucket struct {
+	Period   string
+	Location string
+}
+
+type LeaderboardService struct {
+	db *gorm.DB
+}
+
+func NewLeaderboardService(db *gorm.DB) *LeaderboardService {
+	return &LeaderboardService{db: db}
+}
+
+// Subscribe 订阅攀岩记录变更和时区设置变更事件，增量更新排行榜成绩
+func (s *LeaderboardService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+	bus.Subscribe(events.TimeSettingsChanged, func(e events.Event) error {
+		return s.RebuildUser(e.UserID)
+	})
+}
+
+// HandleRecordEvent 只重新计算变更前后记录所在的周、月、全部时间及其地点的成绩，全部时间的成绩增量更新
+func (s *LeaderboardService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok {
+		return nil
+	}
+
+	settings, err := userTimeSettings(s.db, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	buckets := make(map[scoreBucket]bool)
+	for _, r := range []*models.ClimbingRecord{change.Before, change.After} {
+		if r == nil {
+			continue
+		}
+		for _, b := range recordBuckets(r, settings.Location) {
+			buckets[b] = true
+		}
+	}
+	for b := range buckets {
+		if b.Period == PeriodAll {
+			err = s.refreshAllTime(e.UserID, b, change)
+		} else {
+			err = s.refreshBucket(e.UserID, b, settings.Location)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildUser 按用户当前时区从全部攀岩记录重建成绩
+func (s *LeaderboardService) RebuildUser(userID uint) error {
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		records, err := loadScoreRecords(tx.Where("user_id = ?", userID))
+		if err != nil {
+			return err
+		}
+		grouped := make(map[scoreBucket][]models.ClimbingRecord)
+		for _, r := range records {
+			for _, b := range recordBuckets(&r, settings.Location) {
+				grouped[b] = append(grouped[b], r)
+			}
+		}
+
+		var scores []models.LeaderboardScore
+		for b, rs := range grouped {
+			scores = append(scores, computeScores(userID, b, rs)...)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.LeaderboardScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&scores, 500).Error
+	})
+}
+
+// Backfill 为有攀岩记录但还没有排行榜成绩的用户重建成绩，用于上线后补齐历史数据
+//
+// 单个用户失败时记录日志并继续，最后返回所有失败用户的错误。
+func (s *LeaderboardService) Backfill() error {
+	var userIDs []uint
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Distinct("user_id").
+		Where("user_id NOT IN (?)", s.db.Model(&models.LeaderboardScore{}).Distinct("user_id")).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, userID := range userIDs {
+		if err := s.RebuildUser(userID); err != nil {
+			err = fmt.Errorf("rebuild leaderboard scores for user %d: %w", userID, err)
+			log.Println(err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetLeaderboard 获取排行榜中从 offset 开始的 limit 名
+func (s *LeaderboardService) GetLeaderboard(viewerID uint, q LeaderboardQuery, settings utils.TimeSettings, offset, limit int) (*Leaderboard, error) {
+	q, err := s.normalizeQuery(viewerID, q, settings)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.page(q, offset, limit)
+}
+
+// GetAround 获取以查看者为中心的一页排行榜，查看者不在榜上时返回 ErrRecordNotFound
+func (s *LeaderboardService) GetAround(viewerID uint, q LeaderboardQuery, settings utils.TimeSettings, limit int) (*Leaderboard, error) {
+	q, err := s.normalizeQuery(viewerID, q, settings)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.rank(q, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxLeaderboardLimit {
+		limit = defaultLeaderboardLimit
+	}
+	offset := entry.Rank - 1 - limit/2
+	if offset < 0 {
+		offset = 0
+	}
+	return s.page(q, offset, limit)
+}
+
+// GetRank 获取用户在排行榜上的名次，不在榜上时返回 ErrRecordNotFound
+func (s *LeaderboardService) GetRank(viewerID, userID uint, q LeaderboardQuery, settings utils.TimeSettings) (*LeaderboardEntry, int64, error) {
+	q, err := s.normalizeQuery(viewerID, q, settings)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry, err := s.rank(q, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	if err := s.scores(q).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	return entry, total, nil
+}
+
+func (s *LeaderboardService) page(q LeaderboardQuery, offset, limit int) (*Leaderboard, error) {
+	if limit <= 0 {
+		limit = defaultLeaderboardLimit
+	}
+	if limit > maxLeaderboardLimit {
+		limit = maxLeaderboardLimit
+	}
+	board := &Leaderboard{
+		Metric:   q.Metric,
+		Period:   q.Period,
+		Key:      q.Key,
+		Scope:    q.Scope,
+		Location: q.Location,
+		TeamID:   q.TeamID,
+		Offset:   offset,
+		Entries:  []LeaderboardEntry{},
+	}
+	if err := s.scores(q).Count(&board.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := s.scores(q).
+		Select(leaderboardColumns).
+		Order(leaderboardOrder).
+		Offset(offset).Limit(limit).
+		Scan(&board.Entries).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range board.Entries {
+		board.Entries[i].Rank = offset + i + 1
+	}
+	return board, nil
+}
+
+// rank 按排序规则计算用户的名次: 成绩更高，或成绩相同但更早达到 (再相同时用户ID更小) 的人数 + 1
+func (s *LeaderboardService) rank(q LeaderboardQuery, userID uint) (*LeaderboardEntry, error) {
+	var entry LeaderboardEntry
+	result := s.scores(q).
+		Select(leaderboardColumns).
+		Where("leaderboard_scores.user_id = ?", userID).
+		Limit(1).
+		Scan(&entry)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	var ahead int64
+	err := s.scores(q).
+		Where("leaderboard_scores.value > ? OR (leaderboard_scores.value = ? AND (leaderboard_scores.achieved_at < ? OR "+
+			"(leaderboard_scores.achieved_at = ? AND leaderboard_scores.user_id < ?)))",
+			entry.Value, entry.Value, entry.AchievedAt, entry.AchievedAt, entry.UserID).
+		Count(&ahead).Error
+	if err != nil {
+		return nil, err
+	}
+	entry.Rank = int(ahead) + 1
+	return &entry, nil
+}
+
+const (
+	leaderboardColumns = "leaderboard_scores.user_id, users.username, users.avatar_url, leaderboard_scores.value, " +
+		"leaderboard_scores.grade, leaderboard_scores.achieved_at"
+	leaderboardOrder = "leaderboard_scores.value DESC, leaderboard_scores.achieved_at ASC, leaderboard_scores.user_id ASC"
+)
+
+// scores 排行榜的成绩查询，排除已注销和退出排行榜的用户 (团队排行榜按团队内的设置)
+func (s *LeaderboardService) scores(q LeaderboardQuery) *gorm.DB {
+	db := s.db.Table("leaderboard_scores").
+		Joins("JOIN users ON users.id = leaderboard_scores.user_id AND users.deleted_at IS NULL").
+		Where("leaderboard_scores.metric = ? AND leaderboard_scores.period = ? AND leaderboard_scores.location = ?",
+			q.Metric, q.Key, q.Location)
+	if q.Scope == ScopeTeam {
+		return db.Joins("JOIN team_memberships ON team_memberships.user_id = leaderboard_scores.user_id "+
+			"AND team_memberships.team_id = ? AND team_memberships.leaderboard_opt_out = ?", q.TeamID, false)
+	}
+	return db.Where("users.leaderboard_opt_out = ?", false)
+}
+
+// normalizeQuery 校验查询条件并确定周期键；团队排行榜要求查看者是团队成员，且指标和周期已在团队设置中启用
+func (s *LeaderboardService) normalizeQuery(viewerID uint, q LeaderboardQuery, settings utils.TimeSettings) (LeaderboardQuery, error) {
+	if !contains(LeaderboardMetrics, q.Metric) {
+		return q, fmt.Errorf("%w: 未知的指标 %q", ErrInvalidLeaderboard, q.Metric)
+	}
+	key, err := leaderboardPeriodKey(q.Period, q.Key, time.Now(), settings.Location)
+	if err != nil {
+		return q, err
+	}
+	q.Key = key
+
+	switch q.Scope {
+	case "", ScopeGlobal:
+		q.Scope, q.Location, q.TeamID = ScopeGlobal, "", 0
+	case ScopeLocation:
+		q.Location, q.TeamID = normalizeLocation(q.Location), 0
+		if q.Location == "" {
+			return q, fmt.Errorf("%w: 地点排行榜需要指定地点", ErrInvalidLeaderboard)
+		}
+	case ScopeTeam:
+		q.Location = ""
+		var team models.Team
+		err := s.db.Joins("JOIN team_memberships ON team_memberships.team_id = teams.id AND team_memberships.user_id = ?", viewerID).
+			Where("teams.id = ?", q.TeamID).
+			First(&team).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return q, ErrRecordNotFound
+			}
+			return q, err
+		}
+		if !settingEnabled(team.LeaderboardMetrics, q.Metric) {
+			return q, fmt.Errorf("%w: 团队未启用指标 %q", ErrInvalidLeaderboard, q.Metric)
+		}
+		if !settingEnabled(team.LeaderboardPeriods, q.Period) {
+			return q, fmt.Errorf("%w: 团队未启用周期 %q", ErrInvalidLeaderboard, q.Period)
+		}
+	default:
+		return q, fmt.Errorf("%w: 未知的范围 %q", ErrInvalidLeaderboard, q.Scope)
+	}
+	return q, nil
+}
+
+// refreshBucket 从攀岩记录重新计算用户在一组排行榜上的成绩，成绩为 0 的排行榜删除
+func (s *LeaderboardService) refreshBucket(userID uint, b scoreBucket, loc *time.Location) error {
+	var from, to time.Time
+	if b.Period != PeriodAll {
+		var err error
+		if from, to, err = periodKeyRange(b.Period, loc); err != nil {
+			return err
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 与预聚合统计一样按用户串行刷新，避免并发的记录事件写入旧成绩
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		records, err := loadScoreRecords(scoreRecordsQuery(tx, userID, b, from, to))
+		if err != nil {
+			return err
+		}
+		return saveScores(tx, userID, b, computeScores(userID, b, records), nil)
+	})
+}
+
+// refreshAllTime 按变更前后的记录增量更新用户在全部时间排行榜上的成绩
+//
+// 全部时间的记录随使用时间不断增加，累计类指标 (次数、时长、热量、完攀数) 按差值更新；
+// 积分和最高难度只在变更的完攀可能进入最好的 10 次或达到最高难度时，从完攀记录重新计算。
+// 还没有成绩 (第一条记录或历史数据尚未补齐) 时完整计算。
+func (s *LeaderboardService) refreshAllTime(userID uint, b scoreBucket, change events.RecordChange) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		var existing []models.LeaderboardScore
+		if err := tx.Where("user_id = ? AND period = ? AND location = ?", userID, b.Period, b.Location).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		current := make(map[string]*models.LeaderboardScore, len(existing))
+		for i := range existing {
+			current[existing[i].Metric] = &existing[i]
+		}
+		if current[MetricSessions] == nil {
+			records, err := loadScoreRecords(scoreRecordsQuery(tx, userID, b, time.Time{}, time.Time{}))
+			if err != nil {
+				return err
+			}
+			return saveScores(tx, userID, b, computeScores(userID, b, records), nil)
+		}
+
+		var before, after *models.ClimbingRecord
+		if b.includes(change.Before) {
+			before = change.Before
+		}
+		if b.includes(change.After) {
+			after = change.After
+		}
+
+		values := make(map[string]float64, len(existing))
+		for metric, score := range current {
+			values[metric] = score.Value
+		}
+		apply := func(r *models.ClimbingRecord, sign float64) {
+			values[MetricSessions] += sign
+			values[MetricDuration] += sign * float64(r.Duration)
+			values[MetricCalories] += sign * r.Calories
+			if r.Success {
+				values[MetricSends] += sign
+			}
+		}
+		if before != nil {
+			apply(before, -1)
+		}
+		if after != nil {
+			apply(after, 1)
+		}
+		if values[MetricSessions] < 1 {
+			return tx.Where("user_id = ? AND period = ? AND location = ?", userID, b.Period, b.Location).
+				Delete(&models.LeaderboardScore{}).Error
+		}
+
+		// 达到时间为最后一条记录 (完攀数为最后一次完攀) 的时间；移除的记录可能是最后一条，此时重新查询
+		lastTime := current[MetricSessions].AchievedAt
+		var lastSend time.Time
+		if sends := current[MetricSends]; sends != nil {
+			lastSend = sends.AchievedAt
+		}
+		if before != nil {
+			var last struct {
+				LastTime *time.Time
+				LastSend *time.Time
+			}
+			if err := scoreRecordsQuery(tx, userID, b, time.Time{}, time.Time{}).
+				Model(&models.ClimbingRecord{}).
+				Select("MAX(start_time) AS last_time, MAX(CASE WHEN success THEN start_time END) AS last_send").
+				Scan(&last).Error; err != nil {
+				return err
+			}
+			lastTime, lastSend = time.Time{}, time.Time{}
+			if last.LastTime != nil {
+				lastTime = *last.LastTime
+			}
+			if last.LastSend != nil {
+				lastSend = *last.LastSend
+			}
+		} else if after != nil {
+			if after.StartTime.After(lastTime) {
+				lastTime = after.StartTime
+			}
+			if after.Success && after.StartTime.After(lastSend) {
+				lastSend = after.StartTime
+			}
+		}
+
+		var scores []models.LeaderboardScore
+		metrics := []string{MetricSessions, MetricDuration, MetricCalories, MetricSends}
+		for _, metric := range metrics {
+			achieved := lastTime
+			if metric == MetricSends {
+				achieved = lastSend
+			}
+			// 热量按差值累加会有浮点误差
+			if values[metric] > 1e-6 {
+				scores = append(scores, b.score(userID, metric, values[metric], achieved))
+			}
+		}
+
+		if ascentsChanged(current, before, after) {
+			records, err := loadScoreRecords(scoreRecordsQuery(tx, userID, b, time.Time{}, time.Time{}).Where("success = ?", true))
+			if err != nil {
+				return err
+			}
+			scores = append(scores, computeAscentScores(userID, b, records)...)
+			metrics = append(metrics, MetricPoints, MetricHardest)
+		}
+		return saveScores(tx, userID, b, scores, metrics)
+	})
+}
+
+// ascentsChanged 变更的完攀是否可能改变积分或最高难度
+//
+// 积分不足 10 次完攀时任何完攀都会计入；得分与计入门槛或最高难度相同时按达到时间排名，同样可能改变成绩。
+func ascentsChanged(current map[string]*models.LeaderboardScore, records ...*models.ClimbingRecord) bool {
+	points, hardest := current[MetricPoints], current[MetricHardest]
+	for _, r := range records {
+		if r == nil {
+			continue
+		}
+		a, ok := scoreAscent(r)
+		if !ok {
+			continue
+		}
+		if points == nil || points.Threshold == 0 || a.Points >= points.Threshold {
+			return true
+		}
+		if hardest == nil || a.Base >= hardest.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// saveScores 保存一组排行榜的成绩
+//
+// metrics 为空时 scores 是全部指标的成绩，其余指标的成绩删除；否则只更新 metrics 中的指标，
+// 其中不在 scores 里的 (成绩为 0) 删除。
+func saveScores(tx *gorm.DB, userID uint, b scoreBucket, scores []models.LeaderboardScore, metrics []string) error {
+	kept := make([]string, 0, len(scores))
+	for _, score := range scores {
+		kept = append(kept, score.Metric)
+	}
+
+	stale := tx.Where("user_id = ? AND period = ? AND location = ?", userID, b.Period, b.Location)
+	if len(metrics) > 0 {
+		stale = stale.Where("metric IN ?", metrics)
+	}
+	if len(kept) > 0 {
+		stale = stale.Where("metric NOT IN ?", kept)
+	}
+	if err := stale.Delete(&models.LeaderboardScore{}).Error; err != nil {
+		return err
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "metric"}, {Name: "period"}, {Name: "location"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "grade", "achieved_at", "threshold", "updated_at"}),
+	}).Create(&scores).Error
+}
+
+// scoreRecordsQuery 用户在一组排行榜的周期 [from, to) 和地点内的记录，全部时间的排行榜不限时间
+func scoreRecordsQuery(tx *gorm.DB, userID uint, b scoreBucket, from, to time.Time) *gorm.DB {
+	query := tx.Where("user_id = ?", userID)
+	if b.Period != PeriodAll {
+		query = query.Where("start_time >= ? AND start_time < ?", from, to)
+	}
+	if b.Location != "" {
+		query = query.Where("LOWER(TRIM(location)) = ?", b.Location)
+	}
+	return query
+}
+
+// loadScoreRecords 读取计算成绩需要的记录字段，按开始时间升序
+func loadScoreRecords(query *gorm.DB) ([]models.ClimbingRecord, error) {
+	var records []models.ClimbingRecord
+	err := query.Select("id", "start_time", "type", "grade", "duration", "calories", "success", "attempts", "style", "location").
+		Order("start_time ASC, id ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// recordBuckets 记录所在的周、月和全部时间，以及全部地点和记录地点的排行榜
+func recordBuckets(r *models.ClimbingRecord, loc *time.Location) []scoreBucket {
+	t := r.StartTime.In(loc)
+	year, week := t.ISOWeek()
+	periods := []string{fmt.Sprintf("%d-W%02d", year, week), t.Format("2006-01"), PeriodAll}
+
+	locations := []string{""}
+	if location := normalizeLocation(r.Location); location != "" {
+		locations = append(locations, location)
+	}
+
+	buckets := make([]scoreBucket, 0, len(periods)*len(locations))
+	for _, p := range periods {
+		for _, l := range locations {
+			buckets = append(buckets, scoreBucket{Period: p, Location: l})
+		}
+	}
+	return buckets
+}
+
+// computeScores 计算一组记录在各指标上的成绩，只返回大于 0 的成绩；records 按开始时间升序
+//
+// 累计类指标的达到时间为最后一条记录的时间，积分为计入的最后一次完攀的时间，最高难度为最早完成该难度的时间。
+func computeScores(userID uint, b scoreBucket, records []models.ClimbingRecord) []models.LeaderboardScore {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var duration, calories, sends float64
+	var lastSend time.Time
+	for _, r := range records {
+		duration += float64(r.Duration)
+		calories += r.Calories
+		if r.Success {
+			sends++
+			lastSend = r.StartTime
+		}
+	}
+	last := records[len(records)-1].StartTime
+
+	scores := []models.LeaderboardScore{b.score(userID, MetricSessions, float64(len(records)), last)}
+	if duration > 0 {
+		scores = append(scores, b.score(userID, MetricDuration, duration, last))
+	}
+	if calories > 0 {
+		scores = append(scores, b.score(userID, MetricCalories, calories, last))
+	}
+	if sends > 0 {
+		scores = append(scores, b.score(userID, MetricSends, sends, lastSend))
+	}
+	return append(scores, computeAscentScores(userID, b, records)...)
+}
+
+// computeAscentScores 计算一组记录的积分和最高难度成绩，没有计分的完攀时返回空；records 按开始时间升序
+func computeAscentScores(userID uint, b scoreBucket, records []models.ClimbingRecord) []models.LeaderboardScore {
+	var ascents []ascentScore
+	var hardest *ascentScore
+	for _, r := range records {
+		if a, ok := scoreAscent(&r); ok {
+			ascents = append(ascents, a)
+			if hardest == nil || a.Base > hardest.Base {
+				h := a
+				hardest = &h
+			}
+		}
+	}
+	if len(ascents) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(ascents, func(i, j int) bool { return ascents[i].Points > ascents[j].Points })
+	var threshold float64
+	if len(ascents) >= pointsTopAscents {
+		ascents = ascents[:pointsTopAscents]
+		threshold = ascents[pointsTopAscents-1].Points
+	}
+	var points float64
+	var achieved time.Time
+	for _, a := range ascents {
+		points += a.Points
+		if a.Time.After(achieved) {
+			achieved = a.Time
+		}
+	}
+	p := b.score(userID, MetricPoints, points, achieved)
+	p.Threshold = threshold
+
+	h := b.score(userID, MetricHardest, hardest.Base, hardest.Time)
+	h.Grade = hardest.Grade
+	return []models.LeaderboardScore{p, h}
+}
+
+// score 该组排行榜上的一项成绩
+func (b scoreBucket) score(userID uint, metric string, value float64, achieved time.Time) models.LeaderboardScore {
+	return models.LeaderboardScore{
+		Metric: metric, Period: b.Period, Location: b.Location, UserID: userID,
+		Value: value, AchievedAt: achieved,
+	}
+}
+
+// includes 记录是否属于全部时间的这组排行榜 (地点相同或不限地点)
+func (b scoreBucket) includes(r *models.ClimbingRecord) bool {
+	return r != nil && (b.Location == "" || normalizeLocation(r.Location) == b.Location)
+}
+
+// ascentScore 一次完攀的 8a 风格得分
+type ascentScore struct {
+	Base   float64 // 难度得分
+	Points float64 // 难度得分 + 完成方式加分
+	Grade  string
+	Time   time.Time
+}
+
+// scoreAscent 按 8a 的方式计算完攀得分，难度无法识别、顶绳和重复完成不计分
+//
+// 难度攀登 8a (5.13b) 为 1000 分，每小级 (如 8a 到 8a+) 50 分；抱石 8A (V11) 为 1000 分，每个 V 级 60 分。
+// 看攀加 150 分 (抱石视同闪攀)，闪攀加 50 分。
+func scoreAscent(r *models.ClimbingRecord) (ascentScore, bool) {
+	if !r.Success || r.Style == models.StyleTopRope || r.Style == models.StyleRepeat {
+		return ascentScore{}, false
+	}
+	info, ok := parseGrade(r.Type, r.Grade)
+	if !ok {
+		return ascentScore{}, false
+	}
+
+	var base float64
+	if info.Type == models.Bouldering {
+		base = 1000 + (info.Level-11)*60
+	} else {
+		base = 1000 + (info.Level-13.25)*200
+	}
+	if base <= 0 {
+		return ascentScore{}, false
+	}
+
+	points := base
+	switch {
+	case r.Style == models.StyleOnsight && info.Type != models.Bouldering:
+		points += 150
+	case r.IsFirstGoSend():
+		points += 50
+	}
+	return ascentScore{Base: base, Points: points, Grade: info.Label, Time: r.StartTime}, true
+}
+
+// leaderboardPeriodKey 校验周期和周期键，key 为空时取 loc 时区下 now 所在周期
+func leaderboardPeriodKey(period, key string, now time.Time, loc *time.Location) (string, error) {
+	switch period {
+	case PeriodWeek:
+		if key == "" {
+			year, week := now.In(loc).ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week), nil
+		}
+		if !weekKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("%w: 周的格式为 2026-W42", ErrInvalidLeaderboard)
+		}
+	case PeriodMonth:
+		if key == "" {
+			return now.In(loc).Format("2006-01"), nil
+		}
+		if !monthKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("%w: 月份的格式为 2026-10", ErrInvalidLeaderboard)
+		}
+	case PeriodAll:
+		return PeriodAll, nil
+	default:
+		return "", fmt.Errorf("%w: 未知的周期 %q", ErrInvalidLeaderboard, period)
+	}
+	return key, nil
+}
+
+// periodKeyRange 周期键在 loc 时区的起止时间 [from, to)
+func periodKeyRange(key string, loc *time.Location) (time.Time, time.Time, error) {
+	if weekKeyPattern.MatchString(key) {
+		var year, week int
+		if _, err := fmt.Sscanf(key, "%d-W%d", &year, &week); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		// 1 月 4 日总在第 1 周
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+		from := utils.StartOfWeek(jan4, loc, time.Monday).AddDate(0, 0, (week-1)*7)
+		return from, from.AddDate(0, 0, 7), nil
+	}
+	from, err := time.ParseInLocation("2006-01", key, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// normalizeLocation 地点排行榜的键，不区分大小写和首尾空白
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"movePoint/internal/models"
+)
+
+func boulder(grade string, success bool) *models.ClimbingRecord {
+	return &models.ClimbingRecord{Type: models.Bouldering, Grade: grade, Success: success, Style: models.StyleRedpoint}
+}
+
+func TestComputeAscentScores(t *testing.T) {
+	start := time.Date(2026, 10, 1, 18, 0, 0, 0, time.UTC)
+	var records []models.ClimbingRecord
+	for v := 0; v <= 10; v++ {
+		r := boulder(fmt.Sprintf("V%d", v), true)
+		r.StartTime = start.AddDate(0, 0, v)
+		records = append(records, *r)
+	}
+	b := scoreBucket{Period: PeriodAll}
+
+	scores := computeAscentScores(1, b, records)
+	if len(scores) != 2 {
+		t.Fatalf("got %d scores, want points and hardest", len(scores))
+	}
+	// 最好的 10 次为 V1 到 V10: 400 + 460 + ... + 940，V1 为计入门槛
+	points, hardest := scores[0], scores[1]
+	if points.Metric != MetricPoints || points.Value != 6700 || points.Threshold != 400 {
+		t.Errorf("points = %+v, want 6700 with threshold 400", points)
+	}
+	if hardest.Metric != MetricHardest || hardest.Value != 940 || hardest.Grade != "V10" {
+		t.Errorf("hardest = %+v, want V10 (940)", hardest)
+	}
+
+	// 不足 10 次完攀时没有门槛
+	if scores := computeAscentScores(1, b, records[:3]); scores[0].Threshold != 0 {
+		t.Errorf("threshold with 3 ascents = %v, want 0", scores[0].Threshold)
+	}
+	if scores := computeAscentScores(1, b, nil); scores != nil {
+		t.Errorf("scores without ascents = %+v, want nil", scores)
+	}
+}
+
+func TestAscentsChanged(t *testing.T) {
+	full := map[string]*models.LeaderboardScore{
+		MetricPoints:  {Metric: MetricPoints, Value: 10000, Threshold: 1000},
+		MetricHardest: {Metric: MetricHardest, Value: 1060},
+	}
+	partial := map[string]*models.LeaderboardScore{
+		MetricPoints:  {Metric: MetricPoints, Value: 2000},
+		MetricHardest: {Metric: MetricHardest, Value: 1060},
+	}
+
+	tests := []struct {
+		name    string
+		current map[string]*models.LeaderboardScore
+		records []*models.ClimbingRecord
+		want    bool
+	}{
+		{"no records in bucket", full, []*models.ClimbingRecord{nil, nil}, false},
+		{"attempt without send", full, []*models.ClimbingRecord{boulder("V12", false)}, false},
+		{"ungraded send", full, []*models.ClimbingRecord{boulder("", true)}, false},
+		{"below threshold", full, []*models.ClimbingRecord{nil, boulder("V10", true)}, false},
+		{"equal to threshold", full, []*models.ClimbingRecord{boulder("V11", true)}, true},
+		{"removed top ascent", full, []*models.ClimbingRecord{boulder("V12", true), nil}, true},
+		{"fewer than ten ascents", partial, []*models.ClimbingRecord{boulder("V0", true)}, true},
+		{"no ascent scores yet", map[string]*models.LeaderboardScore{}, []*models.ClimbingRecord{boulder("V0", true)}, true},
+		{"reaches hardest", map[string]*models.LeaderboardScore{
+			MetricPoints:  {Metric: MetricPoints, Value: 12000, Threshold: 1100},
+			MetricHardest: {Metric: MetricHardest, Value: 1000},
+		}, []*models.ClimbingRecord{boulder("V11", true)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ascentsChanged(tt.current, tt.records...); got != tt.want {
+				t.Errorf("ascentsChanged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
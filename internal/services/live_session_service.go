@@ -0,0 +1,423 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+var (
+	ErrInvalidLiveSession = errors.New("invalid live session")
+	ErrNoLiveSession      = errors.New("no live session")
+	ErrLiveSessionActive  = errors.New("live session already in progress")
+)
+
+// liveOpenStatuses 进行中 (含暂停) 的实时攀岩状态
+var liveOpenStatuses = []models.LiveStatus{models.LiveActive, models.LivePaused}
+
+// 实时攀岩默认的无操作超时时间
+const defaultLiveSessionTimeout = 3 * time.Hour
+
+// liveSessionTimeout 超过该时间没有任何操作的实时攀岩会被自动结束，可通过环境变量 LIVE_SESSION_TIMEOUT 覆盖 (如 "90m")
+func liveSessionTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(os.Getenv("LIVE_SESSION_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultLiveSessionTimeout
+}
+
+// LiveSessionState 推送给客户端的实时攀岩状态
+type LiveSessionState struct {
+	Session        models.LiveSession `json:"session"`
+	ElapsedSeconds int                `json:"elapsed_seconds"` // 不含暂停的有效时长
+	Ascents        int                `json:"ascents"`
+	Sends          int                `json:"sends"`
+	HardestGrade   string             `json:"hardest_grade,omitempty"`
+}
+
+// In 返回时间转换到 loc 时区的副本，同一状态会推送给多个连接，不能原地修改
+func (s LiveSessionState) In(loc *time.Location) LiveSessionState {
+	s.Session.Pauses = append([]models.LivePause(nil), s.Session.Pauses...)
+	s.Session.Ascents = append([]models.LiveAscent(nil), s.Session.Ascents...)
+	s.Session.LocalizeTimes(loc)
+	return s
+}
+
+// LiveSessionRequest 开始实时攀岩的参数
+type LiveSessionRequest struct {
+	Type      models.ClimbingType `json:"type"`
+	Location  string              `json:"location"`
+	ShareLive bool                `json:"share_live"` // 是否允许关注者观看，默认不允许
+}
+
+type LiveSessionService struct {
+	db       *gorm.DB
+	climbing *ClimbingService
+	hub      *events.Hub
+}
+
+func NewLiveSessionService(db *gorm.DB, climbing *ClimbingService, hub *events.Hub) *LiveSessionService {
+	return &LiveSessionService{db: db, climbing: climbing, hub: hub}
+}
+
+// Start 开始实时攀岩，同一时间只能有一次进行中的实时攀岩
+func (s *LiveSessionService) Start(userID uint, req LiveSessionRequest) (*LiveSessionState, error) {
+	if req.Type != models.Bouldering && req.Type != models.SportClimbing {
+		return nil, fmt.Errorf("%w: 无效的攀岩类型", ErrInvalidLiveSession)
+	}
+
+	now := time.Now().UTC()
+	session := models.LiveSession{
+		UserID:         userID,
+		Status:         models.LiveActive,
+		Type:           req.Type,
+		Location:       req.Location,
+		ShareLive:      req.ShareLive,
+		StartedAt:      now,
+		LastActivityAt: now,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定用户行，避免两个设备同时开始时都通过检查
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&models.LiveSession{}).
+			Where("user_id = ? AND status IN ?", userID, liveOpenStatuses).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrLiveSessionActive
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publish(events.LiveStarted, &session), nil
+}
+
+// Pause 暂停进行中的实时攀岩
+func (s *LiveSessionService) Pause(userID uint) (*LiveSessionState, error) {
+	session, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.LiveActive {
+		return nil, fmt.Errorf("%w: 实时攀岩已暂停", ErrInvalidLiveSession)
+	}
+
+	now := time.Now().UTC()
+	session.Status = models.LivePaused
+	session.PausedAt = &now
+	session.LastActivityAt = now
+	if err := saveLiveState(s.db, session); err != nil {
+		return nil, err
+	}
+	return s.publish(events.LivePaused, session), nil
+}
+
+// Resume 继续已暂停的实时攀岩
+func (s *LiveSessionService) Resume(userID uint) (*LiveSessionState, error) {
+	session, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.LivePaused {
+		return nil, fmt.Errorf("%w: 实时攀岩未暂停", ErrInvalidLiveSession)
+	}
+
+	now := time.Now().UTC()
+	session.ClosePause(now)
+	session.Status = models.LiveActive
+	session.LastActivityAt = now
+	if err := saveLiveState(s.db, session); err != nil {
+		return nil, err
+	}
+	return s.publish(events.LiveResumed, session), nil
+}
+
+// AddAscent 在进行中的实时攀岩里记录一条线路，暂停时记录会自动继续
+//
+// 未指定 logged_at 时使用当前时间；离线设备补传时可以指定，但不能早于开始时间或晚于当前时间。
+func (s *LiveSessionService) AddAscent(userID uint, ascent *models.LiveAscent) (*LiveSessionState, error) {
+	session, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if ascent.LoggedAt.IsZero() {
+		ascent.LoggedAt = now
+	}
+	ascent.LoggedAt = ascent.LoggedAt.UTC()
+	if ascent.LoggedAt.Before(session.StartedAt) || ascent.LoggedAt.After(now) {
+		return nil, fmt.Errorf("%w: 记录时间不在本次攀岩时间内", ErrInvalidLiveSession)
+	}
+	if ascent.Type == "" {
+		ascent.Type = session.Type
+	}
+	if ascent.Type != models.Bouldering && ascent.Type != models.SportClimbing {
+		return nil, fmt.Errorf("%w: 无效的攀岩类型", ErrInvalidLiveSession)
+	}
+	if ascent.Rating < 0 || ascent.Rating > 5 {
+		return nil, fmt.Errorf("%w: 评分应在 1-5 之间", ErrInvalidLiveSession)
+	}
+	ascent.ID = 0
+	ascent.SessionID = session.ID
+	ascent.RecordID = nil
+
+	if session.Status == models.LivePaused {
+		session.ClosePause(now)
+		session.Status = models.LiveActive
+	}
+	session.LastActivityAt = now
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 先更新状态，实时攀岩已被结束时不再记录线路
+		if err := saveLiveState(tx, session); err != nil {
+			return err
+		}
+		return tx.Create(ascent).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.Ascents = append(session.Ascents, *ascent)
+	sortAscents(session.Ascents)
+	return s.publish(events.LiveAscent, session), nil
+}
+
+// Stop 结束实时攀岩，每条线路转为一条攀岩记录
+func (s *LiveSessionService) Stop(userID uint) (*LiveSessionState, error) {
+	session, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.finish(session, models.LiveFinished, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return s.publish(events.LiveStopped, session), nil
+}
+
+// GetCurrent 获取用户进行中 (含暂停) 的实时攀岩
+func (s *LiveSessionService) GetCurrent(userID uint) (*LiveSessionState, error) {
+	session, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	return newLiveSessionState(session, time.Now()), nil
+}
+
+// CanWatch 本人和关注者可以观看实时攀岩
+func (s *LiveSessionService) CanWatch(viewerID, ownerID uint) (bool, error) {
+	if viewerID == ownerID {
+		return true, nil
+	}
+	var count int64
+	err := s.db.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", viewerID, ownerID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RunAutoClose 按 interval 定期结束长时间无操作的实时攀岩，
+// 阻塞运行，应在单独的 goroutine 中调用
+func (s *LiveSessionService) RunAutoClose(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.CloseAbandoned(time.Now()); err != nil {
+			log.Printf("Failed to close abandoned live sessions: %v", err)
+		}
+	}
+}
+
+// CloseAbandoned 结束 now 之前超时的实时攀岩，结束时间取最后一次操作的时间，已记录的线路照常转为攀岩记录
+func (s *LiveSessionService) CloseAbandoned(now time.Time) error {
+	var sessions []models.LiveSession
+	err := s.db.
+		Where("status IN ? AND last_activity_at < ?", liveOpenStatuses, now.Add(-liveSessionTimeout())).
+		Find(&sessions).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		session := &sessions[i]
+		err := s.finish(session, models.LiveAbandoned, session.LastActivityAt.UTC())
+		if errors.Is(err, ErrNoLiveSession) {
+			continue // 期间已被用户结束
+		}
+		if err != nil {
+			log.Printf("Failed to close live session %d: %v", session.ID, err)
+			continue
+		}
+		s.publish(events.LiveAbandoned, session)
+	}
+	return nil
+}
+
+// current 读取用户进行中 (含暂停) 的实时攀岩及其线路
+func (s *LiveSessionService) current(userID uint) (*models.LiveSession, error) {
+	var session models.LiveSession
+	err := s.db.Preload("Ascents").
+		Where("user_id = ? AND status IN ?", userID, liveOpenStatuses).
+		Order("started_at DESC").
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoLiveSession
+		}
+		return nil, err
+	}
+	sortAscents(session.Ascents)
+	return &session, nil
+}
+
+// finish 结束实时攀岩并生成攀岩记录
+//
+// 状态更新、记录创建和线路关联在同一事务中完成；实时攀岩已被其他请求结束时返回 ErrNoLiveSession，
+// 保证每条线路只转换一次。每条记录从上一条线路 (或开始/暂停结束) 到本条线路的记录时间，暂停时间不计入。
+func (s *LiveSessionService) finish(session *models.LiveSession, status models.LiveStatus, end time.Time) error {
+	if session.Status == models.LivePaused {
+		session.ClosePause(end)
+	}
+	session.Status = status
+	session.EndedAt = &end
+	session.LastActivityAt = end
+
+	var records []*models.ClimbingRecord
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveLiveState(tx, session); err != nil {
+			return err
+		}
+		// 状态更新后行已锁定，重新读取线路以包含结束前刚记录的线路
+		session.Ascents = nil
+		if err := tx.Where("session_id = ?", session.ID).Find(&session.Ascents).Error; err != nil {
+			return err
+		}
+		sortAscents(session.Ascents)
+
+		boundary := session.StartedAt
+		for i := range session.Ascents {
+			ascent := &session.Ascents[i]
+			start := boundary
+			for _, p := range session.Pauses {
+				if p.End.After(start) && !p.End.After(ascent.LoggedAt) {
+					start = p.End
+				}
+			}
+			boundary = ascent.LoggedAt
+
+			record := liveAscentRecord(session, ascent, start)
+			if err := s.climbing.insertRecord(tx, session.UserID, record); err != nil {
+				return err
+			}
+			ascent.RecordID = &record.ID
+			if err := tx.Model(ascent).Update("record_id", record.ID).Error; err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 事务提交后再发布记录事件，订阅者才能读到新记录
+	for _, record := range records {
+		s.climbing.recordCreated(session.UserID, "", record)
+	}
+	return nil
+}
+
+// saveLiveState 保存实时攀岩的状态字段，仅当仍在进行中 (含暂停) 时更新，已被结束时返回 ErrNoLiveSession
+func saveLiveState(db *gorm.DB, session *models.LiveSession) error {
+	result := db.Model(session).
+		Where("status IN ?", liveOpenStatuses).
+		Select("Status", "PausedAt", "Pauses", "LastActivityAt", "EndedAt").
+		Updates(session)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNoLiveSession
+	}
+	return nil
+}
+
+// publish 向本人的其他设备和关注者推送状态
+func (s *LiveSessionService) publish(topic events.Topic, session *models.LiveSession) *LiveSessionState {
+	state := newLiveSessionState(session, time.Now())
+	s.hub.Publish(events.Event{Topic: topic, UserID: session.UserID, Payload: *state})
+	return state
+}
+
+// liveAscentRecord 将实时记录的线路转为攀岩记录
+func liveAscentRecord(session *models.LiveSession, ascent *models.LiveAscent, start time.Time) *models.ClimbingRecord {
+	rating := ascent.Rating
+	if rating == 0 {
+		rating = 3 // 未评分时使用中性评分以满足 rating 检查约束
+	}
+	attempts := ascent.Attempts
+	if attempts == "" && !ascent.Success {
+		attempts = models.Failed
+	}
+	return &models.ClimbingRecord{
+		Type:      ascent.Type,
+		StartTime: start,
+		EndTime:   ascent.LoggedAt,
+		Grade:     ascent.Grade,
+		Color:     ascent.Color,
+		Attempts:  attempts,
+		Success:   ascent.Success,
+		Style:     ascent.Style,
+		Rating:    rating,
+		Location:  session.Location,
+		Notes:     ascent.Notes,
+		Source:    models.SourceLive,
+	}
+}
+
+// newLiveSessionState 汇总实时攀岩状态
+func newLiveSessionState(session *models.LiveSession, now time.Time) *LiveSessionState {
+	state := &LiveSessionState{
+		Session:        *session,
+		ElapsedSeconds: session.ElapsedSeconds(now),
+		Ascents:        len(session.Ascents),
+	}
+
+	hardest := -1.0
+	for _, ascent := range session.Ascents {
+		if !ascent.Success {
+			continue
+		}
+		state.Sends++
+		if info, ok := parseGrade(ascent.Type, ascent.Grade); ok && gradeDifficulty(info) > hardest {
+			hardest = gradeDifficulty(info)
+			state.HardestGrade = info.Label
+		}
+	}
+	return state
+}
+
+func sortAscents(ascents []models.LiveAscent) {
+	sort.SliceStable(ascents, func(i, j int) bool {
+		return ascents[i].LoggedAt.Before(ascents[j].LoggedAt)
+	})
+}
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// 里程碑阈值
+var milestoneThresholds = map[models.MilestoneKind][]int{
+	models.MilestoneSessions: {1, 10, 50, 100, 250, 500, 1000},
+	models.MilestoneHours:    {10, 50, 100, 250, 500, 1000},
+	models.MilestoneSends:    {10, 50, 100, 500, 1000, 5000},
+}
+
+type PersonalRecordService struct {
+	db *gorm.DB
+}
+
+func NewPersonalRecordService(db *gorm.DB) *PersonalRecordService {
+	return &PersonalRecordService{db: db}
+}
+
+// PersonalRecordEvent 刷新个人纪录事件内容
+type PersonalRecordEvent struct {
+	Record   models.PersonalRecord  `json:"record"`
+	Previous *models.PersonalRecord `json:"previous,omitempty"`
+}
+
+// Subscribe 订阅攀岩记录变更事件，增量维护个人纪录和里程碑；
+// 时区或每周起始日变化时按新的周期划分重新计算
+func (s *PersonalRecordService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+	bus.Subscribe(events.TimeSettingsChanged, func(e events.Event) error {
+		return s.RecalculateAll(e.UserID)
+	})
+}
+
+// GetPersonalRecords 获取用户的全部个人纪录
+func (s *PersonalRecordService) GetPersonalRecords(userID uint) ([]models.PersonalRecord, error) {
+	var records []models.PersonalRecord
+	err := s.db.Where("user_id = ?", userID).Order("category ASC, type ASC").Find(&records).Error
+	return records, err
+}
+
+// GetMilestones 获取用户已达成的里程碑
+func (s *PersonalRecordService) GetMilestones(userID uint) ([]models.Milestone, error) {
+	var milestones []models.Milestone
+	err := s.db.Where("user_id = ?", userID).Order("reached_at ASC").Find(&milestones).Error
+	return milestones, err
+}
+
+// HandleRecordEvent 根据记录变更增量更新个人纪录
+//
+// 单条记录类纪录 (最高难度、最长时长): 新记录更优时直接替换；
+// 变更前的记录正是纪录保持者时 (修改或删除)，从数据库重新计算，从而回退到上一纪录。
+// 按天/周统计的纪录 (单日完成数、单周攀岩分钟数): 重新统计受影响的周期，超过纪录时替换；
+// 纪录所在周期的数值下降时重新计算全部周期。
+func (s *PersonalRecordService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok {
+		return nil
+	}
+
+	settings, err := userTimeSettings(s.db, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range singleRecordTargets(change) {
+		if err := s.updateSingleRecordPR(e.UserID, target.category, target.climbingType, change); err != nil {
+			return err
+		}
+	}
+
+	for _, category := range []models.PersonalRecordCategory{models.PRMostSendsDay, models.PRMostMinutesWeek} {
+		periods := make(map[string]bool)
+		for _, r := range []*models.ClimbingRecord{change.Before, change.After} {
+			if r != nil {
+				periods[periodKey(category, r.StartTime, settings)] = true
+			}
+		}
+		for period := range periods {
+			if err := s.updatePeriodPR(e.UserID, category, period, settings); err != nil {
+				return err
+			}
+		}
+	}
+
+	if e.Topic == events.RecordCreated && change.After != nil {
+		return s.checkMilestones(e.UserID, change.After.ID)
+	}
+	return nil
+}
+
+// RecalculateAll 从全部历史记录重新计算用户的个人纪录
+func (s *PersonalRecordService) RecalculateAll(userID uint) error {
+	for _, climbingType := range []models.ClimbingType{models.Bouldering, models.SportClimbing} {
+		for _, category := range []models.PersonalRecordCategory{models.PRHardestSend, models.PRHardestFlash, models.PRHardestOnsight} {
+			if err := s.recomputeSingleRecordPR(userID, category, climbingType, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.recomputeSingleRecordPR(userID, models.PRLongestSession, "", nil); err != nil {
+		return err
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+	for _, category := range []models.PersonalRecordCategory{models.PRMostSendsDay, models.PRMostMinutesWeek} {
+		if err := s.recomputePeriodPR(userID, category, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type prTarget struct {
+	category     models.PersonalRecordCategory
+	climbingType models.ClimbingType
+}
+
+// singleRecordTargets 变更前后记录涉及的单条记录类纪录
+func singleRecordTargets(change events.RecordChange) []prTarget {
+	seen := make(map[prTarget]bool)
+	var targets []prTarget
+	add := func(t prTarget) {
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, r := range []*models.ClimbingRecord{change.Before, change.After} {
+		if r == nil {
+			continue
+		}
+		add(prTarget{models.PRLongestSession, ""})
+		for _, category := range []models.PersonalRecordCategory{models.PRHardestSend, models.PRHardestFlash, models.PRHardestOnsight} {
+			add(prTarget{category, r.Type})
+		}
+	}
+	return targets
+}
+
+// singleRecordValue 记录在某类纪录上的数值，不符合条件时返回 false
+func singleRecordValue(category models.PersonalRecordCategory, r *models.ClimbingRecord) (float64, string, bool) {
+	if category == models.PRLongestSession {
+		if r.Duration <= 0 {
+			return 0, "", false
+		}
+		return float64(r.Duration), strconv.Itoa(r.Duration), true
+	}
+
+	if !r.Success {
+		return 0, "", false
+	}
+	switch category {
+	case models.PRHardestFlash:
+		if !r.IsFirstGoSend() {
+			return 0, "", false
+		}
+	case models.PRHardestOnsight:
+		if r.Style != models.StyleOnsight {
+			return 0, "", false
+		}
+	}
+
+	info, ok := parseGrade(r.Type, r.Grade)
+	if !ok || info.Type != r.Type {
+		return 0, "", false
+	}
+	return info.Level, info.Label, true
+}
+
+func (s *PersonalRecordService) findPR(userID uint, category models.PersonalRecordCategory, climbingType models.ClimbingType) (*models.PersonalRecord, error) {
+	var pr models.PersonalRecord
+	err := s.db.Where("user_id = ? AND category = ? AND type = ?", userID, category, climbingType).First(&pr).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (s *PersonalRecordService) updateSingleRecordPR(userID uint, category models.PersonalRecordCategory, climbingType models.ClimbingType, change events.RecordChange) error {
+	current, err := s.findPR(userID, category, climbingType)
+	if err != nil {
+		return err
+	}
+
+	// 纪录保持记录被修改或删除，重新计算 (可能回退到上一纪录)
+	if current != nil && current.RecordID != nil && change.Before != nil && *current.RecordID == change.Before.ID {
+		return s.recomputeSingleRecordPR(userID, category, climbingType, current)
+	}
+
+	after := change.After
+	if after == nil || (category != models.PRLongestSession && after.Type != climbingType) {
+		return nil
+	}
+	value, display, ok := singleRecordValue(category, after)
+	if !ok || (current != nil && value <= current.Value) {
+		return nil
+	}
+
+	recordID := after.ID
+	return s.savePR(userID, current, models.PersonalRecord{
+		Category:   category,
+		Type:       climbingType,
+		Value:      value,
+		Display:    display,
+		RecordID:   &recordID,
+		AchievedAt: after.StartTime,
+	})
+}
+
+// recomputeSingleRecordPR 从数据库重新计算单条记录类纪录
+func (s *PersonalRecordService) recomputeSingleRecordPR(userID uint, category models.PersonalRecordCategory, climbingType models.ClimbingType, current *models.PersonalRecord) error {
+	query := s.db.Select("id", "type", "grade", "success", "attempts", "style", "duration", "start_time").
+		Where("user_id = ?", userID)
+	if category == models.PRLongestSession {
+		query = query.Order("duration DESC").Limit(1)
+	} else {
+		query = query.Where("type = ? AND success = ?", climbingType, true)
+	}
+
+	var candidates []models.ClimbingRecord
+	if err := query.Order("start_time ASC").Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	var best *models.ClimbingRecord
+	var bestValue float64
+	var bestDisplay string
+	for i := range candidates {
+		value, display, ok := singleRecordValue(category, &candidates[i])
+		if ok && (best == nil || value > bestValue) {
+			best, bestValue, bestDisplay = &candidates[i], value, display
+		}
+	}
+
+	if current == nil {
+		var err error
+		if current, err = s.findPR(userID, category, climbingType); err != nil {
+			return err
+		}
+	}
+	if best == nil {
+		if current != nil {
+			return s.db.Delete(current).Error
+		}
+		return nil
+	}
+
+	recordID := best.ID
+	return s.savePR(userID, current, models.PersonalRecord{
+		Category:   category,
+		Type:       climbingType,
+		Value:      bestValue,
+		Display:    bestDisplay,
+		RecordID:   &recordID,
+		AchievedAt: best.StartTime,
+	})
+}
+
+// updatePeriodPR 重新统计单个周期，与纪录比较
+func (s *PersonalRecordService) updatePeriodPR(userID uint, category models.PersonalRecordCategory, period string, settings utils.TimeSettings) error {
+	start, end := periodRange(category, period, settings)
+
+	var value int64
+	query := s.db.Model(&models.ClimbingRecord{}).
+		Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, start, end)
+	if category == models.PRMostMinutesWeek {
+		query = query.Select("COALESCE(SUM(duration), 0)").Scan(&value)
+	} else {
+		query = query.Where("success = ?", true).Count(&value)
+	}
+	if query.Error != nil {
+		return query.Error
+	}
+
+	current, err := s.findPR(userID, category, "")
+	if err != nil {
+		return err
+	}
+
+	if current != nil && current.Period == period && float64(value) < current.Value {
+		return s.recomputePeriodPR(userID, category, settings)
+	}
+	if value == 0 || (current != nil && float64(value) <= current.Value) {
+		return nil
+	}
+
+	return s.savePR(userID, current, models.PersonalRecord{
+		Category:   category,
+		Value:      float64(value),
+		Display:    strconv.FormatInt(value, 10),
+		Period:     period,
+		AchievedAt: start,
+	})
+}
+
+// recomputePeriodPR 统计全部周期，重新确定按天/周统计的纪录
+func (s *PersonalRecordService) recomputePeriodPR(userID uint, category models.PersonalRecordCategory, settings utils.TimeSettings) error {
+	var records []struct {
+		StartTime time.Time
+		Duration  int
+	}
+	query := s.db.Model(&models.ClimbingRecord{}).Where("user_id = ?", userID)
+	if category != models.PRMostMinutesWeek {
+		query = query.Where("success = ?", true)
+	}
+	if err := query.Select("start_time", "duration").
+		Order("start_time ASC").
+		Scan(&records).Error; err != nil {
+		return err
+	}
+
+	// 单周纪录累计攀岩分钟数，单日纪录累计完成数
+	counts := make(map[string]int)
+	bestPeriod, bestCount := "", 0
+	for _, r := range records {
+		period := periodKey(category, r.StartTime, settings)
+		if category == models.PRMostMinutesWeek {
+			counts[period] += r.Duration
+		} else {
+			counts[period]++
+		}
+		// 数值相同时保留更早达成的周期
+		if counts[period] > bestCount {
+			bestPeriod, bestCount = period, counts[period]
+		}
+	}
+
+	current, err := s.findPR(userID, category, "")
+	if err != nil {
+		return err
+	}
+	if bestCount == 0 {
+		if current != nil {
+			return s.db.Delete(current).Error
+		}
+		return nil
+	}
+
+	start, _ := periodRange(category, bestPeriod, settings)
+	return s.savePR(userID, current, models.PersonalRecord{
+		Category:   category,
+		Value:      float64(bestCount),
+		Display:    strconv.Itoa(bestCount),
+		Period:     bestPeriod,
+		AchievedAt: start,
+	})
+}
+
+// Backfill 删除旧版按完成数统计的单周纪录，为有攀岩记录但还没有单周时长纪录的用户计算该纪录
+//
+// 单个用户失败时记录日志并继续，最后返回所有失败用户的错误。
+func (s *PersonalRecordService) Backfill() error {
+	if err := s.db.Where("category = ?", models.PRLegacyVolumeWeek).Delete(&models.PersonalRecord{}).Error; err != nil {
+		return err
+	}
+
+	var userIDs []uint
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Distinct("user_id").
+		Where("user_id NOT IN (?)", s.db.Model(&models.PersonalRecord{}).
+			Distinct("user_id").
+			Where("category = ?", models.PRMostMinutesWeek)).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, userID := range userIDs {
+		settings, err := userTimeSettings(s.db, userID)
+		if err == nil {
+			err = s.recomputePeriodPR(userID, models.PRMostMinutesWeek, settings)
+		}
+		if err != nil {
+			err = fmt.Errorf("backfill personal records for user %d: %w", userID, err)
+			log.Println(err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// savePR 保存纪录，数值提升时发布刷新个人纪录事件
+func (s *PersonalRecordService) savePR(userID uint, current *models.PersonalRecord, next models.PersonalRecord) error {
+	next.UserID = userID
+	var previous *models.PersonalRecord
+	if current != nil {
+		copied := *current
+		previous = &copied
+		next.ID = current.ID
+		next.CreatedAt = current.CreatedAt
+	}
+
+	if err := s.db.Save(&next).Error; err != nil {
+		return err
+	}
+
+	if previous == nil || next.Value > previous.Value {
+		events.Publish(events.Event{
+			Topic:   events.PersonalRecordAchieved,
+			UserID:  userID,
+			Payload: PersonalRecordEvent{Record: next, Previous: previous},
+		})
+	}
+	return nil
+}
+
+// checkMilestones 检查累计次数、时长和完成数是否跨过里程碑阈值
+func (s *PersonalRecordService) checkMilestones(userID, recordID uint) error {
+	var totals struct {
+		Sessions int64
+		Minutes  int64
+		Sends    int64
+	}
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Select("COUNT(*) AS sessions, COALESCE(SUM(duration), 0) AS minutes, COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0) AS sends").
+		Where("user_id = ?", userID).
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+
+	values := map[models.MilestoneKind]int64{
+		models.MilestoneSessions: totals.Sessions,
+		models.MilestoneHours:    totals.Minutes / 60,
+		models.MilestoneSends:    totals.Sends,
+	}
+
+	for kind, value := range values {
+		for _, threshold := range milestoneThresholds[kind] {
+			if value < int64(threshold) {
+				break
+			}
+
+			var count int64
+			if err := s.db.Model(&models.Milestone{}).
+				Where("user_id = ? AND kind = ? AND threshold = ?", userID, kind, threshold).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+
+			milestone := models.Milestone{
+				UserID:    userID,
+				Kind:      kind,
+				Threshold: threshold,
+				RecordID:  recordID,
+				ReachedAt: time.Now(),
+			}
+			if err := s.db.Create(&milestone).Error; err != nil {
+				return err
+			}
+			events.Publish(events.Event{Topic: events.MilestoneReached, UserID: userID, Payload: milestone})
+		}
+	}
+	return nil
+}
+
+// periodKey 在用户时区内按天或按周计算周期键，按周时为该周第一天的日期
+func periodKey(category models.PersonalRecordCategory, t time.Time, settings utils.TimeSettings) string {
+	if category == models.PRMostMinutesWeek {
+		return utils.StartOfWeek(t, settings.Location, settings.WeekStart).Format("2006-01-02")
+	}
+	return t.In(settings.Location).Format("2006-01-02")
+}
+
+// periodRange 周期键对应的时间范围 [start, end)
+func periodRange(category models.PersonalRecordCategory, period string, settings utils.TimeSettings) (time.Time, time.Time) {
+	start, _ := time.ParseInLocation("2006-01-02", period, settings.Location)
+	if category == models.PRMostMinutesWeek {
+		return start, start.AddDate(0, 0, 7)
+	}
+	return start, start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+	"movePoint/internal/reports"
+	"movePoint/pkg/utils"
+)
+
+// ErrInvalidReport 报告周期无效 (格式错误或尚未开始)
+var ErrInvalidReport = errors.New("invalid report period")
+
+// 报告中常去场馆的数量
+const reportTopLocations = 5
+
+// ReportService 汇总分析和用户数据生成月度/年度报告
+type ReportService struct {
+	db       *gorm.DB
+	analysis *AnalysisService
+	users    *UserService
+}
+
+func NewReportService(db *gorm.DB, analysis *AnalysisService, users *UserService) *ReportService {
+	return &ReportService{db: db, analysis: analysis, users: users}
+}
+
+// ReportRange 报告周期在 loc 时区内的起止时间，key 为 YYYY-MM (月度) 或 YYYY (年度)
+func ReportRange(period models.ReportPeriod, key string, loc *time.Location) (time.Time, time.Time, error) {
+	var start, end time.Time
+	switch period {
+	case models.ReportMonthly:
+		t, err := time.ParseInLocation("2006-01", key, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("%w: %s", ErrInvalidReport, key)
+		}
+		start, end = t, t.AddDate(0, 1, 0)
+	case models.ReportYearly:
+		t, err := time.ParseInLocation("2006", key, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("%w: %s", ErrInvalidReport, key)
+		}
+		start, end = t, t.AddDate(1, 0, 0)
+	default:
+		return start, end, fmt.Errorf("%w: %s", ErrInvalidReport, period)
+	}
+	return start, end.Add(-time.Microsecond), nil
+}
+
+// GenerateReport 生成 (或重新生成) 报告并保存，周期可以是尚未结束的当月/当年；
+// 没有配置 PDF 字体时报告只保存 HTML，同时返回报告和 reports.ErrPDFFontRequired
+func (s *ReportService) GenerateReport(userID uint, period models.ReportPeriod, key string, scheduled bool) (*models.Report, error) {
+	report := models.Report{UserID: userID, Period: period, PeriodKey: key}
+	if err := s.db.Where(&report).FirstOrInit(&report).Error; err != nil {
+		return nil, err
+	}
+	report.Scheduled = scheduled
+
+	html, pdf, err := s.render(userID, period, key)
+	if errors.Is(err, ErrInvalidReport) {
+		return nil, err
+	}
+	// 没有配置 PDF 字体时仍保存 HTML 报告，只是没有 PDF
+	pdfMissing := errors.Is(err, reports.ErrPDFFontRequired)
+	if err != nil && !pdfMissing {
+		report.Status = models.ReportFailed
+		report.Error = err.Error()
+		if len(report.Error) > 255 {
+			report.Error = report.Error[:255]
+		}
+		if saveErr := s.db.Save(&report).Error; saveErr != nil {
+			return nil, saveErr
+		}
+		return &report, err
+	}
+
+	now := time.Now()
+	report.Status = models.ReportReady
+	report.Error = ""
+	report.HTML = string(html)
+	report.PDF = pdf
+	report.GeneratedAt = &now
+	if saveErr := s.db.Save(&report).Error; saveErr != nil {
+		return nil, saveErr
+	}
+	return &report, err
+}
+
+// ListReports 用户的报告列表 (不含报告内容)，按周期倒序
+func (s *ReportService) ListReports(userID uint) ([]models.Report, error) {
+	var list []models.Report
+	err := s.db.Omit("html", "pdf").
+		Where("user_id = ?", userID).
+		Order("period_key DESC, period ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// GetReport 获取报告 (含 HTML 和 PDF 内容)
+func (s *ReportService) GetReport(userID, reportID uint) (*models.Report, error) {
+	var report models.Report
+	err := s.db.Where("user_id = ? AND id = ?", userID, reportID).First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// RunScheduler 按 interval 定期为有攀岩记录的用户生成上月和去年的报告，阻塞运行，应在单独的 goroutine 中调用
+func (s *ReportService) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if generated, err := s.GenerateDueReports(time.Now()); err != nil {
+			log.Printf("Failed to generate scheduled reports: %v", err)
+		} else if generated > 0 {
+			log.Printf("Generated %d scheduled reports", generated)
+		}
+	}
+}
+
+// GenerateDueReports 为每个用户生成 now 时 (用户时区) 上一个完整月份和年份中尚未生成的报告，
+// 周期内没有攀岩记录时跳过，返回生成的报告数
+func (s *ReportService) GenerateDueReports(now time.Time) (int, error) {
+	var userIDs []uint
+	if err := s.db.Model(&models.ClimbingRecord{}).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, userID := range userIDs {
+		settings, err := userTimeSettings(s.db, userID)
+		if err != nil {
+			log.Printf("Failed to load time settings for user %d: %v", userID, err)
+			continue
+		}
+
+		month := utils.StartOfMonth(now, settings.Location)
+		due := map[models.ReportPeriod]string{
+			models.ReportMonthly: month.AddDate(0, -1, 0).Format("2006-01"),
+			models.ReportYearly:  strconv.Itoa(month.Year() - 1),
+		}
+		for period, key := range due {
+			ok, err := s.reportDue(userID, period, key, settings.Location)
+			if err != nil {
+				return generated, err
+			}
+			if !ok {
+				continue
+			}
+			// 缺少 PDF 字体时 HTML 报告已保存为 ready，不会在下次定时任务中重复生成
+			if _, err := s.GenerateReport(userID, period, key, true); err != nil && !errors.Is(err, reports.ErrPDFFontRequired) {
+				log.Printf("Failed to generate %s report %s for user %d: %v", period, key, userID, err)
+				continue
+			}
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+// reportDue 报告尚未生成 (或上次失败) 且周期内有攀岩记录
+func (s *ReportService) reportDue(userID uint, period models.ReportPeriod, key string, loc *time.Location) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.Report{}).
+		Where("user_id = ? AND period = ? AND period_key = ? AND status = ?", userID, period, key, models.ReportReady).
+		Count(&count).Error; err != nil || count > 0 {
+		return false, err
+	}
+
+	from, to, err := ReportRange(period, key, loc)
+	if err != nil {
+		return false, err
+	}
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// render 汇总报告数据并渲染为 HTML 和 PDF，PDF 渲染失败时仍返回 HTML
+func (s *ReportService) render(userID uint, period models.ReportPeriod, key string) ([]byte, []byte, error) {
+	data, err := s.BuildReportData(userID, period, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	html, err := reports.RenderHTML(*data)
+	if err != nil {
+		return nil, nil, err
+	}
+	pdf, err := reports.RenderPDF(*data)
+	if err != nil {
+		return html, nil, err
+	}
+	return html, pdf, nil
+}
+
+// BuildReportData 汇总报告数据，按用户的时间设置划分周期
+func (s *ReportService) BuildReportData(userID uint, period models.ReportPeriod, key string) (*reports.Data, error) {
+	user, err := s.users.GetUserProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	settings := utils.NewTimeSettings(user.Timezone, user.WeekStart, user.Locale)
+	loc := settings.Location
+
+	from, to, err := ReportRange(period, key, loc)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if from.After(now) {
+		return nil, fmt.Errorf("%w: %s 尚未开始", ErrInvalidReport, key)
+	}
+	prevFrom, _, _ := ReportRange(period, previousPeriodKey(period, from), loc)
+
+	data := &reports.Data{
+		Lang:        reports.Language(settings.Locale),
+		Username:    user.Username,
+		Period:      string(period),
+		PeriodKey:   key,
+		From:        from,
+		To:          to,
+		GeneratedAt: now.In(loc),
+	}
+
+	// 按天汇总: 总览、攀岩天数、最忙的一天和连续天数
+	days, err := s.analysis.GetAnalysisSeries(userID, from, to, settings, []string{GroupByDay}, AnalysisFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var current models.RollupTotals
+	var activeDays []time.Time
+	var busiest *SeriesPoint
+	for i, p := range days.Points {
+		current.Sessions += p.Sessions
+		current.Duration += p.Duration
+		current.Calories += p.Calories
+		current.Sends += p.Sends
+		if p.Sessions == 0 {
+			continue
+		}
+		day, _ := time.ParseInLocation("2006-01-02", p.Keys[GroupByDay], loc)
+		activeDays = append(activeDays, day)
+		if busiest == nil || p.Sessions > busiest.Sessions {
+			busiest = &days.Points[i]
+		}
+	}
+	data.Totals = reports.Totals{
+		Sessions:    current.Sessions,
+		ActiveDays:  len(activeDays),
+		Duration:    current.Duration,
+		Calories:    round1(current.Calories),
+		Sends:       current.Sends,
+		SuccessRate: round1(percentage(current.Sends, current.Sessions)),
+	}
+	if longest, _ := longestAndCurrent(activeDays, 1, to); longest.Length > 0 {
+		data.Streak = reports.Streak{Days: longest.Length, Start: longest.Start, End: longest.End}
+	}
+
+	// 与上一周期对比
+	previous, err := s.analysis.GetAnalysisSeries(userID, prevFrom, from.Add(-time.Microsecond), settings, []string{GroupByMonth}, AnalysisFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var prev models.RollupTotals
+	for _, p := range previous.Points {
+		prev.Sessions += p.Sessions
+		prev.Duration += p.Duration
+		prev.Sends += p.Sends
+	}
+	for _, c := range []struct {
+		metric            string
+		current, previous int
+	}{
+		{"sessions", current.Sessions, prev.Sessions},
+		{"duration", current.Duration, prev.Duration},
+		{"sends", current.Sends, prev.Sends},
+	} {
+		delta := newMetricDelta(float64(c.current), float64(c.previous))
+		data.Changes = append(data.Changes, reports.Change{Metric: c.metric, Current: delta.Current, Previous: delta.Previous, PercentChange: delta.PercentChange})
+	}
+
+	// 亮点: 各类型最高完成难度、最长单次攀岩、攀岩最多的一天
+	highlights, err := s.highlights(userID, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	data.Highlights = highlights
+	if busiest != nil {
+		day, _ := time.ParseInLocation("2006-01-02", busiest.Keys[GroupByDay], loc)
+		data.Highlights = append(data.Highlights, reports.Item{Key: "busiest_day", Value: strconv.Itoa(busiest.Sessions), Date: day})
+	}
+
+	// 周期内首次完成的难度
+	progressions, err := s.analysis.GetGradeProgressions(userID, "", from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range progressions {
+		for _, first := range p.FirstSends {
+			if !first.Date.Before(from) {
+				data.NewGrades = append(data.NewGrades, reports.Item{Key: string(p.Type), Value: first.Grade, Date: first.Date})
+			}
+		}
+	}
+
+	// 常去的场馆
+	locations, err := s.analysis.GetAnalysisSeries(userID, from, to, settings, []string{GroupByLocation}, AnalysisFilter{})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(locations.Points, func(i, j int) bool {
+		return locations.Points[i].Sessions > locations.Points[j].Sessions
+	})
+	for _, p := range locations.Points {
+		if name := p.Keys[GroupByLocation]; name != "" && len(data.Locations) < reportTopLocations {
+			data.Locations = append(data.Locations, reports.Item{Key: name, Value: strconv.Itoa(p.Sessions)})
+		}
+	}
+
+	// 周期内刷新的个人纪录、达成的里程碑和解锁的成就
+	inRange := func(t time.Time) bool { return !t.Before(from) && !t.After(to) }
+	for _, pr := range user.PersonalRecords {
+		if inRange(pr.AchievedAt) {
+			data.PersonalRecords = append(data.PersonalRecords, reports.Item{Key: string(pr.Category), Title: string(pr.Type), Value: pr.Display, Date: pr.AchievedAt.In(loc)})
+		}
+	}
+	for _, m := range user.Milestones {
+		if inRange(m.ReachedAt) {
+			data.Milestones = append(data.Milestones, reports.Item{Key: "milestone_" + string(m.Kind), Value: strconv.Itoa(m.Threshold), Date: m.ReachedAt.In(loc)})
+		}
+	}
+	achievements, err := s.users.GetUserAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range achievements {
+		if a.Completed && inRange(a.UnlockedAt) {
+			data.Achievements = append(data.Achievements, reports.Item{Key: a.ID, Title: a.Name, Date: a.UnlockedAt.In(loc)})
+		}
+	}
+	sortItems := func(items []reports.Item) {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+	}
+	sortItems(data.PersonalRecords)
+	sortItems(data.Milestones)
+	sortItems(data.Achievements)
+
+	return data, nil
+}
+
+// highlights 各攀岩类型的最高完成难度和最长单次攀岩
+func (s *ReportService) highlights(userID uint, from, to time.Time, loc *time.Location) ([]reports.Item, error) {
+	var records []models.ClimbingRecord
+	if err := s.db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).
+		Order("start_time ASC").
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	var items []reports.Item
+	for _, t := range climbingTypes("") {
+		var best *gradedRecord
+		sends := gradedSends(t, records)
+		for i := range sends {
+			if best == nil || sends[i].grade.Level > best.grade.Level {
+				best = &sends[i]
+			}
+		}
+		if best != nil {
+			items = append(items, reports.Item{Key: "hardest_send", Title: string(t), Value: best.grade.Label, Date: best.record.StartTime.In(loc)})
+		}
+	}
+
+	var longest *models.ClimbingRecord
+	for i := range records {
+		if longest == nil || records[i].Duration > longest.Duration {
+			longest = &records[i]
+		}
+	}
+	if longest != nil && longest.Duration > 0 {
+		items = append(items, reports.Item{Key: "longest_session", Value: strconv.Itoa(longest.Duration), Date: longest.StartTime.In(loc)})
+	}
+	return items, nil
+}
+
+// previousPeriodKey 上一个报告周期的 key
+func previousPeriodKey(period models.ReportPeriod, start time.Time) string {
+	if period == models.ReportYearly {
+		return strconv.Itoa(start.Year() - 1)
+	}
+	return start.AddDate(0, -1, 0).Format("2006-01")
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// roleNamePattern 角色名: 小写字母开头，只含小写字母、数字和下划线
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+type RoleService struct {
+	db *gorm.DB
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+// EnsureBuiltinRoles 创建缺少的内置角色，已存在的角色保留管理员修改过的权限
+func (s *RoleService) EnsureBuiltinRoles() error {
+	for name, permissions := range models.BuiltinRoles {
+		role := models.Role{Name: name, Builtin: true, Permissions: permissions}
+		if err := s.db.Where(models.Role{Name: name}).Attrs(role).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GrantAdmins 为指定邮箱的用户添加管理员角色，用于初始化第一个管理员
+func (s *RoleService) GrantAdmins(emails []string) error {
+	var admin models.Role
+	if err := s.db.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		return err
+	}
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		var user models.User
+		if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue // 尚未注册
+			}
+			return err
+		}
+		if err := s.db.Model(&user).Association("Roles").Append(&admin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRoles 获取全部角色
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := s.db.Order("builtin DESC, name").Find(&roles).Error
+	return roles, err
+}
+
+// CreateRole 创建自定义角色
+func (s *RoleService) CreateRole(role *models.Role) error {
+	role.ID = 0
+	role.Builtin = false
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if err := s.ensureUniqueName(role.Name, 0); err != nil {
+		return err
+	}
+	return s.db.Create(role).Error
+}
+
+// UpdateRole 修改角色的描述和权限，内置角色不能改名
+func (s *RoleService) UpdateRole(roleID uint, update *models.Role) (*models.Role, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.Builtin && update.Name != role.Name {
+		return nil, fmt.Errorf("%w: 内置角色不能改名", ErrInvalidRole)
+	}
+	if err := validateRole(update); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUniqueName(update.Name, roleID); err != nil {
+		return nil, err
+	}
+
+	role.Name = update.Name
+	role.Description = update.Description
+	role.Permissions = update.Permissions
+	if err := s.db.Save(role).Error; err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole 删除自定义角色，并移除用户的该角色
+func (s *RoleService) DeleteRole(roleID uint) error {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return err
+	}
+	if role.Builtin {
+		return fmt.Errorf("%w: 内置角色不能删除", ErrInvalidRole)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", roleID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+}
+
+// SetUserRoles 将用户的角色替换为 names，不能移除最后一名管理员的管理员角色
+func (s *RoleService) SetUserRoles(userID uint, names []string) (*models.User, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: 至少指定一个角色", ErrInvalidRole)
+	}
+
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Roles").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+
+		var roles []models.Role
+		if err := tx.Where("name IN ?", names).Find(&roles).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(uniqueStrings(names)) {
+			return fmt.Errorf("%w: 角色不存在", ErrInvalidRole)
+		}
+
+		if hasRole(user.Roles, models.RoleAdmin) && !hasRole(roles, models.RoleAdmin) {
+			var admins int64
+			err := tx.Table("user_roles").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+				Where("roles.name = ? AND user_roles.user_id <> ?", models.RoleAdmin, userID).
+				Count(&admins).Error
+			if err != nil {
+				return err
+			}
+			if admins == 0 {
+				return fmt.Errorf("%w: 至少需要保留一名管理员", ErrInvalidRole)
+			}
+		}
+
+		if err := tx.Model(&user).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		user.Roles = roles
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UserAccess 获取用户的角色名和权限 (去重排序)，供 AccessMiddleware 每次请求读取
+func (s *RoleService) UserAccess(userID uint) ([]string, []string, error) {
+	return userAccess(s.db, userID)
+}
+
+func (s *RoleService) getRole(roleID uint) (*models.Role, error) {
+	var role models.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *RoleService) ensureUniqueName(name string, exceptID uint) error {
+	var count int64
+	if err := s.db.Model(&models.Role{}).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: 角色名已存在", ErrInvalidRole)
+	}
+	return nil
+}
+
+// validateRole 检查角色名和权限格式，权限为冒号分隔的非空分段
+func validateRole(role *models.Role) error {
+	if !roleNamePattern.MatchString(role.Name) {
+		return fmt.Errorf("%w: 角色名只能包含小写字母、数字和下划线，长度 2-32", ErrInvalidRole)
+	}
+	if len(role.Permissions) == 0 {
+		return fmt.Errorf("%w: 至少指定一个权限", ErrInvalidRole)
+	}
+	for _, permission := range role.Permissions {
+		if strings.Contains(permission, ",") {
+			return fmt.Errorf("%w: 无效的权限 %q", ErrInvalidRole, permission)
+		}
+		for _, part := range strings.Split(permission, ":") {
+			if strings.TrimSpace(part) == "" {
+				return fmt.Errorf("%w: 无效的权限 %q", ErrInvalidRole, permission)
+			}
+		}
+	}
+	return nil
+}
+
+// assignRole 为用户添加角色
+func assignRole(db *gorm.DB, user *models.User, name string) error {
+	var role models.Role
+	if err := db.Where("name = ?", name).First(&role).Error; err != nil {
+		return err
+	}
+	return db.Model(user).Association("Roles").Append(&role)
+}
+
+// userAccess 获取用户的角色名和权限，没有任何角色的用户 (如启用角色前注册的用户) 视为攀岩者
+func userAccess(db *gorm.DB, userID uint) ([]string, []string, error) {
+	var roles []models.Role
+	err := db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roles) == 0 {
+		athlete := models.Role{Name: models.RoleAthlete, Permissions: models.BuiltinRoles[models.RoleAthlete]}
+		if err := db.Where("name = ?", models.RoleAthlete).Limit(1).Find(&athlete).Error; err != nil {
+			return nil, nil, err
+		}
+		roles = []models.Role{athlete}
+	}
+
+	names := make([]string, 0, len(roles))
+	var permissions []string
+	for _, role := range roles {
+		names = append(names, role.Name)
+		permissions = append(permissions, role.Permissions...)
+	}
+	return names, uniqueStrings(permissions), nil
+}
+
+func hasRole(roles []models.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueStrings 去重并排序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+type RollupService struct {
+	db *gorm.DB
+}
+
+func NewRollupService(db *gorm.DB) *RollupService {
+	return &RollupService{db: db}
+}
+
+// rollupBucket 预聚合统计的一个桶 (某天或某月、某类型、某难度)
+type rollupBucket struct {
+	Period string
+	Type   models.ClimbingType
+	Grade  string
+	models.RollupTotals
+}
+
+// Subscribe 订阅攀岩记录变更和时区设置变更事件，维护每日和每月预聚合统计
+func (s *RollupService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+	bus.Subscribe(events.TimeSettingsChanged, func(e events.Event) error {
+		return s.RebuildUser(e.UserID)
+	})
+}
+
+// HandleRecordEvent 重新统计变更前后记录所在的日期及其月份
+func (s *RollupService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok {
+		return nil
+	}
+
+	settings, err := userTimeSettings(s.db, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	days := make(map[string]bool)
+	for _, r := range []*models.ClimbingRecord{change.Before, change.After} {
+		if r != nil {
+			days[r.StartTime.In(settings.Location).Format("2006-01-02")] = true
+		}
+	}
+	for day := range days {
+		if err := s.refreshDay(e.UserID, day, settings.Location); err != nil {
+			return err
+		}
+	}
+
+	events.Publish(events.Event{Topic: events.RollupsUpdated, UserID: e.UserID})
+	return nil
+}
+
+// refreshDay 从攀岩记录重新统计用户某一天，并在同一事务中重新汇总所在月份
+func (s *RollupService) refreshDay(userID uint, day string, loc *time.Location) error {
+	start, err := time.ParseInLocation("2006-01-02", day, loc)
+	if err != nil {
+		return err
+	}
+
+	month := day[:7]
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 同一用户的统计刷新串行执行，避免并发的记录事件读到旧数据或重复插入同一统计桶
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		var records []models.ClimbingRecord
+		if err := tx.Select("start_time", "type", "grade", "duration", "calories", "success").
+			Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, start, start.AddDate(0, 0, 1)).
+			Find(&records).Error; err != nil {
+			return err
+		}
+
+		var daily []models.DailyRollup
+		for _, b := range bucketRecords(records, loc, "2006-01-02") {
+			daily = append(daily, models.DailyRollup{UserID: userID, Day: day, Type: b.Type, Grade: b.Grade, RollupTotals: b.RollupTotals})
+		}
+
+		if err := tx.Where("user_id = ? AND day = ?", userID, day).Delete(&models.DailyRollup{}).Error; err != nil {
+			return err
+		}
+		if len(daily) > 0 {
+			if err := tx.Create(&daily).Error; err != nil {
+				return err
+			}
+		}
+
+		// 月度统计由当月的每日统计汇总
+		var buckets []rollupBucket
+		if err := tx.Model(&models.DailyRollup{}).
+			Select("type, grade, SUM(sessions) AS sessions, SUM(duration) AS duration, SUM(calories) AS calories, "+
+				"SUM(sends) AS sends, MAX(last_activity) AS last_activity").
+			Where("user_id = ? AND day BETWEEN ? AND ?", userID, month+"-01", month+"-31").
+			Group("type, grade").
+			Scan(&buckets).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ? AND month = ?", userID, month).Delete(&models.MonthlyRollup{}).Error; err != nil {
+			return err
+		}
+		if len(buckets) == 0 {
+			return nil
+		}
+		monthly := make([]models.MonthlyRollup, len(buckets))
+		for i, b := range buckets {
+			monthly[i] = models.MonthlyRollup{UserID: userID, Month: month, Type: b.Type, Grade: b.Grade, RollupTotals: b.RollupTotals}
+		}
+		return tx.Create(&monthly).Error
+	})
+}
+
+// lockUser 在事务中锁定用户行 (包括已注销的用户)，用于串行化同一用户的预聚合刷新和实时攀岩的开始
+func lockUser(tx *gorm.DB, userID uint) error {
+	var ids []uint
+	return tx.Unscoped().Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", userID).
+		Pluck("id", &ids).Error
+}
+
+// RebuildUser 按用户当前时区从全部攀岩记录重建每日和每月预聚合统计
+func (s *RollupService) RebuildUser(userID uint) error {
+	// 用户已删除时按默认时区重建，保证统计与记录一致
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		var records []models.ClimbingRecord
+		if err := tx.Select("start_time", "type", "grade", "duration", "calories", "success").
+			Where("user_id = ?", userID).
+			Find(&records).Error; err != nil {
+			return err
+		}
+
+		var daily []models.DailyRollup
+		for _, b := range bucketRecords(records, settings.Location, "2006-01-02") {
+			daily = append(daily, models.DailyRollup{UserID: userID, Day: b.Period, Type: b.Type, Grade: b.Grade, RollupTotals: b.RollupTotals})
+		}
+		var monthly []models.MonthlyRollup
+		for _, b := range bucketRecords(records, settings.Location, "2006-01") {
+			monthly = append(monthly, models.MonthlyRollup{UserID: userID, Month: b.Period, Type: b.Type, Grade: b.Grade, RollupTotals: b.RollupTotals})
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.DailyRollup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MonthlyRollup{}).Error; err != nil {
+			return err
+		}
+		if len(daily) > 0 {
+			if err := tx.CreateInBatches(&daily, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(monthly) > 0 {
+			if err := tx.CreateInBatches(&monthly, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	events.Publish(events.Event{Topic: events.RollupsUpdated, UserID: userID})
+	return nil
+}
+
+// RebuildAll 重建所有有攀岩记录或预聚合数据的用户，返回重建的用户数
+//
+// 单个用户失败时记录日志并继续，最后返回所有失败用户的错误。
+func (s *RollupService) RebuildAll() (int, error) {
+	var recordUsers, rollupUsers []uint
+	if err := s.db.Model(&models.ClimbingRecord{}).Distinct("user_id").Pluck("user_id", &recordUsers).Error; err != nil {
+		return 0, err
+	}
+	if err := s.db.Model(&models.DailyRollup{}).Distinct("user_id").Pluck("user_id", &rollupUsers).Error; err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	seen := make(map[uint]bool)
+	var errs []error
+	for _, userID := range append(recordUsers, rollupUsers...) {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if err := s.rebuildLogged(userID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rebuilt++
+	}
+	return rebuilt, errors.Join(errs...)
+}
+
+// Backfill 为有攀岩记录但还没有预聚合数据的用户重建统计，用于上线后补齐历史数据
+//
+// 单个用户失败时记录日志并继续，最后返回所有失败用户的错误。
+func (s *RollupService) Backfill() error {
+	var userIDs []uint
+	if err := s.db.Model(&models.ClimbingRecord{}).
+		Distinct("user_id").
+		Where("user_id NOT IN (?)", s.db.Model(&models.MonthlyRollup{}).Distinct("user_id")).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, userID := range userIDs {
+		if err := s.rebuildLogged(userID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rebuildLogged 重建用户的统计，失败时记录日志并返回带用户ID的错误
+func (s *RollupService) rebuildLogged(userID uint) error {
+	if err := s.RebuildUser(userID); err != nil {
+		err = fmt.Errorf("rebuild rollups for user %d: %w", userID, err)
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
+// loadRollupRange 读取用户 [fromDay, toDay] 范围内的预聚合数据
+//
+// 范围内的完整月份使用月度统计，首尾不完整的月份使用每日统计。
+func loadRollupRange(db *gorm.DB, userID uint, fromDay, toDay string) ([]rollupBucket, error) {
+	first, err := time.Parse("2006-01-02", fromDay)
+	if err != nil {
+		return nil, err
+	}
+	last, err := time.Parse("2006-01-02", toDay)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第一个和最后一个完整月份的首日
+	firstFull := utils.StartOfMonth(first, time.UTC)
+	if !firstFull.Equal(first) {
+		firstFull = firstFull.AddDate(0, 1, 0)
+	}
+	lastFull := utils.StartOfMonth(last, time.UTC)
+	if last.AddDate(0, 0, 1).Day() != 1 {
+		lastFull = lastFull.AddDate(0, -1, 0)
+	}
+	if lastFull.Before(firstFull) {
+		return loadDailyRollups(db.Where("day BETWEEN ? AND ?", fromDay, toDay), userID)
+	}
+
+	buckets, err := loadDailyRollups(db.Where("(day >= ? AND day < ?) OR (day >= ? AND day <= ?)",
+		fromDay, firstFull.Format("2006-01-02"),
+		lastFull.AddDate(0, 1, 0).Format("2006-01-02"), toDay), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var monthly []models.MonthlyRollup
+	if err := db.Where("user_id = ? AND month BETWEEN ? AND ?", userID, firstFull.Format("2006-01"), lastFull.Format("2006-01")).
+		Order("month ASC").
+		Find(&monthly).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range monthly {
+		buckets = append(buckets, rollupBucket{Period: r.Month, Type: r.Type, Grade: r.Grade, RollupTotals: r.RollupTotals})
+	}
+	return buckets, nil
+}
+
+// loadDailyRollups 读取用户满足 scope 条件的每日预聚合数据
+func loadDailyRollups(scope *gorm.DB, userID uint) ([]rollupBucket, error) {
+	var rollups []models.DailyRollup
+	if err := scope.Where("user_id = ?", userID).Order("day ASC").Find(&rollups).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]rollupBucket, len(rollups))
+	for i, r := range rollups {
+		buckets[i] = rollupBucket{Period: r.Day, Type: r.Type, Grade: r.Grade, RollupTotals: r.RollupTotals}
+	}
+	return buckets, nil
+}
+
+// bucketRecords 按 layout 格式化的日期 (天或月)、类型和难度分桶聚合记录，保持周期顺序
+func bucketRecords(records []models.ClimbingRecord, loc *time.Location, layout string) []rollupBucket {
+	type bucketKey struct {
+		period string
+		typ    models.ClimbingType
+		grade  string
+	}
+
+	index := make(map[bucketKey]int)
+	var buckets []rollupBucket
+	for _, r := range records {
+		key := bucketKey{r.StartTime.In(loc).Format(layout), r.Type, gradeBucket(r.Type, r.Grade)}
+		i, ok := index[key]
+		if !ok {
+			i = len(buckets)
+			index[key] = i
+			buckets = append(buckets, rollupBucket{Period: key.period, Type: key.typ, Grade: key.grade})
+		}
+		b := &buckets[i]
+		b.Sessions++
+		b.Duration += r.Duration
+		b.Calories += r.Calories
+		if r.Success {
+			b.Sends++
+		}
+		if r.StartTime.After(b.LastActivity) {
+			b.LastActivity = r.StartTime
+		}
+	}
+	return buckets
+}
+
+// gradeBucket 难度分桶: 可识别的难度使用规范写法 (如 v4 → V4)，否则转为小写并去掉重音符号
+//
+// 统计表的 grade 列使用不区分大小写和重音的排序规则，"Blue" 和 "blue" 必须落在同一个桶中，否则插入时违反唯一索引。
+func gradeBucket(climbingType models.ClimbingType, grade string) string {
+	if info, ok := parseGrade(climbingType, grade); ok {
+		return info.Label
+	}
+	// transform.Chain 有内部状态，不能在 goroutine 间共享
+	fold := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(fold, strings.ToLower(strings.TrimSpace(grade)))
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(grade))
+	}
+	return folded
+}
+
+// rollupAligned 判断 [from, to] 是否恰好覆盖 loc 时区内的整天，可以直接使用预聚合数据
+func rollupAligned(from, to time.Time, loc *time.Location) bool {
+	end := to.Add(time.Microsecond)
+	return from.Equal(utils.StartOfDay(from, loc)) && end.Equal(utils.StartOfDay(end, loc))
+}
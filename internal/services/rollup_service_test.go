@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"movePoint/internal/models"
+)
+
+func TestGradeBucket(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   models.ClimbingType
+		grade string
+		want  string
+	}{
+		{"parsed grade uses label", models.Bouldering, " v4 ", "V4"},
+		{"empty", models.Bouldering, "", ""},
+		{"unparsed grade is lower-cased", models.Bouldering, "Blue", "blue"},
+		{"case variants share a bucket", models.Bouldering, " BLUE", "blue"},
+		{"accents are removed", models.Bouldering, "Bleu Foncé", "bleu fonce"},
+		{"decomposed accents", models.SportClimbing, "Rose\u0301", "rose"},
+		{"non latin kept", models.Bouldering, "黄色", "黄色"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradeBucket(tt.typ, tt.grade); got != tt.want {
+				t.Errorf("gradeBucket(%q, %q) = %q, want %q", tt.typ, tt.grade, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// ErrShareLinkNotFound 分享链接不存在或已过期
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+type ShareService struct {
+	db *gorm.DB
+}
+
+func NewShareService(db *gorm.DB) *ShareService {
+	return &ShareService{db: db}
+}
+
+// CreateShareLink 创建分享链接，相同内容已分享过时返回原链接并延长有效期
+func (s *ShareService) CreateShareLink(userID uint, resource, query string, ttl time.Duration) (*models.ShareLink, error) {
+	link := &models.ShareLink{
+		Code:      utils.ShareCode(userID, resource, query),
+		UserID:    userID,
+		Resource:  resource,
+		Query:     query,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at", "updated_at"}),
+	}).Create(link).Error
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// GetShareLink 按分享码获取未过期的分享链接
+func (s *ShareService) GetShareLink(code string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := s.db.Where("code = ? AND expires_at > ?", code, time.Now()).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
@@ -0,0 +1,466 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// ErrInvalidStrength 力量训练或测试数据无效
+var ErrInvalidStrength = errors.New("invalid strength data")
+
+// campus 板横档上限
+const maxCampusRung = 12
+
+type StrengthService struct {
+	db *gorm.DB
+}
+
+func NewStrengthService(db *gorm.DB) *StrengthService {
+	return &StrengthService{db: db}
+}
+
+// BenchmarkFilter 力量测试趋势的筛选条件，不同边缘深度和握法的成绩不可直接比较
+type BenchmarkFilter struct {
+	EdgeSize float64
+	Grip     models.GripType
+	Hand     models.Hand
+}
+
+// BenchmarkPoint 趋势中的一次测试
+type BenchmarkPoint struct {
+	ID          uint            `json:"id"`
+	TestedAt    time.Time       `json:"tested_at"`
+	Score       float64         `json:"score"`
+	AddedWeight float64         `json:"added_weight"`
+	BodyWeight  float64         `json:"body_weight"`
+	EdgeSize    float64         `json:"edge_size,omitempty"`
+	Grip        models.GripType `json:"grip,omitempty"`
+	Hand        models.Hand     `json:"hand,omitempty"`
+}
+
+// BenchmarkTrend 力量测试成绩随时间的变化
+type BenchmarkTrend struct {
+	Kind   models.BenchmarkKind `json:"kind"`
+	Unit   string               `json:"unit"` // percent_bodyweight 或 rung
+	Points []BenchmarkPoint     `json:"points"`
+	Best   *BenchmarkPoint      `json:"best"`
+	Latest *BenchmarkPoint      `json:"latest"`
+	Change *MetricDelta         `json:"change"` // 最近一次相对第一次的变化，少于两次测试时为空
+}
+
+// StrengthVolume 力量训练量统计
+type StrengthVolume struct {
+	From             time.Time        `json:"from"`
+	To               time.Time        `json:"to"`
+	Sessions         int              `json:"sessions"`
+	Duration         int              `json:"duration"`           // 分钟
+	TimeUnderTension int              `json:"time_under_tension"` // 秒
+	Weeks            []StrengthWeek   `json:"weeks"`
+	Protocols        []ProtocolVolume `json:"protocols"`
+}
+
+// StrengthWeek 单周的力量训练量
+type StrengthWeek struct {
+	Start            time.Time `json:"start"`
+	Sessions         int       `json:"sessions"`
+	Duration         int       `json:"duration"`
+	TimeUnderTension int       `json:"time_under_tension"`
+	MaxLoad          float64   `json:"max_load"` // 最大悬挂负荷占体重的百分比
+}
+
+// ProtocolVolume 按训练方式的训练量
+type ProtocolVolume struct {
+	Protocol         models.HangProtocol `json:"protocol"`
+	Sessions         int                 `json:"sessions"`
+	TimeUnderTension int                 `json:"time_under_tension"`
+}
+
+// CreateSession 创建力量训练记录
+func (s *StrengthService) CreateSession(userID uint, session *models.StrengthSession) error {
+	session.ID = 0
+	session.UserID = userID
+	if err := s.normalizeSession(session); err != nil {
+		return err
+	}
+	return s.db.Create(session).Error
+}
+
+// GetSessions 分页获取力量训练记录
+func (s *StrengthService) GetSessions(userID uint, page, limit int, from, to time.Time) ([]models.StrengthSession, int64, error) {
+	var sessions []models.StrengthSession
+	var total int64
+
+	query := s.db.Where("user_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("start_time >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_time <= ?", to)
+	}
+	if err := query.Model(&models.StrengthSession{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("start_time DESC").Offset(offset).Limit(limit).Find(&sessions).Error
+	return sessions, total, err
+}
+
+// GetSession 获取单条力量训练记录
+func (s *StrengthService) GetSession(userID, sessionID uint) (*models.StrengthSession, error) {
+	var session models.StrengthSession
+	err := s.db.Where("user_id = ? AND id = ?", userID, sessionID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// UpdateSession 修改力量训练记录
+func (s *StrengthService) UpdateSession(userID uint, session *models.StrengthSession) error {
+	existing, err := s.GetSession(userID, session.ID)
+	if err != nil {
+		return err
+	}
+	session.UserID = userID
+	session.CreatedAt = existing.CreatedAt
+	if err := s.normalizeSession(session); err != nil {
+		return err
+	}
+	return s.db.Save(session).Error
+}
+
+// DeleteSession 删除力量训练记录
+func (s *StrengthService) DeleteSession(userID, sessionID uint) error {
+	result := s.db.Where("user_id = ? AND id = ?", userID, sessionID).Delete(&models.StrengthSession{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateBenchmark 记录力量测试成绩
+func (s *StrengthService) CreateBenchmark(userID uint, benchmark *models.StrengthBenchmark) error {
+	benchmark.ID = 0
+	benchmark.UserID = userID
+	if err := s.normalizeBenchmark(benchmark); err != nil {
+		return err
+	}
+	return s.db.Create(benchmark).Error
+}
+
+// GetBenchmarks 获取力量测试成绩，kind 为空时获取全部项目
+func (s *StrengthService) GetBenchmarks(userID uint, kind models.BenchmarkKind) ([]models.StrengthBenchmark, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var benchmarks []models.StrengthBenchmark
+	err := query.Order("tested_at DESC").Find(&benchmarks).Error
+	return benchmarks, err
+}
+
+// DeleteBenchmark 删除力量测试成绩
+func (s *StrengthService) DeleteBenchmark(userID, benchmarkID uint) error {
+	result := s.db.Where("user_id = ? AND id = ?", userID, benchmarkID).Delete(&models.StrengthBenchmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetBenchmarkTrend 获取力量测试成绩趋势，时间按 loc 时区返回
+func (s *StrengthService) GetBenchmarkTrend(userID uint, kind models.BenchmarkKind, from, to time.Time, filter BenchmarkFilter, loc *time.Location) (*BenchmarkTrend, error) {
+	if !validBenchmarkKind(kind) {
+		return nil, fmt.Errorf("%w: 无效的测试项目 %s", ErrInvalidStrength, kind)
+	}
+
+	query := s.db.Where("user_id = ? AND kind = ? AND tested_at BETWEEN ? AND ?", userID, kind, from, to)
+	if filter.EdgeSize > 0 {
+		query = query.Where("edge_size = ?", filter.EdgeSize)
+	}
+	if filter.Grip != "" {
+		query = query.Where("grip = ?", filter.Grip)
+	}
+	if filter.Hand != "" {
+		query = query.Where("hand = ?", filter.Hand)
+	}
+	var benchmarks []models.StrengthBenchmark
+	if err := query.Order("tested_at ASC").Find(&benchmarks).Error; err != nil {
+		return nil, err
+	}
+
+	trend := &BenchmarkTrend{Kind: kind, Unit: "percent_bodyweight", Points: []BenchmarkPoint{}}
+	if kind == models.BenchmarkCampusLadder {
+		trend.Unit = "rung"
+	}
+	for _, b := range benchmarks {
+		trend.Points = append(trend.Points, BenchmarkPoint{
+			ID:          b.ID,
+			TestedAt:    b.TestedAt.In(loc),
+			Score:       b.Score,
+			AddedWeight: b.AddedWeight,
+			BodyWeight:  b.BodyWeight,
+			EdgeSize:    b.EdgeSize,
+			Grip:        b.Grip,
+			Hand:        b.Hand,
+		})
+	}
+	if len(trend.Points) == 0 {
+		return trend, nil
+	}
+
+	for i := range trend.Points {
+		if trend.Best == nil || trend.Points[i].Score > trend.Best.Score {
+			trend.Best = &trend.Points[i]
+		}
+	}
+	trend.Latest = &trend.Points[len(trend.Points)-1]
+	if len(trend.Points) > 1 {
+		change := newMetricDelta(trend.Latest.Score, trend.Points[0].Score)
+		trend.Change = &change
+	}
+	return trend, nil
+}
+
+// GetVolume 统计 [from, to] 内的力量训练量，按用户的每周起始日分周
+func (s *StrengthService) GetVolume(userID uint, from, to time.Time, settings utils.TimeSettings) (*StrengthVolume, error) {
+	var sessions []models.StrengthSession
+	err := s.db.Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from, to).
+		Order("start_time ASC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	loc := settings.Location
+	volume := &StrengthVolume{From: from.In(loc), To: to.In(loc), Weeks: []StrengthWeek{}, Protocols: []ProtocolVolume{}}
+	for week := utils.StartOfWeek(from, loc, settings.WeekStart); !week.After(to); week = week.AddDate(0, 0, 7) {
+		volume.Weeks = append(volume.Weeks, StrengthWeek{Start: week})
+	}
+
+	protocols := make(map[models.HangProtocol]*ProtocolVolume)
+	var order []models.HangProtocol
+	for i := range sessions {
+		session := &sessions[i]
+		tut := session.TimeUnderTension()
+		volume.Sessions++
+		volume.Duration += session.Duration
+		volume.TimeUnderTension += tut
+
+		start := utils.StartOfWeek(session.StartTime, loc, settings.WeekStart)
+		for j := range volume.Weeks {
+			if !volume.Weeks[j].Start.Equal(start) {
+				continue
+			}
+			w := &volume.Weeks[j]
+			w.Sessions++
+			w.Duration += session.Duration
+			w.TimeUnderTension += tut
+			if load := round1(session.LoadPercent()); load > w.MaxLoad {
+				w.MaxLoad = load
+			}
+		}
+
+		p, ok := protocols[session.Protocol]
+		if !ok {
+			p = &ProtocolVolume{Protocol: session.Protocol}
+			protocols[session.Protocol] = p
+			order = append(order, session.Protocol)
+		}
+		p.Sessions++
+		p.TimeUnderTension += tut
+	}
+	for _, protocol := range order {
+		volume.Protocols = append(volume.Protocols, *protocols[protocol])
+	}
+	return volume, nil
+}
+
+// normalizeSession 校验力量训练记录，补全体重和默认的组数、次数
+func (s *StrengthService) normalizeSession(session *models.StrengthSession) error {
+	switch session.Protocol {
+	case models.ProtocolMaxHang, models.ProtocolRepeaters, models.ProtocolOneArm, models.ProtocolCustom:
+	default:
+		return fmt.Errorf("%w: 无效的训练方式 %s", ErrInvalidStrength, session.Protocol)
+	}
+	if err := validateGrip(session.Grip, session.Hand); err != nil {
+		return err
+	}
+	if session.Protocol == models.ProtocolOneArm && session.Hand == "" {
+		return fmt.Errorf("%w: 单臂悬挂需要指定左手或右手", ErrInvalidStrength)
+	}
+	if session.StartTime.IsZero() {
+		return fmt.Errorf("%w: 缺少训练时间", ErrInvalidStrength)
+	}
+	if session.EdgeSize < 0 || session.HangTime < 0 || session.RestTime < 0 || session.SetRest < 0 ||
+		session.Reps < 0 || session.Sets < 0 || session.Duration < 0 || session.BodyWeight < 0 || session.Calories < 0 {
+		return fmt.Errorf("%w: 训练数据不能为负数", ErrInvalidStrength)
+	}
+	if session.RPE < 0 || session.RPE > 10 {
+		return fmt.Errorf("%w: 疲劳度须为 0-10", ErrInvalidStrength)
+	}
+	if session.Reps == 0 {
+		session.Reps = 1
+	}
+	if session.Sets == 0 {
+		session.Sets = 1
+	}
+	// 未填写时长时按悬挂和休息时间估算
+	if session.Duration == 0 {
+		seconds := session.Sets*(session.Reps*session.HangTime+(session.Reps-1)*session.RestTime) + (session.Sets-1)*session.SetRest
+		session.Duration = (seconds + 59) / 60
+	}
+	if session.Calories <= 0 {
+		session.Calories = estimateCalories(models.ActivityStrength, "", session.Duration)
+	}
+
+	if session.BodyWeight == 0 {
+		weight, err := s.profileWeight(session.UserID)
+		if err != nil {
+			return err
+		}
+		session.BodyWeight = weight
+	}
+	if session.BodyWeight > 0 && session.BodyWeight+session.AddedWeight <= 0 {
+		return fmt.Errorf("%w: 减重不能超过体重", ErrInvalidStrength)
+	}
+	return nil
+}
+
+// normalizeBenchmark 校验测试数据并计算成绩
+//
+// 最大悬挂: (体重 + 负重) / 体重；引体向上: 按 Epley 公式由负重和次数估算单次极限 (只做一次时即为实际负荷)，再除以体重；
+// campus 板阶梯: 横档序列中的最高横档。
+func (s *StrengthService) normalizeBenchmark(b *models.StrengthBenchmark) error {
+	if !validBenchmarkKind(b.Kind) {
+		return fmt.Errorf("%w: 无效的测试项目 %s", ErrInvalidStrength, b.Kind)
+	}
+	if err := validateGrip(b.Grip, b.Hand); err != nil {
+		return err
+	}
+	if b.TestedAt.IsZero() {
+		return fmt.Errorf("%w: 缺少测试时间", ErrInvalidStrength)
+	}
+	if b.BodyWeight < 0 || b.EdgeSize < 0 || b.HangTime < 0 || b.Reps < 0 {
+		return fmt.Errorf("%w: 测试数据不能为负数", ErrInvalidStrength)
+	}
+
+	if b.Kind == models.BenchmarkCampusLadder {
+		rungs, err := parseLadder(b.Ladder)
+		if err != nil {
+			return err
+		}
+		b.Ladder = strings.Join(rungs, "-")
+		top := 0
+		for _, r := range rungs {
+			n, _ := strconv.Atoi(r)
+			if n > top {
+				top = n
+			}
+		}
+		b.Score = float64(top)
+		b.BodyWeight, b.AddedWeight, b.EdgeSize, b.HangTime, b.Reps = 0, 0, 0, 0, 0
+		return nil
+	}
+
+	b.Ladder = ""
+	if b.BodyWeight == 0 {
+		weight, err := s.profileWeight(b.UserID)
+		if err != nil {
+			return err
+		}
+		if weight == 0 {
+			return fmt.Errorf("%w: 请填写体重或在个人资料中设置体重", ErrInvalidStrength)
+		}
+		b.BodyWeight = weight
+	}
+	total := b.BodyWeight + b.AddedWeight
+	if total <= 0 {
+		return fmt.Errorf("%w: 减重不能超过体重", ErrInvalidStrength)
+	}
+
+	switch b.Kind {
+	case models.BenchmarkMaxHang:
+		if b.EdgeSize == 0 {
+			return fmt.Errorf("%w: 最大悬挂需要填写边缘深度", ErrInvalidStrength)
+		}
+		b.Reps = 0
+	case models.BenchmarkPullUpMax:
+		if b.Reps == 0 {
+			b.Reps = 1
+		}
+		total *= 1 + float64(b.Reps-1)/30
+		b.EdgeSize, b.HangTime = 0, 0
+	}
+	b.Score = round1(total / b.BodyWeight * 100)
+	return nil
+}
+
+// profileWeight 个人资料中的体重，未设置时为 0
+func (s *StrengthService) profileWeight(userID uint) (float64, error) {
+	var user models.User
+	if err := s.db.Select("weight").Where("id = ?", userID).First(&user).Error; err != nil {
+		return 0, err
+	}
+	return user.Weight, nil
+}
+
+// parseLadder 解析 campus 板阶梯序列，如 "1-4-7" 或 "1,3,5"，横档须逐步上升
+func parseLadder(ladder string) ([]string, error) {
+	fields := strings.FieldsFunc(ladder, func(r rune) bool {
+		return r == '-' || r == ',' || r == ' '
+	})
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%w: 阶梯序列至少包含两个横档，如 1-4-7", ErrInvalidStrength)
+	}
+	rungs := make([]string, len(fields))
+	prev := 0
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 1 || n > maxCampusRung || n <= prev {
+			return nil, fmt.Errorf("%w: 无效的阶梯序列 %s", ErrInvalidStrength, ladder)
+		}
+		rungs[i] = strconv.Itoa(n)
+		prev = n
+	}
+	return rungs, nil
+}
+
+func validateGrip(grip models.GripType, hand models.Hand) error {
+	switch grip {
+	case "", models.GripOpenHand, models.GripHalfCrimp, models.GripFullCrimp, models.GripPinch, models.GripSloper:
+	default:
+		return fmt.Errorf("%w: 无效的握法 %s", ErrInvalidStrength, grip)
+	}
+	switch hand {
+	case "", models.HandLeft, models.HandRight:
+	default:
+		return fmt.Errorf("%w: 无效的手 %s", ErrInvalidStrength, hand)
+	}
+	return nil
+}
+
+// validBenchmarkKind 是否为支持的测试项目
+func validBenchmarkKind(kind models.BenchmarkKind) bool {
+	switch kind {
+	case models.BenchmarkMaxHang, models.BenchmarkPullUpMax, models.BenchmarkCampusLadder:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,640 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+)
+
+// SyncEntityRecord 同步实体: 攀岩记录
+const SyncEntityRecord = "climbing_record"
+
+// 推送变更的操作
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+)
+
+// 推送变更的处理结果
+const (
+	SyncApplied   = "applied"   // 全部字段已应用
+	SyncMerged    = "merged"    // 与其他设备的并发修改合并，Rejected 中的字段保留了更新的值
+	SyncDuplicate = "duplicate" // 已处理过的变更 (客户端重试)
+	SyncRejected  = "rejected"  // 无效的变更，见 Error
+)
+
+const (
+	syncServerDevice = "server"  // 通过服务端接口 (含导入、实时攀岩) 的修改
+	syncDeletedField = "deleted" // 删除状态按普通字段参与最后写入优先
+	defaultSyncLimit = 200
+	maxSyncLimit     = 1000
+	maxSyncBatch     = 500
+)
+
+var ErrInvalidSync = errors.New("invalid sync request")
+
+// SyncItem 拉取到的实体变更，删除的实体只返回墓碑
+type SyncItem struct {
+	Entity   string                 `json:"entity"`
+	ID       uint                   `json:"id"`
+	ClientID string                 `json:"client_id"`
+	Seq      uint64                 `json:"seq"`
+	Deleted  bool                   `json:"deleted"`
+	Version  models.VersionVector   `json:"version"`
+	Data     *models.ClimbingRecord `json:"data,omitempty"`
+}
+
+// SyncChanges 游标之后的变更，HasMore 为 true 时应使用新游标继续拉取
+type SyncChanges struct {
+	Changes []SyncItem `json:"changes"`
+	Cursor  string     `json:"cursor"`
+	HasMore bool       `json:"has_more"`
+}
+
+// SyncPushItem 客户端推送的一条变更
+//
+// Version 为客户端修改后的版本向量 (已包含本设备的计数)。字段的修改时间优先使用 FieldUpdatedAt，
+// 其次为 UpdatedAt，晚于服务端当前时间时按当前时间处理。
+type SyncPushItem struct {
+	Entity         string                     `json:"entity"`
+	ClientID       string                     `json:"client_id"`
+	ID             uint                       `json:"id"`
+	Op             string                     `json:"op"`
+	Version        models.VersionVector       `json:"version"`
+	Fields         map[string]json.RawMessage `json:"fields"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	FieldUpdatedAt map[string]time.Time       `json:"field_updated_at"`
+}
+
+// SyncPushRequest 批量推送的变更，按顺序处理
+type SyncPushRequest struct {
+	DeviceID string         `json:"device_id"`
+	Changes  []SyncPushItem `json:"changes"`
+}
+
+// SyncPushResult 一条变更的处理结果，Item 为处理后服务端的状态
+type SyncPushResult struct {
+	ClientID string    `json:"client_id"`
+	Status   string    `json:"status"`
+	Rejected []string  `json:"rejected,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Item     *SyncItem `json:"item,omitempty"`
+}
+
+type SyncService struct {
+	db       *gorm.DB
+	climbing *ClimbingService
+	locks    sync.Map // userID -> *sync.Mutex，同一用户的同步元数据串行更新
+}
+
+func NewSyncService(db *gorm.DB, climbing *ClimbingService) *SyncService {
+	return &SyncService{db: db, climbing: climbing}
+}
+
+// Subscribe 订阅攀岩记录变更事件，为服务端接口产生的修改记录同步元数据
+func (s *SyncService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+}
+
+// HandleRecordEvent 更新记录的版本向量和字段时钟，离线同步推送的变更已在推送时处理
+func (s *SyncService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok || change.Device != "" {
+		return nil
+	}
+	record := change.After
+	if record == nil {
+		record = change.Before
+	}
+	if record == nil {
+		return nil
+	}
+
+	unlock := s.lock(e.UserID)
+	defer unlock()
+
+	state, err := s.findState(e.UserID, SyncEntityRecord, record.ID)
+	if err != nil {
+		return err
+	}
+	clock := models.FieldClock{Time: e.OccurredAt.UTC(), Device: syncServerDevice}
+
+	if state == nil {
+		if change.After == nil {
+			return nil // 从未同步过的记录无需墓碑
+		}
+		if state, err = newSyncState(e.UserID, record.ID, clock); err != nil {
+			return err
+		}
+	} else {
+		var changed []string
+		if change.Before != nil && change.After != nil {
+			if changed, err = diffRecordFields(change.Before, change.After); err != nil {
+				return err
+			}
+		} else {
+			// 撤销删除
+			changed = append([]string{syncDeletedField}, editableRecordFields...)
+		}
+		if change.After == nil {
+			changed = []string{syncDeletedField}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		for _, field := range changed {
+			state.FieldClocks[field] = clock
+		}
+		state.Deleted = change.After == nil
+		state.Version = state.Version.Merge(models.VersionVector{syncServerDevice: state.Version[syncServerDevice] + 1})
+	}
+
+	return s.saveState(state)
+}
+
+// Backfill 为启用同步前创建的记录生成同步元数据
+func (s *SyncService) Backfill() error {
+	var records []models.ClimbingRecord
+	synced := s.db.Model(&models.SyncState{}).Select("entity_id").Where("entity = ?", SyncEntityRecord)
+	return s.db.Where("id NOT IN (?)", synced).FindInBatches(&records, 500, func(tx *gorm.DB, batch int) error {
+		for _, record := range records {
+			if err := s.backfillRecord(record); err != nil {
+				return fmt.Errorf("backfill sync state for record %d: %w", record.ID, err)
+			}
+		}
+		return nil
+	}).Error
+}
+
+func (s *SyncService) backfillRecord(record models.ClimbingRecord) error {
+	unlock := s.lock(record.UserID)
+	defer unlock()
+
+	state, err := s.findState(record.UserID, SyncEntityRecord, record.ID)
+	if err != nil || state != nil {
+		return err
+	}
+	state, err = newSyncState(record.UserID, record.ID, models.FieldClock{Time: record.UpdatedAt.UTC(), Device: syncServerDevice})
+	if err != nil {
+		return err
+	}
+	return s.saveState(state)
+}
+
+// GetChanges 获取游标之后的变更，游标为空时从头拉取；同一实体多次修改只返回最新状态
+func (s *SyncService) GetChanges(userID uint, cursor string, limit int, loc *time.Location) (*SyncChanges, error) {
+	var since uint64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: 无效的同步游标", ErrInvalidSync)
+		}
+	}
+	if limit <= 0 {
+		limit = defaultSyncLimit
+	}
+	if limit > maxSyncLimit {
+		limit = maxSyncLimit
+	}
+
+	var states []models.SyncState
+	err := s.db.Where("user_id = ? AND seq > ?", userID, since).
+		Order("seq").
+		Limit(limit + 1).
+		Find(&states).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncChanges{Changes: []SyncItem{}, Cursor: strconv.FormatUint(since, 10)}
+	if len(states) > limit {
+		states = states[:limit]
+		result.HasMore = true
+	}
+	if len(states) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, len(states))
+	for i, state := range states {
+		ids[i] = state.EntityID
+	}
+	var records []models.ClimbingRecord
+	if err := s.db.Unscoped().Where("user_id = ? AND id IN ?", userID, ids).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.ClimbingRecord, len(records))
+	for i := range records {
+		byID[records[i].ID] = &records[i]
+	}
+
+	for i := range states {
+		result.Changes = append(result.Changes, syncItem(&states[i], byID[states[i].EntityID], loc))
+	}
+	result.Cursor = strconv.FormatUint(states[len(states)-1].Seq, 10)
+	return result, nil
+}
+
+// Push 按顺序处理客户端推送的变更
+//
+// 单条变更无效时只拒绝该条；数据库错误时中止，已处理的变更保留，客户端重试时会识别为重复。
+func (s *SyncService) Push(userID uint, req SyncPushRequest, loc *time.Location) ([]SyncPushResult, error) {
+	if req.DeviceID == "" || req.DeviceID == syncServerDevice || len(req.DeviceID) > 64 {
+		return nil, fmt.Errorf("%w: 无效的设备ID", ErrInvalidSync)
+	}
+	if len(req.Changes) > maxSyncBatch {
+		return nil, fmt.Errorf("%w: 每次最多推送 %d 条变更", ErrInvalidSync, maxSyncBatch)
+	}
+
+	unlock := s.lock(userID)
+	defer unlock()
+
+	now := time.Now().UTC()
+	results := make([]SyncPushResult, 0, len(req.Changes))
+	for _, item := range req.Changes {
+		result, err := s.pushItem(userID, req.DeviceID, item, now, loc)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidSync) && !errors.Is(err, ErrInvalidRecord) &&
+				!errors.Is(err, ErrRecordNotFound) && !errors.Is(err, ErrVersionConflict) {
+				return nil, err
+			}
+			message := err.Error()
+			switch {
+			case errors.Is(err, ErrRecordNotFound):
+				message = "记录不存在"
+			case errors.Is(err, ErrVersionConflict):
+				message = "记录同时被其他请求修改，请重试"
+			}
+			result = SyncPushResult{ClientID: item.ClientID, Status: SyncRejected, Error: message}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *SyncService) pushItem(userID uint, device string, item SyncPushItem, now time.Time, loc *time.Location) (SyncPushResult, error) {
+	if item.Entity != SyncEntityRecord {
+		return SyncPushResult{}, fmt.Errorf("%w: 不支持的同步实体", ErrInvalidSync)
+	}
+	if item.Version[device] == 0 {
+		return SyncPushResult{}, fmt.Errorf("%w: 版本向量必须包含本设备的修改计数", ErrInvalidSync)
+	}
+	for field := range item.Fields {
+		if !validSyncField(field) {
+			return SyncPushResult{}, fmt.Errorf("%w: 不支持的字段 %s", ErrInvalidSync, field)
+		}
+	}
+
+	var state models.SyncState
+	query := s.db.Where("user_id = ? AND entity = ?", userID, item.Entity)
+	switch {
+	case item.ClientID != "":
+		query = query.Where("client_id = ?", item.ClientID)
+	case item.ID != 0:
+		query = query.Where("entity_id = ?", item.ID)
+	default:
+		return SyncPushResult{}, fmt.Errorf("%w: 缺少 client_id", ErrInvalidSync)
+	}
+	err := query.First(&state).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return SyncPushResult{}, err
+	}
+
+	fields := item.Fields
+	switch item.Op {
+	case SyncOpCreate:
+		if !found {
+			return s.pushCreate(userID, device, item, now, loc)
+		}
+		// 重试的创建按修改处理
+	case SyncOpUpdate:
+	case SyncOpDelete:
+		fields = map[string]json.RawMessage{syncDeletedField: json.RawMessage("true")}
+	default:
+		return SyncPushResult{}, fmt.Errorf("%w: 无效的操作 %s", ErrInvalidSync, item.Op)
+	}
+	if !found {
+		return SyncPushResult{}, ErrRecordNotFound
+	}
+	return s.pushUpdate(userID, device, &state, item, fields, now, loc)
+}
+
+// pushCreate 创建客户端离线生成的记录
+func (s *SyncService) pushCreate(userID uint, device string, item SyncPushItem, now time.Time, loc *time.Location) (SyncPushResult, error) {
+	if item.ClientID == "" || len(item.ClientID) > 64 {
+		return SyncPushResult{}, fmt.Errorf("%w: 创建记录需要 client_id", ErrInvalidSync)
+	}
+	if _, ok := item.Fields[syncDeletedField]; ok {
+		return SyncPushResult{}, fmt.Errorf("%w: 不支持的字段 %s", ErrInvalidSync, syncDeletedField)
+	}
+
+	var record models.ClimbingRecord
+	if err := decodeSyncFields(item.Fields, &record); err != nil {
+		return SyncPushResult{}, err
+	}
+	if err := validateRecord(&record); err != nil {
+		return SyncPushResult{}, err
+	}
+	record.Source = models.SourceManual
+	if err := s.climbing.createRecordFrom(userID, device, &record); err != nil {
+		return SyncPushResult{}, err
+	}
+
+	state := &models.SyncState{
+		UserID:      userID,
+		Entity:      SyncEntityRecord,
+		EntityID:    record.ID,
+		ClientID:    item.ClientID,
+		Version:     models.VersionVector{}.Merge(item.Version),
+		FieldClocks: make(map[string]models.FieldClock),
+	}
+	for _, field := range editableRecordFields {
+		state.FieldClocks[field] = syncFieldClock(item, field, device, now)
+	}
+	if err := s.saveState(state); err != nil {
+		return SyncPushResult{}, err
+	}
+
+	result := syncItem(state, &record, loc)
+	return SyncPushResult{ClientID: state.ClientID, Status: SyncApplied, Item: &result}, nil
+}
+
+// pushUpdate 合并客户端的修改
+//
+// 客户端版本已包含服务端全部修改时直接应用；存在并发修改时逐字段比较修改时间，较新的一方保留。
+// 删除状态作为 deleted 字段参与比较，记录删除后其他字段的修改仍会保存，恢复后可见。
+func (s *SyncService) pushUpdate(userID uint, device string, state *models.SyncState, item SyncPushItem, fields map[string]json.RawMessage, now time.Time, loc *time.Location) (SyncPushResult, error) {
+	var record models.ClimbingRecord
+	if err := s.db.Unscoped().Where("user_id = ? AND id = ?", userID, state.EntityID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SyncPushResult{}, ErrRecordNotFound
+		}
+		return SyncPushResult{}, err
+	}
+
+	if state.Version.Dominates(item.Version) {
+		current := syncItem(state, &record, loc)
+		return SyncPushResult{ClientID: state.ClientID, Status: SyncDuplicate, Item: &current}, nil
+	}
+	accepted, clocks, rejected := resolveSyncFields(state, item, fields, device, now)
+
+	deleted := state.Deleted
+	if value, ok := accepted[syncDeletedField]; ok {
+		if err := json.Unmarshal(value, &deleted); err != nil {
+			return SyncPushResult{}, fmt.Errorf("%w: deleted 应为布尔值", ErrInvalidSync)
+		}
+		delete(accepted, syncDeletedField)
+	}
+
+	if len(accepted) > 0 {
+		before := record
+		fields := make([]string, 0, len(accepted))
+		for field := range accepted {
+			fields = append(fields, field)
+		}
+		if err := decodeSyncFields(accepted, &record); err != nil {
+			return SyncPushResult{}, err
+		}
+		if err := s.climbing.saveRecordFields(s.db.Unscoped(), userID, events.RecordChange{Device: device}, &before, &record, fields); err != nil {
+			return SyncPushResult{}, err
+		}
+	}
+
+	wasDeleted := record.DeletedAt.Valid
+	switch {
+	case deleted && !wasDeleted:
+		if err := s.db.Delete(&record).Error; err != nil {
+			return SyncPushResult{}, err
+		}
+		publishRecordChangeFrom(events.RecordDeleted, userID, events.RecordChange{Device: device}, &record, nil)
+	case !deleted && wasDeleted:
+		err := s.db.Unscoped().Model(&record).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return SyncPushResult{}, err
+		}
+		record.DeletedAt = gorm.DeletedAt{}
+		record.Version++
+		publishRecordChangeFrom(events.RecordCreated, userID, events.RecordChange{Device: device, Action: events.RecordActionUndelete}, nil, &record)
+	}
+
+	for field, clock := range clocks {
+		state.FieldClocks[field] = clock
+	}
+	state.Deleted = deleted
+	state.Version = state.Version.Merge(item.Version)
+	if err := s.saveState(state); err != nil {
+		return SyncPushResult{}, err
+	}
+
+	status := SyncApplied
+	if len(rejected) > 0 {
+		status = SyncMerged
+	}
+	current := syncItem(state, &record, loc)
+	return SyncPushResult{ClientID: state.ClientID, Status: status, Rejected: rejected, Item: &current}, nil
+}
+
+// saveState 分配新的同步序号并保存元数据
+func (s *SyncService) saveState(state *models.SyncState) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := nextSyncSeq(tx, state.UserID)
+		if err != nil {
+			return err
+		}
+		state.Seq = seq
+		return tx.Save(state).Error
+	})
+}
+
+func (s *SyncService) findState(userID uint, entity string, entityID uint) (*models.SyncState, error) {
+	var state models.SyncState
+	err := s.db.Where("user_id = ? AND entity = ? AND entity_id = ?", userID, entity, entityID).First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if state.FieldClocks == nil {
+		state.FieldClocks = make(map[string]models.FieldClock)
+	}
+	return &state, nil
+}
+
+func (s *SyncService) lock(userID uint) func() {
+	mu, _ := s.locks.LoadOrStore(userID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// nextSyncSeq 递增用户的同步序号，游标行在事务提交前保持锁定，保证序号顺序与提交顺序一致
+func nextSyncSeq(tx *gorm.DB, userID uint) (uint64, error) {
+	cursor := models.SyncCursor{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&cursor).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.SyncCursor{}).Where("user_id = ?", userID).UpdateColumn("seq", gorm.Expr("seq + 1")).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", userID).First(&cursor).Error; err != nil {
+		return 0, err
+	}
+	return cursor.Seq, nil
+}
+
+// newSyncState 服务端创建的记录的同步元数据，全部字段的时钟为 clock
+func newSyncState(userID, recordID uint, clock models.FieldClock) (*models.SyncState, error) {
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, err
+	}
+	state := &models.SyncState{
+		UserID:      userID,
+		Entity:      SyncEntityRecord,
+		EntityID:    recordID,
+		ClientID:    clientID,
+		Version:     models.VersionVector{syncServerDevice: 1},
+		FieldClocks: make(map[string]models.FieldClock),
+	}
+	for _, field := range editableRecordFields {
+		state.FieldClocks[field] = clock
+	}
+	return state, nil
+}
+
+// newClientID 生成随机的 UUID (v4)
+func newClientID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// resolveSyncFields 逐字段的最后写入优先: 字段时钟晚于服务端的修改被接受，其余字段被拒绝
+//
+// 客户端的版本向量包含服务端的全部修改 (快进) 时，客户端已看到服务端的修改，
+// 即使设备时钟较慢也接受本次修改，并沿用服务端的字段时间。
+func resolveSyncFields(state *models.SyncState, item SyncPushItem, fields map[string]json.RawMessage, device string, now time.Time) (map[string]json.RawMessage, map[string]models.FieldClock, []string) {
+	fastForward := item.Version.Dominates(state.Version)
+
+	accepted := make(map[string]json.RawMessage)
+	clocks := make(map[string]models.FieldClock)
+	var rejected []string
+	for field, value := range fields {
+		clock := syncFieldClock(item, field, device, now)
+		existing := state.FieldClocks[field]
+		switch {
+		case clock.After(existing):
+		case fastForward:
+			clock.Time = existing.Time
+		default:
+			rejected = append(rejected, field)
+			continue
+		}
+		accepted[field] = value
+		clocks[field] = clock
+	}
+	sort.Strings(rejected)
+	return accepted, clocks, rejected
+}
+
+// syncFieldClock 客户端修改字段的时钟，未提供时间或时间晚于服务端当前时间时使用当前时间
+func syncFieldClock(item SyncPushItem, field, device string, now time.Time) models.FieldClock {
+	t := item.FieldUpdatedAt[field]
+	if t.IsZero() {
+		t = item.UpdatedAt
+	}
+	if t.IsZero() || t.After(now) {
+		t = now
+	}
+	return models.FieldClock{Time: t.UTC(), Device: device}
+}
+
+func validSyncField(field string) bool {
+	if field == syncDeletedField {
+		return true
+	}
+	return editableRecordField(field)
+}
+
+// decodeSyncFields 将客户端推送的字段写入 record，未推送的字段保持不变
+func decodeSyncFields(fields map[string]json.RawMessage, record *models.ClimbingRecord) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("%w: 无效的字段值", ErrInvalidSync)
+	}
+	if err := json.Unmarshal(data, record); err != nil {
+		return fmt.Errorf("%w: 无效的字段值", ErrInvalidSync)
+	}
+	return nil
+}
+
+// diffRecordFields 比较可同步字段，返回发生变化的字段
+func diffRecordFields(before, after *models.ClimbingRecord) ([]string, error) {
+	var a, b map[string]json.RawMessage
+	if err := remarshal(before, &a); err != nil {
+		return nil, err
+	}
+	if err := remarshal(after, &b); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, field := range editableRecordFields {
+		if !bytes.Equal(a[field], b[field]) {
+			changed = append(changed, field)
+		}
+	}
+	return changed, nil
+}
+
+// remarshal 经 JSON 将 v 转换为 out
+func remarshal(v interface{}, out interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// syncItem 转换为拉取结果，记录已被彻底清除时同样按墓碑返回
+func syncItem(state *models.SyncState, record *models.ClimbingRecord, loc *time.Location) SyncItem {
+	item := SyncItem{
+		Entity:   state.Entity,
+		ID:       state.EntityID,
+		ClientID: state.ClientID,
+		Seq:      state.Seq,
+		Deleted:  state.Deleted || record == nil,
+		Version:  state.Version,
+	}
+	if !item.Deleted {
+		data := *record
+		data.DeletedAt = gorm.DeletedAt{}
+		data.LocalizeTimes(loc)
+		item.Data = &data
+	}
+	return item
+}
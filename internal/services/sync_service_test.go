@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"movePoint/internal/models"
+)
+
+func TestResolveSyncFields(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(-time.Minute)
+
+	state := func(version models.VersionVector) *models.SyncState {
+		return &models.SyncState{
+			Version: version,
+			FieldClocks: map[string]models.FieldClock{
+				"grade": {Time: now.Add(-30 * time.Minute), Device: "phone"},
+				"notes": {Time: now.Add(-30 * time.Minute), Device: "phone"},
+			},
+		}
+	}
+	fields := map[string]json.RawMessage{
+		"grade": json.RawMessage(`"V5"`),
+		"notes": json.RawMessage(`"crimpy"`),
+	}
+
+	tests := []struct {
+		name         string
+		state        *models.SyncState
+		item         SyncPushItem
+		device       string
+		wantAccepted []string
+		wantRejected []string
+		wantTimes    map[string]time.Time
+	}{
+		{
+			name:         "newer edit wins",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"tablet": 1}, UpdatedAt: later},
+			device:       "tablet",
+			wantAccepted: []string{"grade", "notes"},
+			wantTimes:    map[string]time.Time{"grade": later, "notes": later},
+		},
+		{
+			name:         "older concurrent edit loses",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"tablet": 1}, UpdatedAt: earlier},
+			device:       "tablet",
+			wantRejected: []string{"grade", "notes"},
+		},
+		{
+			name:  "per field timestamps",
+			state: state(models.VersionVector{"phone": 1}),
+			item: SyncPushItem{
+				Version:        models.VersionVector{"tablet": 1},
+				UpdatedAt:      earlier,
+				FieldUpdatedAt: map[string]time.Time{"grade": later},
+			},
+			device:       "tablet",
+			wantAccepted: []string{"grade"},
+			wantRejected: []string{"notes"},
+			wantTimes:    map[string]time.Time{"grade": later},
+		},
+		{
+			name:         "fast forward keeps server time",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"phone": 1, "tablet": 1}, UpdatedAt: earlier},
+			device:       "tablet",
+			wantAccepted: []string{"grade", "notes"},
+			wantTimes:    map[string]time.Time{"grade": now.Add(-30 * time.Minute), "notes": now.Add(-30 * time.Minute)},
+		},
+		{
+			name:         "same time breaks tie by device",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"alpha": 1}, UpdatedAt: now.Add(-30 * time.Minute)},
+			device:       "alpha",
+			wantRejected: []string{"grade", "notes"},
+		},
+		{
+			name:         "future clock is capped at now",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"tablet": 1}, UpdatedAt: now.Add(24 * time.Hour)},
+			device:       "tablet",
+			wantAccepted: []string{"grade", "notes"},
+			wantTimes:    map[string]time.Time{"grade": now, "notes": now},
+		},
+		{
+			name:         "missing clock uses now",
+			state:        state(models.VersionVector{"phone": 1}),
+			item:         SyncPushItem{Version: models.VersionVector{"tablet": 1}},
+			device:       "tablet",
+			wantAccepted: []string{"grade", "notes"},
+			wantTimes:    map[string]time.Time{"grade": now, "notes": now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted, clocks, rejected := resolveSyncFields(tt.state, tt.item, fields, tt.device, now)
+
+			var gotAccepted []string
+			for field, value := range accepted {
+				gotAccepted = append(gotAccepted, field)
+				if string(value) != string(fields[field]) {
+					t.Errorf("accepted[%s] = %s, want %s", field, value, fields[field])
+				}
+			}
+			sort.Strings(gotAccepted)
+			if !reflect.DeepEqual(gotAccepted, tt.wantAccepted) {
+				t.Errorf("accepted = %v, want %v", gotAccepted, tt.wantAccepted)
+			}
+			if !reflect.DeepEqual(rejected, tt.wantRejected) {
+				t.Errorf("rejected = %v, want %v", rejected, tt.wantRejected)
+			}
+
+			if len(clocks) != len(tt.wantTimes) {
+				t.Fatalf("clocks = %v, want times %v", clocks, tt.wantTimes)
+			}
+			for field, want := range tt.wantTimes {
+				clock := clocks[field]
+				if !clock.Time.Equal(want) || clock.Device != tt.device {
+					t.Errorf("clocks[%s] = %v, want %v from %s", field, clock, want, tt.device)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+var (
+	ErrInvalidTeam   = errors.New("invalid team")
+	ErrTeamForbidden = errors.New("team permission denied") // 成员角色不足
+)
+
+// teamInviteCodeLength 邀请码长度
+const teamInviteCodeLength = 8
+
+// 团队动态每页条数
+const (
+	defaultTeamFeedLimit = 20
+	maxTeamFeedLimit     = 100
+)
+
+// TeamMember 团队成员及其公开信息
+type TeamMember struct {
+	UserID            uint            `json:"user_id"`
+	Username          string          `json:"username"`
+	AvatarURL         string          `json:"avatar_url"`
+	Role              models.TeamRole `json:"role"`
+	LeaderboardOptOut bool            `json:"leaderboard_opt_out"`
+	FeedOptOut        bool            `json:"feed_opt_out"`
+	JoinedAt          time.Time       `json:"joined_at"`
+}
+
+// TeamFeedItem 团队动态中的一条攀岩记录
+type TeamFeedItem struct {
+	RecordID  uint                `json:"record_id"`
+	UserID    uint                `json:"user_id"`
+	Username  string              `json:"username"`
+	AvatarURL string              `json:"avatar_url"`
+	Type      models.ClimbingType `json:"type"`
+	Grade     string              `json:"grade"`
+	Attempts  string              `json:"attempts"`
+	Success   bool                `json:"success"`
+	Duration  int                 `json:"duration"`
+	Location  string              `json:"location"`
+	StartTime time.Time           `json:"start_time"`
+}
+
+// TeamFeed 团队动态的一页
+type TeamFeed struct {
+	Data  []TeamFeedItem `json:"data"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+}
+
+// TeamStats 团队在时间范围内的汇总统计，按成员的每日预聚合统计计算
+type TeamStats struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Members       int       `json:"members"`
+	ActiveMembers int       `json:"active_members"` // 范围内有攀岩记录的成员
+	TotalSessions int       `json:"total_sessions"`
+	TotalDuration int       `json:"total_duration"` // 分钟
+	TotalCalories float64   `json:"total_calories"`
+	TotalSends    int       `json:"total_sends"`
+
+	SendsByGrade map[models.ClimbingType]map[string]int `json:"sends_by_grade"` // 类型 -> 难度 -> 完攀数
+}
+
+type TeamService struct {
+	db           *gorm.DB
+	leaderboards *LeaderboardService
+}
+
+func NewTeamService(db *gorm.DB, leaderboards *LeaderboardService) *TeamService {
+	return &TeamService{db: db, leaderboards: leaderboards}
+}
+
+// CreateTeam 创建团队，创建者成为团队所有者
+func (s *TeamService) CreateTeam(userID uint, team *models.Team) error {
+	if err := normalizeTeam(team); err != nil {
+		return err
+	}
+	code, err := utils.InviteCode(teamInviteCodeLength)
+	if err != nil {
+		return err
+	}
+	team.ID = 0
+	team.OwnerID = userID
+	team.InviteCode = code
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TeamMembership{TeamID: team.ID, UserID: userID, Role: models.TeamOwner}).Error
+	})
+	if err != nil {
+		return err
+	}
+	team.Role = models.TeamOwner
+	team.MemberCount = 1
+	return nil
+}
+
+// GetTeams 获取用户加入的团队
+func (s *TeamService) GetTeams(userID uint) ([]models.Team, error) {
+	var memberships []models.TeamMembership
+	if err := s.db.Where("user_id = ?", userID).Order("created_at").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	teams := make([]models.Team, 0, len(memberships))
+	for _, m := range memberships {
+		team, err := s.teamFor(m)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, *team)
+	}
+	return teams, nil
+}
+
+// GetTeam 获取团队信息，只有成员可以查看，邀请码只对管理员可见
+func (s *TeamService) GetTeam(userID, teamID uint) (*models.Team, error) {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.teamFor(*member)
+}
+
+// UpdateTeam 修改团队名称、简介和排行榜设置，需要管理员
+func (s *TeamService) UpdateTeam(userID, teamID uint, update *models.Team) (*models.Team, error) {
+	member, err := s.requireAdmin(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeTeam(update); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.Team{}).Where("id = ?", teamID).Updates(map[string]interface{}{
+		"name":                update.Name,
+		"description":         update.Description,
+		"leaderboard_metrics": update.LeaderboardMetrics,
+		"leaderboard_periods": update.LeaderboardPeriods,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.teamFor(*member)
+}
+
+// DeleteTeam 删除团队及其成员关系，只有所有者可以删除
+func (s *TeamService) DeleteTeam(userID, teamID uint) error {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role != models.TeamOwner {
+		return ErrTeamForbidden
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamMembership{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Team{}, teamID).Error
+	})
+}
+
+// RegenerateInviteCode 重新生成邀请码，原邀请码失效，需要管理员
+func (s *TeamService) RegenerateInviteCode(userID, teamID uint) (*models.Team, error) {
+	member, err := s.requireAdmin(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := utils.InviteCode(teamInviteCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.Team{}).Where("id = ?", teamID).Update("invite_code", code).Error; err != nil {
+		return nil, err
+	}
+	return s.teamFor(*member)
+}
+
+// JoinTeam 通过邀请码加入团队，已是成员时直接返回团队
+func (s *TeamService) JoinTeam(userID uint, code string) (*models.Team, error) {
+	var team models.Team
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if err := s.db.Where("invite_code = ?", code).First(&team).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: 邀请码无效", ErrInvalidTeam)
+		}
+		return nil, err
+	}
+
+	member := models.TeamMembership{TeamID: team.ID, UserID: userID}
+	if err := s.db.Where(member).Attrs(models.TeamMembership{Role: models.TeamMember}).FirstOrCreate(&member).Error; err != nil {
+		return nil, err
+	}
+	return s.teamFor(member)
+}
+
+// LeaveTeam 退出团队，所有者需先转让团队
+func (s *TeamService) LeaveTeam(userID, teamID uint) error {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.TeamOwner {
+		return fmt.Errorf("%w: 所有者需先转让团队或删除团队", ErrInvalidTeam)
+	}
+	return s.db.Delete(member).Error
+}
+
+// GetMembers 获取团队成员，只有成员可以查看
+func (s *TeamService) GetMembers(userID, teamID uint) ([]TeamMember, error) {
+	if _, err := s.membership(teamID, userID); err != nil {
+		return nil, err
+	}
+
+	var members []TeamMember
+	err := s.db.Table("team_memberships").
+		Select("team_memberships.user_id, users.username, users.avatar_url, team_memberships.role, "+
+			"team_memberships.leaderboard_opt_out, team_memberships.feed_opt_out, team_memberships.created_at AS joined_at").
+		Joins("JOIN users ON users.id = team_memberships.user_id AND users.deleted_at IS NULL").
+		Where("team_memberships.team_id = ?", teamID).
+		Order("FIELD(team_memberships.role, 'owner', 'admin', 'member'), team_memberships.created_at").
+		Scan(&members).Error
+	return members, err
+}
+
+// SetMemberRole 修改成员角色，只有所有者可以操作；设为 owner 时转让团队，原所有者成为管理员
+func (s *TeamService) SetMemberRole(userID, teamID, memberID uint, role models.TeamRole) error {
+	actor, err := s.membership(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != models.TeamOwner {
+		return ErrTeamForbidden
+	}
+	if role != models.TeamOwner && role != models.TeamAdmin && role != models.TeamMember {
+		return fmt.Errorf("%w: 无效的成员角色", ErrInvalidTeam)
+	}
+	if memberID == userID {
+		return fmt.Errorf("%w: 不能修改自己的角色", ErrInvalidTeam)
+	}
+	target, err := s.membership(teamID, memberID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if role == models.TeamOwner {
+			if err := tx.Model(actor).Update("role", models.TeamAdmin).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Team{}).Where("id = ?", teamID).Update("owner_id", memberID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(target).Update("role", role).Error
+	})
+}
+
+// RemoveMember 移出成员，管理员只能移出普通成员，所有者可以移出任何人
+func (s *TeamService) RemoveMember(userID, teamID, memberID uint) error {
+	actor, err := s.requireAdmin(teamID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID == userID {
+		return fmt.Errorf("%w: 请使用退出团队", ErrInvalidTeam)
+	}
+	target, err := s.membership(teamID, memberID)
+	if err != nil {
+		return err
+	}
+	if target.IsAdmin() && actor.Role != models.TeamOwner {
+		return ErrTeamForbidden
+	}
+	return s.db.Delete(target).Error
+}
+
+// SetLeaderboardOptOut 设置是否参与团队排行榜
+func (s *TeamService) SetLeaderboardOptOut(userID, teamID uint, optOut bool) error {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Model(member).Update("leaderboard_opt_out", optOut).Error
+}
+
+// SetFeedOptOut 设置是否在团队动态中显示自己的记录
+func (s *TeamService) SetFeedOptOut(userID, teamID uint, optOut bool) error {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return err
+	}
+	return s.db.Model(member).Update("feed_opt_out", optOut).Error
+}
+
+// GetFeed 获取团队成员的攀岩记录，按开始时间倒序
+//
+// 只包含成员加入团队之后开始的记录，不包含退出团队动态的成员。
+func (s *TeamService) GetFeed(userID, teamID uint, page, limit int) (*TeamFeed, error) {
+	if _, err := s.membership(teamID, userID); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultTeamFeedLimit
+	}
+	if limit > maxTeamFeedLimit {
+		limit = maxTeamFeedLimit
+	}
+
+	query := s.db.Table("climbing_records").
+		Joins("JOIN team_memberships ON team_memberships.user_id = climbing_records.user_id AND team_memberships.team_id = ? "+
+			"AND team_memberships.feed_opt_out = ?", teamID, false).
+		Joins("JOIN users ON users.id = climbing_records.user_id AND users.deleted_at IS NULL").
+		Where("climbing_records.deleted_at IS NULL AND climbing_records.start_time >= team_memberships.created_at")
+
+	feed := &TeamFeed{Data: []TeamFeedItem{}, Page: page, Limit: limit}
+	if err := query.Count(&feed.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := query.
+		Select("climbing_records.id AS record_id, climbing_records.user_id, users.username, users.avatar_url, " +
+			"climbing_records.type, climbing_records.grade, climbing_records.attempts, climbing_records.success, " +
+			"climbing_records.duration, climbing_records.location, climbing_records.start_time").
+		Order("climbing_records.start_time DESC, climbing_records.id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Scan(&feed.Data).Error
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// GetStats 获取团队成员在 [from, to] 内的汇总统计
+func (s *TeamService) GetStats(userID, teamID uint, from, to time.Time, loc *time.Location) (*TeamStats, error) {
+	if _, err := s.membership(teamID, userID); err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := s.memberIDs(teamID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &TeamStats{
+		From:         from,
+		To:           to,
+		Members:      len(memberIDs),
+		SendsByGrade: make(map[models.ClimbingType]map[string]int),
+	}
+	if len(memberIDs) == 0 {
+		return stats, nil
+	}
+
+	rollups := s.db.Model(&models.DailyRollup{}).
+		Where("user_id IN ? AND day BETWEEN ? AND ?", memberIDs, from.In(loc).Format("2006-01-02"), to.In(loc).Format("2006-01-02"))
+
+	var active int64
+	if err := rollups.Session(&gorm.Session{}).Distinct("user_id").Count(&active).Error; err != nil {
+		return nil, err
+	}
+	stats.ActiveMembers = int(active)
+
+	var buckets []rollupBucket
+	if err := rollups.Session(&gorm.Session{}).
+		Select("type, grade, SUM(sessions) AS sessions, SUM(duration) AS duration, SUM(calories) AS calories, SUM(sends) AS sends").
+		Group("type, grade").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		stats.TotalSessions += b.Sessions
+		stats.TotalDuration += b.Duration
+		stats.TotalCalories += b.Calories
+		stats.TotalSends += b.Sends
+		if b.Sends == 0 || b.Grade == "" {
+			continue
+		}
+		if stats.SendsByGrade[b.Type] == nil {
+			stats.SendsByGrade[b.Type] = make(map[string]int)
+		}
+		stats.SendsByGrade[b.Type][b.Grade] += b.Sends
+	}
+	return stats, nil
+}
+
+// GetLeaderboard 获取团队排行榜，不包含退出排行榜的成员；指标和周期需在团队设置中启用
+func (s *TeamService) GetLeaderboard(userID, teamID uint, q LeaderboardQuery, settings utils.TimeSettings, offset, limit int) (*Leaderboard, error) {
+	q.Scope, q.TeamID = ScopeTeam, teamID
+	return s.leaderboards.GetLeaderboard(userID, q, settings, offset, limit)
+}
+
+// membership 获取用户在团队中的成员关系，不是成员时返回 ErrRecordNotFound，避免泄露团队是否存在
+func (s *TeamService) membership(teamID, userID uint) (*models.TeamMembership, error) {
+	var member models.TeamMembership
+	if err := s.db.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// requireAdmin 要求用户是团队的管理员或所有者
+func (s *TeamService) requireAdmin(teamID, userID uint) (*models.TeamMembership, error) {
+	member, err := s.membership(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.IsAdmin() {
+		return nil, ErrTeamForbidden
+	}
+	return member, nil
+}
+
+// memberIDs 获取团队成员ID
+func (s *TeamService) memberIDs(teamID uint) ([]uint, error) {
+	var ids []uint
+	err := s.db.Model(&models.TeamMembership{}).Where("team_id = ?", teamID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// teamFor 读取成员所在的团队，并填充成员数和当前用户的角色
+func (s *TeamService) teamFor(member models.TeamMembership) (*models.Team, error) {
+	var team models.Team
+	if err := s.db.First(&team, member.TeamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.TeamMembership{}).Where("team_id = ?", team.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	team.MemberCount = int(count)
+	team.Role = member.Role
+	if !member.IsAdmin() {
+		team.InviteCode = ""
+	}
+	return &team, nil
+}
+
+// normalizeTeam 校验团队名称和排行榜设置
+func normalizeTeam(team *models.Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" || len([]rune(team.Name)) > 100 {
+		return fmt.Errorf("%w: 团队名称不能为空且不超过 100 个字符", ErrInvalidTeam)
+	}
+	team.LeaderboardMetrics = team.LeaderboardMetrics.Normalize()
+	for _, metric := range team.LeaderboardMetrics {
+		if !contains(LeaderboardMetrics, metric) {
+			return fmt.Errorf("%w: 未知的指标 %q", ErrInvalidLeaderboard, metric)
+		}
+	}
+	team.LeaderboardPeriods = team.LeaderboardPeriods.Normalize()
+	for _, period := range team.LeaderboardPeriods {
+		if !contains(LeaderboardPeriods, period) {
+			return fmt.Errorf("%w: 未知的周期 %q", ErrInvalidLeaderboard, period)
+		}
+	}
+	return nil
+}
+
+// settingEnabled 团队设置是否启用 value，未设置时全部启用
+func settingEnabled(list models.TagList, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
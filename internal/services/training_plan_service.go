@@ -0,0 +1,711 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"movePoint/internal/events"
+	"movePoint/internal/models"
+	"movePoint/pkg/utils"
+)
+
+// ErrInvalidPlan 训练计划设置无效
+var ErrInvalidPlan = errors.New("invalid training plan")
+
+// 训练计划最长周数
+const maxPlanWeeks = 52
+
+type TrainingPlanService struct {
+	db *gorm.DB
+}
+
+func NewTrainingPlanService(db *gorm.DB) *TrainingPlanService {
+	return &TrainingPlanService{db: db}
+}
+
+// PlanAdherence 训练计划的执行情况，只统计计划日期不晚于今天的训练，休息日单独统计
+type PlanAdherence struct {
+	PlanID       uint                    `json:"plan_id"`
+	From         time.Time               `json:"from"`
+	To           time.Time               `json:"to"`
+	Planned      int                     `json:"planned"`     // 到期的训练数 (不含休息日)
+	Completed    int                     `json:"completed"`   // 其中已完成的
+	TargetsMet   int                     `json:"targets_met"` // 其中达到全部训练目标的
+	Upcoming     int                     `json:"upcoming"`    // 尚未到期的训练数
+	Adherence    float64                 `json:"adherence"`   // 完成率 (%)
+	TargetRate   float64                 `json:"target_rate"` // 达标率 (%)
+	RestDays     int                     `json:"rest_days"`   // 已过的休息日
+	RestDaysKept int                     `json:"rest_days_kept"`
+	Weeks        []WeekAdherence         `json:"weeks"`
+	Kinds        []KindAdherence         `json:"kinds"`
+	Missed       []models.PlannedSession `json:"missed"`
+}
+
+// WeekAdherence 单周的执行情况
+type WeekAdherence struct {
+	Week       int       `json:"week"`
+	Start      time.Time `json:"start"`
+	Planned    int       `json:"planned"`
+	Completed  int       `json:"completed"`
+	TargetsMet int       `json:"targets_met"`
+	Adherence  float64   `json:"adherence"`
+}
+
+// KindAdherence 按训练类型的执行情况
+type KindAdherence struct {
+	Kind      models.SessionKind `json:"kind"`
+	Planned   int                `json:"planned"`
+	Completed int                `json:"completed"`
+	Adherence float64            `json:"adherence"`
+}
+
+// Subscribe 订阅攀岩记录变更事件，重新匹配受影响的计划；时区变化时重新匹配全部计划
+func (s *TrainingPlanService) Subscribe(bus *events.Bus) {
+	for _, topic := range []events.Topic{events.RecordCreated, events.RecordUpdated, events.RecordDeleted} {
+		bus.Subscribe(topic, s.HandleRecordEvent)
+	}
+	bus.Subscribe(events.TimeSettingsChanged, func(e events.Event) error {
+		return s.MatchAll(e.UserID, nil)
+	})
+}
+
+// HandleRecordEvent 根据变更前后的记录时间重新匹配覆盖该时间的计划
+func (s *TrainingPlanService) HandleRecordEvent(e events.Event) error {
+	change, ok := e.Payload.(events.RecordChange)
+	if !ok {
+		return nil
+	}
+	var times []time.Time
+	for _, r := range []*models.ClimbingRecord{change.Before, change.After} {
+		if r != nil {
+			times = append(times, r.StartTime)
+		}
+	}
+	return s.MatchAll(e.UserID, times)
+}
+
+// MatchAll 重新匹配用户的计划，times 不为空时只匹配覆盖其中任一时间的计划
+func (s *TrainingPlanService) MatchAll(userID uint, times []time.Time) error {
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND is_template = ? AND start_date IS NOT NULL", userID, false).Find(&plans).Error
+	if err != nil || len(plans) == 0 {
+		return err
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range plans {
+		if len(times) > 0 {
+			from, to := planRange(&plans[i], settings.Location)
+			covered := false
+			for _, t := range times {
+				if !t.Before(from) && !t.After(to) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				continue
+			}
+		}
+		if err := s.matchPlan(&plans[i], settings.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePlan 创建训练计划或模板，并匹配已有的攀岩记录
+func (s *TrainingPlanService) CreatePlan(userID uint, plan *models.TrainingPlan) error {
+	return s.createPlan(userID, userID, plan)
+}
+
+// AssignPlan 由教练 authorID 为用户 userID 创建训练计划，计划归用户所有；调用方负责检查教练的权限
+//
+// 开始日期取 plan.StartDate 所在时区的日历日期，换算为用户时区的零点。
+func (s *TrainingPlanService) AssignPlan(authorID, userID uint, plan *models.TrainingPlan) error {
+	plan.IsTemplate = false
+	plan.Public = false
+	if plan.StartDate != nil {
+		settings, err := userTimeSettings(s.db, userID)
+		if err != nil {
+			return err
+		}
+		y, m, d := plan.StartDate.Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, settings.Location)
+		plan.StartDate = &start
+	}
+	return s.createPlan(userID, authorID, plan)
+}
+
+func (s *TrainingPlanService) createPlan(userID, authorID uint, plan *models.TrainingPlan) error {
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+	plan.ID = 0
+	plan.UserID = userID
+	plan.AuthorID = authorID
+	plan.SourceID = nil
+	if err := normalizePlan(plan); err != nil {
+		return err
+	}
+	for i := range plan.Sessions {
+		resetSession(&plan.Sessions[i])
+	}
+
+	if err := s.db.Create(plan).Error; err != nil {
+		return err
+	}
+	return s.matchPlan(plan, settings.Location)
+}
+
+// UpdatePlan 修改训练计划，整体替换计划训练；同一天同类型的训练保留手动标记的完成状态
+func (s *TrainingPlanService) UpdatePlan(userID, planID uint, plan *models.TrainingPlan) error {
+	existing, err := s.findOwnPlan(userID, planID)
+	if err != nil {
+		return err
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return err
+	}
+
+	plan.ID = existing.ID
+	plan.CreatedAt = existing.CreatedAt
+	plan.UserID, plan.AuthorID, plan.SourceID = existing.UserID, existing.AuthorID, existing.SourceID
+	if err := normalizePlan(plan); err != nil {
+		return err
+	}
+
+	manual := make(map[string]*time.Time)
+	for _, sess := range existing.Sessions {
+		if sess.Manual {
+			manual[sessionSlot(&sess)] = sess.CompletedAt
+		}
+	}
+	for i := range plan.Sessions {
+		sess := &plan.Sessions[i]
+		resetSession(sess)
+		sess.PlanID = plan.ID
+		if completedAt, ok := manual[sessionSlot(sess)]; ok {
+			sess.Manual, sess.CompletedAt = true, completedAt
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.PlannedSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Sessions").Save(plan).Error; err != nil {
+			return err
+		}
+		if len(plan.Sessions) == 0 {
+			return nil
+		}
+		return tx.Create(&plan.Sessions).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.matchPlan(plan, settings.Location)
+}
+
+// DeletePlan 删除训练计划及其计划训练
+func (s *TrainingPlanService) DeletePlan(userID, planID uint) error {
+	if _, err := s.findOwnPlan(userID, planID); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", planID).Delete(&models.PlannedSession{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TrainingPlan{}, planID).Error
+	})
+}
+
+// GetPlan 获取训练计划，包含每次训练的计划日期和完成状态；公开模板所有用户可见
+func (s *TrainingPlanService) GetPlan(userID, planID uint) (*models.TrainingPlan, error) {
+	var plan models.TrainingPlan
+	err := s.db.Preload("Sessions", orderSessions).
+		Where("id = ? AND (user_id = ? OR (is_template = ? AND public = ?))", planID, userID, true, true).
+		First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	annotateSessions(&plan, time.Now(), settings.Location)
+	plan.LocalizeTimes(settings.Location)
+	return &plan, nil
+}
+
+// ListPlans 获取用户的训练计划 (不含计划训练)，templates 为 true 时获取自己的模板
+func (s *TrainingPlanService) ListPlans(userID uint, templates bool) ([]models.TrainingPlan, error) {
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND is_template = ?", userID, templates).
+		Order("start_date DESC, created_at DESC").Find(&plans).Error
+	return plans, err
+}
+
+// ListTemplates 获取自己的模板和其他用户公开的模板
+func (s *TrainingPlanService) ListTemplates(userID uint) ([]models.TrainingPlan, error) {
+	var plans []models.TrainingPlan
+	err := s.db.Where("is_template = ? AND (user_id = ? OR public = ?)", true, userID, true).
+		Order("created_at DESC").Find(&plans).Error
+	return plans, err
+}
+
+// CopyPlan 复制计划或模板；asTemplate 为 false 时必须指定开始日期，复制后匹配已有记录
+func (s *TrainingPlanService) CopyPlan(userID, planID uint, name string, startDate *time.Time, asTemplate bool) (*models.TrainingPlan, error) {
+	var source models.TrainingPlan
+	err := s.db.Preload("Sessions", orderSessions).
+		Where("id = ? AND (user_id = ? OR (is_template = ? AND public = ?))", planID, userID, true, true).
+		First(&source).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.TrainingPlan{
+		Name:        source.Name,
+		Description: source.Description,
+		IsTemplate:  asTemplate,
+		StartDate:   startDate,
+		Weeks:       source.Weeks,
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		plan.Name = name
+	}
+	for _, sess := range source.Sessions {
+		sess.ID = 0
+		sess.PlanID = 0
+		plan.Sessions = append(plan.Sessions, sess)
+	}
+
+	if err := s.CreatePlan(userID, plan); err != nil {
+		return nil, err
+	}
+	sourceID := source.ID
+	if err := s.db.Model(plan).Update("source_id", sourceID).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPlan(userID, plan.ID)
+}
+
+// CompleteSession 手动标记计划训练完成或取消标记，取消后仍可能因匹配到记录而完成
+func (s *TrainingPlanService) CompleteSession(userID, planID, sessionID uint, completed bool) (*models.TrainingPlan, error) {
+	plan, err := s.findOwnPlan(userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.IsTemplate {
+		return nil, fmt.Errorf("%w: 模板不能标记完成", ErrInvalidPlan)
+	}
+
+	var sess *models.PlannedSession
+	for i := range plan.Sessions {
+		if plan.Sessions[i].ID == sessionID {
+			sess = &plan.Sessions[i]
+		}
+	}
+	if sess == nil {
+		return nil, ErrRecordNotFound
+	}
+	if sess.Kind == models.SessionRest {
+		return nil, fmt.Errorf("%w: 休息日不能标记完成", ErrInvalidPlan)
+	}
+
+	updates := map[string]interface{}{"manual": completed}
+	if completed {
+		updates["completed_at"] = time.Now()
+	} else {
+		updates["completed_at"] = nil
+	}
+	if err := s.db.Model(sess).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	plan, err = s.findOwnPlan(userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.matchPlan(plan, settings.Location); err != nil {
+		return nil, err
+	}
+	return s.GetPlan(userID, planID)
+}
+
+// GetAdherence 计算训练计划的执行情况
+func (s *TrainingPlanService) GetAdherence(userID, planID uint) (*PlanAdherence, error) {
+	plan, err := s.findOwnPlan(userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.IsTemplate || plan.StartDate == nil {
+		return nil, fmt.Errorf("%w: 模板没有执行情况", ErrInvalidPlan)
+	}
+	settings, err := userTimeSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location
+
+	annotateSessions(plan, time.Now(), loc)
+	from, to := planRange(plan, loc)
+	result := &PlanAdherence{PlanID: plan.ID, From: from.In(loc), To: to.In(loc)}
+
+	weeks := make([]WeekAdherence, plan.Weeks)
+	for i := range weeks {
+		weeks[i] = WeekAdherence{Week: i + 1, Start: from.In(loc).AddDate(0, 0, i*7)}
+	}
+	kinds := make(map[models.SessionKind]*KindAdherence)
+	var kindOrder []models.SessionKind
+
+	for _, sess := range plan.Sessions {
+		switch sess.Status {
+		case models.StatusUpcoming:
+			if sess.Kind != models.SessionRest {
+				result.Upcoming++
+			}
+			continue
+		case models.StatusRested, models.StatusBroken:
+			result.RestDays++
+			if sess.Status == models.StatusRested {
+				result.RestDaysKept++
+			}
+			continue
+		}
+
+		done := sess.Status == models.StatusCompleted || sess.Status == models.StatusPartial
+		week := &weeks[sess.Week-1]
+		result.Planned++
+		week.Planned++
+		k, ok := kinds[sess.Kind]
+		if !ok {
+			k = &KindAdherence{Kind: sess.Kind}
+			kinds[sess.Kind] = k
+			kindOrder = append(kindOrder, sess.Kind)
+		}
+		k.Planned++
+		if done {
+			result.Completed++
+			week.Completed++
+			k.Completed++
+		}
+		if sess.Status == models.StatusCompleted {
+			result.TargetsMet++
+			week.TargetsMet++
+		}
+		if sess.Status == models.StatusMissed {
+			sess.LocalizeTimes(loc)
+			result.Missed = append(result.Missed, sess)
+		}
+	}
+
+	result.Adherence = round1(percentage(result.Completed, result.Planned))
+	result.TargetRate = round1(percentage(result.TargetsMet, result.Completed))
+	for i := range weeks {
+		weeks[i].Adherence = round1(percentage(weeks[i].Completed, weeks[i].Planned))
+	}
+	result.Weeks = weeks
+	for _, kind := range kindOrder {
+		k := kinds[kind]
+		k.Adherence = round1(percentage(k.Completed, k.Planned))
+		result.Kinds = append(result.Kinds, *k)
+	}
+	return result, nil
+}
+
+// matchPlan 将计划期间的攀岩记录匹配到同一天的训练并更新完成情况
+//
+// 每条记录优先匹配同类型的攀岩训练，其次匹配不限类型的攀岩训练；
+// 当天没有攀岩训练但为休息日时记到休息日上，表示没有按计划休息。
+func (s *TrainingPlanService) matchPlan(plan *models.TrainingPlan, loc *time.Location) error {
+	if plan.IsTemplate || plan.StartDate == nil {
+		return nil
+	}
+	if plan.Sessions == nil {
+		if err := s.db.Where("plan_id = ?", plan.ID).Order("week ASC, day ASC, id ASC").Find(&plan.Sessions).Error; err != nil {
+			return err
+		}
+	}
+
+	from, to := planRange(plan, loc)
+	var records []models.ClimbingRecord
+	err := s.db.Select("id", "type", "start_time", "end_time", "duration", "grade", "success").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", plan.UserID, from, to).
+		Order("start_time ASC").Find(&records).Error
+	if err != nil {
+		return err
+	}
+
+	byDay := make(map[string][]*models.PlannedSession)
+	for i := range plan.Sessions {
+		sess := &plan.Sessions[i]
+		day := sessionDate(plan, sess, loc).Format("2006-01-02")
+		byDay[day] = append(byDay[day], sess)
+	}
+
+	matched := make(map[uint][]models.ClimbingRecord)
+	for _, r := range records {
+		if sess := matchSession(byDay[r.StartTime.In(loc).Format("2006-01-02")], r.Type); sess != nil {
+			matched[sess.ID] = append(matched[sess.ID], r)
+		}
+	}
+
+	for i := range plan.Sessions {
+		sess := &plan.Sessions[i]
+		recs := matched[sess.ID]
+		ids := models.IDList{}
+		for _, r := range recs {
+			ids = append(ids, r.ID)
+		}
+
+		completedAt, targetsMet := sess.CompletedAt, sess.Manual
+		if !sess.Manual {
+			completedAt = nil
+		}
+		if len(recs) > 0 && sess.Kind != models.SessionRest {
+			targetsMet = sessionTargetsMet(sess, recs)
+			if !sess.Manual {
+				end := recs[len(recs)-1].EndTime
+				completedAt = &end
+			}
+		}
+
+		if sameIDs(ids, sess.RecordIDs) && sameTime(completedAt, sess.CompletedAt) && targetsMet == sess.TargetsMet {
+			continue
+		}
+		err := s.db.Model(sess).Updates(map[string]interface{}{
+			"record_ids":   ids,
+			"completed_at": completedAt,
+			"targets_met":  targetsMet,
+		}).Error
+		if err != nil {
+			return err
+		}
+		sess.RecordIDs, sess.CompletedAt, sess.TargetsMet = ids, completedAt, targetsMet
+	}
+	return nil
+}
+
+// matchSession 选择同一天中与记录类型匹配的训练
+func matchSession(sessions []*models.PlannedSession, climbingType models.ClimbingType) *models.PlannedSession {
+	var anyType, rest *models.PlannedSession
+	for _, sess := range sessions {
+		switch {
+		case sess.Kind == models.SessionClimbing && sess.Type == climbingType:
+			return sess
+		case sess.Kind == models.SessionClimbing && sess.Type == "" && anyType == nil:
+			anyType = sess
+		case sess.Kind == models.SessionRest && rest == nil:
+			rest = sess
+		}
+	}
+	if anyType != nil {
+		return anyType
+	}
+	return rest
+}
+
+// sessionTargetsMet 匹配到的记录是否达到训练的时长、完成数和难度目标
+func sessionTargetsMet(sess *models.PlannedSession, records []models.ClimbingRecord) bool {
+	duration, sends := 0, 0
+	gradeMet := sess.TargetGrade == ""
+	target, _ := parseGrade(sess.Type, sess.TargetGrade)
+	for _, r := range records {
+		duration += r.Duration
+		if !r.Success {
+			continue
+		}
+		sends++
+		if info, ok := parseGrade(r.Type, r.Grade); ok && !gradeMet && info.Type == target.Type && info.Level >= target.Level {
+			gradeMet = true
+		}
+	}
+	return duration >= sess.TargetDuration && sends >= sess.TargetSends && gradeMet
+}
+
+// annotateSessions 计算每次训练的计划日期和完成状态，模板不做处理
+func annotateSessions(plan *models.TrainingPlan, now time.Time, loc *time.Location) {
+	if plan.IsTemplate || plan.StartDate == nil {
+		return
+	}
+	today := utils.StartOfDay(now, loc)
+	for i := range plan.Sessions {
+		sess := &plan.Sessions[i]
+		date := sessionDate(plan, sess, loc)
+		sess.Date = &date
+
+		past := date.Before(today)
+		switch {
+		case sess.Kind == models.SessionRest && len(sess.RecordIDs) > 0:
+			sess.Status = models.StatusBroken
+		case sess.Kind == models.SessionRest && past:
+			sess.Status = models.StatusRested
+		case sess.Kind == models.SessionRest:
+			sess.Status = models.StatusUpcoming
+		case sess.CompletedAt != nil && sess.TargetsMet:
+			sess.Status = models.StatusCompleted
+		case sess.CompletedAt != nil:
+			sess.Status = models.StatusPartial
+		case past:
+			sess.Status = models.StatusMissed
+		default:
+			sess.Status = models.StatusUpcoming
+		}
+	}
+}
+
+// planRange 计划覆盖的时间范围 [from, to]
+func planRange(plan *models.TrainingPlan, loc *time.Location) (time.Time, time.Time) {
+	from := utils.StartOfDay(*plan.StartDate, loc)
+	return from, from.AddDate(0, 0, plan.Weeks*7).Add(-time.Microsecond)
+}
+
+// sessionDate 训练的计划日期 (用户时区的零点)
+func sessionDate(plan *models.TrainingPlan, sess *models.PlannedSession, loc *time.Location) time.Time {
+	return utils.StartOfDay(*plan.StartDate, loc).AddDate(0, 0, (sess.Week-1)*7+sess.Day-1)
+}
+
+// sessionSlot 训练在计划中的位置，用于修改计划时保留手动完成状态
+func sessionSlot(sess *models.PlannedSession) string {
+	return fmt.Sprintf("%d-%d-%s", sess.Week, sess.Day, sess.Kind)
+}
+
+// resetSession 清除客户端提交的完成情况，由匹配重新计算
+func resetSession(sess *models.PlannedSession) {
+	sess.ID = 0
+	sess.RecordIDs = models.IDList{}
+	sess.CompletedAt = nil
+	sess.Manual = false
+	sess.TargetsMet = false
+	sess.Date = nil
+	sess.Status = ""
+}
+
+// normalizePlan 校验计划设置，周数默认取计划训练的最大周
+func normalizePlan(plan *models.TrainingPlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	if plan.Name == "" || len([]rune(plan.Name)) > 100 {
+		return fmt.Errorf("%w: 计划名称不能为空且不超过 100 个字符", ErrInvalidPlan)
+	}
+	if plan.IsTemplate {
+		plan.StartDate = nil
+	} else {
+		plan.Public = false
+		if plan.StartDate == nil {
+			return fmt.Errorf("%w: 训练计划需要开始日期", ErrInvalidPlan)
+		}
+	}
+
+	maxWeek := 0
+	for i := range plan.Sessions {
+		sess := &plan.Sessions[i]
+		if sess.Week < 1 || sess.Week > maxPlanWeeks || sess.Day < 1 || sess.Day > 7 {
+			return fmt.Errorf("%w: 第 %d 次训练的周数须为 1-%d，天数须为 1-7", ErrInvalidPlan, i+1, maxPlanWeeks)
+		}
+		if sess.Week > maxWeek {
+			maxWeek = sess.Week
+		}
+
+		switch sess.Kind {
+		case models.SessionClimbing, models.SessionHangboard, models.SessionConditioning:
+		case models.SessionRest:
+			sess.Type, sess.TargetDuration, sess.TargetSends, sess.TargetGrade = "", 0, 0, ""
+		default:
+			return fmt.Errorf("%w: 无效的训练类型 %s", ErrInvalidPlan, sess.Kind)
+		}
+		switch sess.Type {
+		case "", models.Bouldering, models.SportClimbing:
+		default:
+			return fmt.Errorf("%w: 无效的攀岩类型 %s", ErrInvalidPlan, sess.Type)
+		}
+		if sess.TargetDuration < 0 || sess.TargetSends < 0 {
+			return fmt.Errorf("%w: 训练目标不能为负数", ErrInvalidPlan)
+		}
+		if sess.TargetGrade != "" {
+			if sess.Kind != models.SessionClimbing {
+				return fmt.Errorf("%w: 只有攀岩训练可以设置难度目标", ErrInvalidPlan)
+			}
+			if _, ok := parseGrade(sess.Type, sess.TargetGrade); !ok {
+				return fmt.Errorf("%w: 无法识别的难度 %s", ErrInvalidPlan, sess.TargetGrade)
+			}
+		}
+		sess.Title = strings.TrimSpace(sess.Title)
+		if len([]rune(sess.Title)) > 100 {
+			return fmt.Errorf("%w: 训练名称不超过 100 个字符", ErrInvalidPlan)
+		}
+	}
+
+	if plan.Weeks == 0 {
+		plan.Weeks = maxWeek
+	}
+	if plan.Weeks < 1 || plan.Weeks > maxPlanWeeks || plan.Weeks < maxWeek {
+		return fmt.Errorf("%w: 计划周数须为 1-%d 且不少于计划训练所在的周", ErrInvalidPlan, maxPlanWeeks)
+	}
+
+	sort.SliceStable(plan.Sessions, func(i, j int) bool {
+		a, b := plan.Sessions[i], plan.Sessions[j]
+		if a.Week != b.Week {
+			return a.Week < b.Week
+		}
+		return a.Day < b.Day
+	})
+	return nil
+}
+
+// findOwnPlan 获取用户自己的计划 (含计划训练)
+func (s *TrainingPlanService) findOwnPlan(userID, planID uint) (*models.TrainingPlan, error) {
+	var plan models.TrainingPlan
+	err := s.db.Preload("Sessions", orderSessions).Where("user_id = ? AND id = ?", userID, planID).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func orderSessions(db *gorm.DB) *gorm.DB {
+	return db.Order("week ASC, day ASC, id ASC")
+}
+
+func sameIDs(a, b models.IDList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"movePoint/internal/events"
 	"movePoint/internal/models"
+	"movePoint/pkg/utils"
 )
 
 type UserService struct {
@@ -20,7 +22,9 @@ func NewUserService(db *gorm.DB) *UserService {
 // GetUserProfile 获取用户个人信息
 func (s *UserService) GetUserProfile(userID uint) (*models.User, error) {
 	var user models.User
-	result := s.db.Select("id", "username", "email", "weight", "height", "birth_date", "avatar_url", "bio", "achievements", "created_at").
+	result := s.db.Select("id", "username", "email", "weight", "height", "birth_date", "avatar_url", "bio", "achievements", "max_heart_rate", "resting_heart_rate", "timezone", "week_start", "locale", "leaderboard_opt_out", "created_at").
+		Preload("PersonalRecords").
+		Preload("Milestones").
 		Where("id = ?", userID).
 		First(&user)
 
@@ -31,14 +35,16 @@ func (s *UserService) GetUserProfile(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
+// profileFields 用户可以修改的个人信息字段 (JSON 字段名与列名一致)
+var profileFields = []string{"weight", "height", "birth_date", "avatar_url", "bio", "max_heart_rate", "resting_heart_rate", "timezone", "week_start", "locale", "leaderboard_opt_out"}
+
 // UpdateUserProfile 更新用户个人信息
 func (s *UserService) UpdateUserProfile(userID uint, updates map[string]interface{}) error {
 	// 过滤允许更新的字段
-	allowedFields := []string{"weight", "height", "birth_date", "avatar_url", "bio"}
 	filteredUpdates := make(map[string]interface{})
 
 	for key, value := range updates {
-		for _, allowed := range allowedFields {
+		for _, allowed := range profileFields {
 			if key == allowed {
 				filteredUpdates[key] = value
 				break
@@ -50,71 +56,148 @@ func (s *UserService) UpdateUserProfile(userID uint, updates map[string]interfac
 		return fmt.Errorf("没有有效的更新字段")
 	}
 
+	if err := validateTimeSettings(filteredUpdates); err != nil {
+		return err
+	}
+	if v, ok := filteredUpdates["leaderboard_opt_out"]; ok {
+		if _, isBool := v.(bool); !isBool {
+			return fmt.Errorf("%w: leaderboard_opt_out 必须为布尔值", ErrInvalidProfile)
+		}
+	}
+
+	var before, after models.User
+	if err := s.db.Select(profileFields).Where("id = ?", userID).First(&before).Error; err != nil {
+		return err
+	}
+
 	result := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(filteredUpdates)
-	return result.Error
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if err := s.db.Select(profileFields).Where("id = ?", userID).First(&after).Error; err != nil {
+		return err
+	}
+	before.ID, after.ID = userID, userID
+	events.Publish(events.Event{
+		Topic:   events.ProfileUpdated,
+		UserID:  userID,
+		Payload: events.ProfileChange{Before: &before, After: &after},
+	})
+
+	// 时区或每周起始日变化后，按日期划分的统计需要重新计算
+	_, tzChanged := filteredUpdates["timezone"]
+	_, weekChanged := filteredUpdates["week_start"]
+	if tzChanged || weekChanged {
+		events.Publish(events.Event{Topic: events.TimeSettingsChanged, UserID: userID})
+	}
+	return nil
 }
 
-// GetUserStats 获取用户统计数据
-func (s *UserService) GetUserStats(userID uint) (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
+// GetTimeSettings 获取用户的时区、每周起始日和语言区域设置
+func (s *UserService) GetTimeSettings(userID uint) (utils.TimeSettings, error) {
+	return userTimeSettings(s.db, userID)
+}
 
-	// 获取总攀岩次数
-	var totalSessions int64
-	if err := s.db.Model(&models.ClimbingRecord{}).
-		Where("user_id = ?", userID).
-		Count(&totalSessions).Error; err != nil {
-		return nil, err
+// userTimeSettings 读取用户时间设置，供各服务按用户时区划分日期
+func userTimeSettings(db *gorm.DB, userID uint) (utils.TimeSettings, error) {
+	var user models.User
+	if err := db.Select("timezone", "week_start", "locale").Where("id = ?", userID).First(&user).Error; err != nil {
+		return utils.DefaultTimeSettings(), err
 	}
-	stats["total_sessions"] = totalSessions
+	return utils.NewTimeSettings(user.Timezone, user.WeekStart, user.Locale), nil
+}
 
-	// 获取总攀岩时长
-	var totalDuration struct {
-		Total int
+// validateTimeSettings 校验时区、每周起始日和语言区域，非法值不写入数据库
+func validateTimeSettings(updates map[string]interface{}) error {
+	if v, ok := updates["timezone"]; ok {
+		tz, _ := v.(string)
+		if _, err := utils.LoadLocation(tz); err != nil {
+			return fmt.Errorf("%w: 无效的时区", ErrInvalidProfile)
+		}
 	}
-	if err := s.db.Model(&models.ClimbingRecord{}).
-		Select("SUM(duration) as total").
-		Where("user_id = ?", userID).
-		Scan(&totalDuration).Error; err != nil {
-		return nil, err
+	if v, ok := updates["week_start"]; ok {
+		// JSON 数字解码为 float64
+		ws, isNumber := v.(float64)
+		if !isNumber || ws != float64(int(ws)) || ws < 0 || ws > 6 {
+			return fmt.Errorf("%w: 每周起始日必须为 0-6", ErrInvalidProfile)
+		}
+		updates["week_start"] = int(ws)
 	}
-	stats["total_duration"] = totalDuration.Total
-
-	// 获取最高难度
-	var highestGrade struct {
-		Grade string
+	if v, ok := updates["locale"]; ok {
+		locale, _ := v.(string)
+		if !utils.ValidLocale(locale) {
+			return fmt.Errorf("%w: 无效的语言区域", ErrInvalidProfile)
+		}
 	}
-	if err := s.db.Model(&models.ClimbingRecord{}).
-		Select("grade").
-		Where("user_id = ? AND success = true", userID).
-		Order("grade DESC").
-		First(&highestGrade).Error; err != nil && err != gorm.ErrRecordNotFound {
+	return nil
+}
+
+// GetUserStats 获取用户统计数据，本周按用户时区和每周起始日计算
+//
+// 攀岩总计从月度预聚合数据汇总，本周次数从每日预聚合数据读取；
+// settings 的时区与预聚合数据使用的用户时区不同时，本周次数回退到查询记录。
+func (s *UserService) GetUserStats(userID uint, settings utils.TimeSettings) (map[string]interface{}, error) {
+	stats := make(map[string]interface{})
+
+	var monthly []models.MonthlyRollup
+	if err := s.db.Where("user_id = ?", userID).Find(&monthly).Error; err != nil {
 		return nil, err
 	}
-	stats["highest_grade"] = highestGrade.Grade
 
-	// 获取最近活动时间
-	var lastActivity struct {
-		LastTime time.Time
-	}
-	if err := s.db.Model(&models.ClimbingRecord{}).
-		Select("MAX(start_time) as last_time").
-		Where("user_id = ?", userID).
-		Scan(&lastActivity).Error; err != nil {
-		return nil, err
+	var totalSessions int64
+	var totalDuration int
+	var highestGrade string
+	var lastActivity time.Time
+	for _, r := range monthly {
+		totalSessions += int64(r.Sessions)
+		totalDuration += r.Duration
+		if r.Sends > 0 && r.Grade != "" && isHigherGrade(r.Grade, highestGrade) {
+			highestGrade = r.Grade
+		}
+		if r.LastActivity.After(lastActivity) {
+			lastActivity = r.LastActivity
+		}
 	}
-	stats["last_activity"] = lastActivity.LastTime
+	stats["total_sessions"] = totalSessions
+	stats["total_duration"] = totalDuration
+	stats["highest_grade"] = highestGrade
+	stats["last_activity"] = lastActivity.In(settings.Location)
 
 	// 获取本周活动次数
-	now := time.Now()
-	startOfWeek := now.AddDate(0, 0, -int(now.Weekday()))
+	startOfWeek := utils.StartOfWeek(time.Now(), settings.Location, settings.WeekStart)
 	var weeklySessions int64
-	if err := s.db.Model(&models.ClimbingRecord{}).
+	userSettings, err := userTimeSettings(s.db, userID)
+	if err == nil && userSettings.Location.String() == settings.Location.String() {
+		var weekly struct{ Total int64 }
+		if err := s.db.Model(&models.DailyRollup{}).
+			Select("COALESCE(SUM(sessions), 0) AS total").
+			Where("user_id = ? AND day >= ?", userID, startOfWeek.Format("2006-01-02")).
+			Scan(&weekly).Error; err != nil {
+			return nil, err
+		}
+		weeklySessions = weekly.Total
+	} else if err := s.db.Model(&models.ClimbingRecord{}).
 		Where("user_id = ? AND start_time >= ?", userID, startOfWeek).
 		Count(&weeklySessions).Error; err != nil {
 		return nil, err
 	}
 	stats["weekly_sessions"] = weeklySessions
 
+	// 包含力量训练和其他训练的全部活动
+	breakdown, err := activityTotalsByKind(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	var totalActivities, totalActivityDuration int
+	for _, t := range breakdown {
+		totalActivities += t.Sessions
+		totalActivityDuration += t.Duration
+	}
+	stats["total_activities"] = totalActivities
+	stats["total_activity_duration"] = totalActivityDuration
+	stats["activity_breakdown"] = breakdown
+
 	return stats, nil
 }
 
@@ -160,7 +243,11 @@ func (s *UserService) CheckAndUpdateAchievements(userID uint) error {
 	}
 
 	// 获取用户统计数据
-	stats, err := s.GetUserStats(userID)
+	settings, err := s.GetTimeSettings(userID)
+	if err != nil {
+		return err
+	}
+	stats, err := s.GetUserStats(userID, settings)
 	if err != nil {
 		return err
 	}
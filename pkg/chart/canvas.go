@@ -0,0 +1,107 @@
+// Package chart 纯 Go 实现的简单图表绘制，同一份绘制指令可输出为 SVG 或 PNG。
+//
+// 输出只取决于输入数据：不包含时间戳或随机标识，浮点坐标统一保留一位小数，
+// 因此相同数据生成的字节完全一致，可直接按内容摘要缓存。
+package chart
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Anchor 文本水平对齐方式
+type Anchor string
+
+const (
+	AnchorStart  Anchor = "start"
+	AnchorMiddle Anchor = "middle"
+	AnchorEnd    Anchor = "end"
+)
+
+// 图表配色
+var (
+	ColorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ColorText       = color.RGBA{0x24, 0x29, 0x2e, 0xff}
+	ColorMuted      = color.RGBA{0x8c, 0x95, 0x9f, 0xff}
+	ColorGrid       = color.RGBA{0xe1, 0xe4, 0xe8, 0xff}
+	ColorPrimary    = color.RGBA{0xf0, 0x6a, 0x2a, 0xff}
+	ColorSecondary  = color.RGBA{0xfb, 0xd3, 0xbd, 0xff}
+
+	// 热力图 0-4 级颜色
+	HeatmapColors = [5]color.RGBA{
+		{0xeb, 0xed, 0xf0, 0xff},
+		{0xfd, 0xd9, 0xc4, 0xff},
+		{0xfa, 0xa9, 0x7d, 0xff},
+		{0xf0, 0x6a, 0x2a, 0xff},
+		{0xb8, 0x3f, 0x0b, 0xff},
+	}
+)
+
+type shapeKind int
+
+const (
+	shapeRect shapeKind = iota
+	shapeLine
+	shapeText
+)
+
+// shape 一条绘制指令
+type shape struct {
+	kind   shapeKind
+	x, y   float64
+	w, h   float64 // 矩形宽高；直线时为终点坐标
+	radius float64
+	color  color.RGBA
+	text   string
+	size   int
+	anchor Anchor
+}
+
+// Canvas 记录绘制指令的画布
+type Canvas struct {
+	Width  int
+	Height int
+	shapes []shape
+}
+
+// NewCanvas 创建指定尺寸、白色背景的画布
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{Width: width, Height: height}
+	c.Rect(0, 0, float64(width), float64(height), ColorBackground)
+	return c
+}
+
+// Rect 填充矩形
+func (c *Canvas) Rect(x, y, w, h float64, fill color.RGBA) {
+	c.RoundRect(x, y, w, h, 0, fill)
+}
+
+// RoundRect 填充圆角矩形 (PNG 输出忽略圆角)
+func (c *Canvas) RoundRect(x, y, w, h, radius float64, fill color.RGBA) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	c.shapes = append(c.shapes, shape{kind: shapeRect, x: x, y: y, w: w, h: h, radius: radius, color: fill})
+}
+
+// Line 1 像素宽的直线
+func (c *Canvas) Line(x1, y1, x2, y2 float64, stroke color.RGBA) {
+	c.shapes = append(c.shapes, shape{kind: shapeLine, x: x1, y: y1, w: x2, h: y2, color: stroke})
+}
+
+// Text 文本，y 为基线位置
+func (c *Canvas) Text(x, y float64, text string, size int, fill color.RGBA, anchor Anchor) {
+	if text == "" {
+		return
+	}
+	c.shapes = append(c.shapes, shape{kind: shapeText, x: x, y: y, text: text, size: size, color: fill, anchor: anchor})
+}
+
+// coord 坐标格式化，保证输出稳定
+func coord(v float64) string {
+	return fmt.Sprintf("%.1f", v)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
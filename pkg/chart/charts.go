@@ -0,0 +1,332 @@
+package chart
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// 通用布局尺寸
+const (
+	padding      = 24.0
+	titleHeight  = 56.0
+	footerHeight = 32.0
+)
+
+// Options 图表通用选项
+type Options struct {
+	Title    string
+	Subtitle string
+	Brand    string // 品牌名称，显示在左下角，为空不显示
+	ShareURL string // 分享链接，显示在右下角，为空不显示
+}
+
+func (o Options) footer() float64 {
+	if o.Brand == "" && o.ShareURL == "" {
+		return 0
+	}
+	return footerHeight
+}
+
+// frame 创建画布并绘制标题和页脚，返回的画布宽高已包含标题和页脚区域
+func frame(width, bodyHeight float64, opts Options) *Canvas {
+	height := titleHeight + bodyHeight + opts.footer()
+	c := NewCanvas(int(width), int(height))
+	c.Text(padding, 32, opts.Title, 18, ColorText, AnchorStart)
+	c.Text(width-padding, 32, opts.Subtitle, 12, ColorMuted, AnchorEnd)
+
+	if opts.footer() > 0 {
+		y := height - footerHeight
+		c.Line(padding, y, width-padding, y, ColorGrid)
+		c.Text(padding, y+21, opts.Brand, 12, ColorPrimary, AnchorStart)
+		c.Text(width-padding, y+21, opts.ShareURL, 11, ColorMuted, AnchorEnd)
+	}
+	return c
+}
+
+// Bar 柱状图中的一根柱子
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// BarChart 纵向柱状图，用于月度趋势等时间序列
+func BarChart(bars []Bar, opts Options) *Canvas {
+	const (
+		plotHeight = 240.0
+		axisWidth  = 48.0
+		labelSpace = 28.0
+		slot       = 44.0
+	)
+	width := math.Max(640, 2*padding+axisWidth+slot*float64(len(bars)))
+	c := frame(width, plotHeight+labelSpace+padding/2, opts)
+
+	top := titleHeight + padding/2
+	left := padding + axisWidth
+	plotWidth := width - left - padding
+	bottom := top + plotHeight
+
+	maxValue := 0.0
+	for _, b := range bars {
+		maxValue = math.Max(maxValue, b.Value)
+	}
+	scaleMax, step := niceScale(maxValue, 4)
+
+	for v := 0.0; v <= scaleMax+step/2; v += step {
+		y := bottom - v/scaleMax*plotHeight
+		c.Line(left, y, left+plotWidth, y, ColorGrid)
+		c.Text(left-8, y+4, formatValue(v), 11, ColorMuted, AnchorEnd)
+	}
+
+	if len(bars) == 0 {
+		return c
+	}
+	barSlot := plotWidth / float64(len(bars))
+	barWidth := barSlot * 0.6
+	// 标签过密时间隔显示
+	labelEvery := int(math.Ceil(56 / barSlot))
+	for i, b := range bars {
+		x := left + barSlot*float64(i) + (barSlot-barWidth)/2
+		h := b.Value / scaleMax * plotHeight
+		c.RoundRect(x, bottom-h, barWidth, h, 2, ColorPrimary)
+		if b.Value > 0 && barSlot >= 28 {
+			c.Text(x+barWidth/2, bottom-h-4, formatValue(b.Value), 10, ColorText, AnchorMiddle)
+		}
+		if i%labelEvery == 0 {
+			c.Text(x+barWidth/2, bottom+18, b.Label, 11, ColorMuted, AnchorMiddle)
+		}
+	}
+	return c
+}
+
+// Point 折线图中的一个点
+type Point struct {
+	Label string
+	Value float64
+}
+
+// LineChart 折线图，用于成绩随时间的变化；纵轴从数据范围取整，而不是从 0 开始
+func LineChart(points []Point, opts Options) *Canvas {
+	const (
+		plotHeight = 240.0
+		axisWidth  = 48.0
+		labelSpace = 28.0
+		slot       = 44.0
+		dot        = 6.0
+	)
+	width := math.Max(640, 2*padding+axisWidth+slot*float64(len(points)))
+	c := frame(width, plotHeight+labelSpace+padding/2, opts)
+
+	top := titleHeight + padding/2
+	left := padding + axisWidth
+	plotWidth := width - left - padding
+	bottom := top + plotHeight
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minValue = math.Min(minValue, p.Value)
+		maxValue = math.Max(maxValue, p.Value)
+	}
+	if len(points) == 0 {
+		minValue, maxValue = 0, 0
+	}
+	_, step := niceScale(math.Max(maxValue-minValue, math.Abs(maxValue)/10), 4)
+	low := step * math.Floor(minValue/step)
+	high := step * math.Ceil(maxValue/step)
+	if high <= low {
+		high = low + step
+	}
+	y := func(v float64) float64 {
+		return bottom - (v-low)/(high-low)*plotHeight
+	}
+
+	for v := low; v <= high+step/2; v += step {
+		c.Line(left, y(v), left+plotWidth, y(v), ColorGrid)
+		c.Text(left-8, y(v)+4, formatValue(v), 11, ColorMuted, AnchorEnd)
+	}
+
+	if len(points) == 0 {
+		return c
+	}
+	pointSlot := plotWidth / float64(len(points))
+	x := func(i int) float64 {
+		return left + pointSlot*(float64(i)+0.5)
+	}
+	// 标签过密时间隔显示
+	labelEvery := int(math.Ceil(56 / pointSlot))
+	for i, p := range points {
+		if i > 0 {
+			c.Line(x(i-1), y(points[i-1].Value), x(i), y(p.Value), ColorPrimary)
+		}
+		if i%labelEvery == 0 {
+			c.Text(x(i), bottom+18, p.Label, 11, ColorMuted, AnchorMiddle)
+		}
+	}
+	for i, p := range points {
+		c.RoundRect(x(i)-dot/2, y(p.Value)-dot/2, dot, dot, dot/2, ColorPrimary)
+		if pointSlot >= 28 {
+			c.Text(x(i), y(p.Value)-8, formatValue(p.Value), 10, ColorText, AnchorMiddle)
+		}
+	}
+	return c
+}
+
+// PyramidBar 难度金字塔中的一层
+type PyramidBar struct {
+	Label string
+	Value float64 // 实际完成次数
+	Ideal float64 // 理想完成次数，0 表示不显示
+}
+
+// Pyramid 居中的难度金字塔，levels 从最高难度往下排列
+func Pyramid(levels []PyramidBar, opts Options) *Canvas {
+	const (
+		width      = 640.0
+		rowHeight  = 28.0
+		labelWidth = 56.0
+	)
+	c := frame(width, rowHeight*float64(len(levels))+padding, opts)
+
+	maxValue := 0.0
+	for _, l := range levels {
+		maxValue = math.Max(maxValue, math.Max(l.Value, l.Ideal))
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	left := padding + labelWidth
+	plotWidth := width - left - padding - labelWidth
+	center := left + plotWidth/2
+	for i, l := range levels {
+		y := titleHeight + padding/2 + rowHeight*float64(i)
+		if l.Ideal > 0 {
+			w := l.Ideal / maxValue * plotWidth
+			c.RoundRect(center-w/2, y+4, w, rowHeight-8, 3, ColorSecondary)
+		}
+		w := l.Value / maxValue * plotWidth
+		c.RoundRect(center-w/2, y+4, w, rowHeight-8, 3, ColorPrimary)
+
+		c.Text(left-8, y+rowHeight/2+4, l.Label, 12, ColorText, AnchorEnd)
+		value := formatValue(l.Value)
+		if l.Ideal > 0 {
+			value += "/" + formatValue(l.Ideal)
+		}
+		c.Text(width-padding, y+rowHeight/2+4, value, 11, ColorMuted, AnchorEnd)
+	}
+	return c
+}
+
+// HeatCell 热力图中的一天
+type HeatCell struct {
+	Date  time.Time
+	Level int // 0-4
+}
+
+// Heatmap 日历热力图，每列一周，每行一个星期几，cells 需按日期升序排列
+func Heatmap(cells []HeatCell, weekStart time.Weekday, opts Options) *Canvas {
+	const (
+		cell       = 12.0
+		gap        = 2.0
+		monthSpace = 18.0
+	)
+	// 第一天在首列中的行号
+	offset := 0
+	if len(cells) > 0 {
+		offset = (int(cells[0].Date.Weekday()) - int(weekStart) + 7) % 7
+	}
+	weeks := (offset + len(cells) + 6) / 7
+	width := math.Max(320, 2*padding+float64(weeks)*(cell+gap))
+	c := frame(width, monthSpace+7*(cell+gap)+padding, opts)
+
+	top := titleHeight + monthSpace
+	if len(cells) == 0 {
+		return c
+	}
+	lastMonth := time.Month(0)
+	for i, day := range cells {
+		index := offset + i
+		col, row := index/7, index%7
+		x := padding + float64(col)*(cell+gap)
+		y := top + float64(row)*(cell+gap)
+
+		level := day.Level
+		if level < 0 {
+			level = 0
+		} else if level >= len(HeatmapColors) {
+			level = len(HeatmapColors) - 1
+		}
+		c.RoundRect(x, y, cell, cell, 2, HeatmapColors[level])
+
+		// 每月第一天所在列标注月份
+		if month := day.Date.Month(); month != lastMonth {
+			c.Text(x, top-6, strconv.Itoa(int(month)), 10, ColorMuted, AnchorStart)
+			lastMonth = month
+		}
+	}
+	return c
+}
+
+// Badge 成就徽章卡片内容
+type Badge struct {
+	Mark        string // 徽章中心的图标或简短文字
+	Title       string
+	Description string
+	Status      string // 如解锁日期或当前进度
+	Progress    float64
+	Completed   bool
+}
+
+// BadgeCard 成就徽章卡片
+func BadgeCard(b Badge, opts Options) *Canvas {
+	const (
+		width  = 480.0
+		medal  = 96.0
+		height = 136.0
+	)
+	c := frame(width, height, opts)
+	top := titleHeight + padding/2
+
+	medalColor := ColorSecondary
+	if b.Completed {
+		medalColor = ColorPrimary
+	}
+	c.RoundRect(padding, top, medal, medal, medal/2, medalColor)
+	c.Text(padding+medal/2, top+medal/2+12, b.Mark, 32, ColorBackground, AnchorMiddle)
+
+	left := padding*2 + medal
+	c.Text(left, top+24, b.Title, 20, ColorText, AnchorStart)
+	c.Text(left, top+48, b.Description, 12, ColorMuted, AnchorStart)
+
+	barWidth := width - left - padding
+	progress := math.Max(0, math.Min(100, b.Progress))
+	c.RoundRect(left, top+64, barWidth, 8, 4, HeatmapColors[0])
+	c.RoundRect(left, top+64, barWidth*progress/100, 8, 4, ColorPrimary)
+	c.Text(left, top+92, b.Status, 12, ColorText, AnchorStart)
+	return c
+}
+
+// niceScale 取整后的坐标轴最大值和刻度间隔
+func niceScale(maxValue float64, ticks int) (float64, float64) {
+	if maxValue <= 0 {
+		return float64(ticks), 1
+	}
+	raw := maxValue / float64(ticks)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if raw <= m*magnitude {
+			step = m * magnitude
+			break
+		}
+	}
+	return step * math.Ceil(maxValue/step), step
+}
+
+// formatValue 数值标签，整数不带小数位
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
@@ -0,0 +1,101 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 位图字体基准字号，更大的字号按整数倍放大
+const pngBaseFontSize = 13
+
+// PNG 输出为 PNG 图片
+//
+// 文本使用内置的 7x13 位图字体，只能显示 ASCII 和拉丁字符，其他字符会被跳过；
+// 需要中文标签时应使用 SVG 输出。
+func (c *Canvas) PNG() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	for _, s := range c.shapes {
+		switch s.kind {
+		case shapeRect:
+			r := image.Rect(round(s.x), round(s.y), round(s.x+s.w), round(s.y+s.h))
+			draw.Draw(img, r, image.NewUniform(s.color), image.Point{}, draw.Src)
+		case shapeLine:
+			drawLine(img, s.x, s.y, s.w, s.h, s.color)
+		case shapeText:
+			drawText(img, s)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func round(v float64) int {
+	return int(math.Round(v))
+}
+
+// drawLine 逐点绘制直线
+func drawLine(img *image.RGBA, x1, y1, x2, y2 float64, c color.RGBA) {
+	steps := math.Max(math.Abs(x2-x1), math.Abs(y2-y1))
+	if steps == 0 {
+		img.SetRGBA(round(x1), round(y1), c)
+		return
+	}
+	for i := 0.0; i <= steps; i++ {
+		t := i / steps
+		img.SetRGBA(round(x1+(x2-x1)*t), round(y1+(y2-y1)*t), c)
+	}
+}
+
+// drawText 先按基准字号绘制到临时图层，再按整数倍放大合成
+func drawText(img *image.RGBA, s shape) {
+	face := basicfont.Face7x13
+	scale := s.size / pngBaseFontSize
+	if s.size%pngBaseFontSize*2 >= pngBaseFontSize {
+		scale++
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	width := font.MeasureString(face, s.text).Ceil()
+	height := face.Height
+	if width == 0 {
+		return
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(s.text)
+
+	x := round(s.x)
+	switch s.anchor {
+	case AnchorMiddle:
+		x -= width * scale / 2
+	case AnchorEnd:
+		x -= width * scale
+	}
+	y := round(s.y) - face.Ascent*scale
+
+	src := image.NewUniform(s.color)
+	for my := 0; my < height; my++ {
+		for mx := 0; mx < width; mx++ {
+			alpha := mask.AlphaAt(mx, my)
+			if alpha.A == 0 {
+				continue
+			}
+			r := image.Rect(x+mx*scale, y+my*scale, x+(mx+1)*scale, y+(my+1)*scale)
+			draw.DrawMask(img, r, src, image.Point{}, image.NewUniform(alpha), image.Point{}, draw.Over)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// 文本字体，由查看端按顺序选择可用字体
+const svgFontFamily = "-apple-system, 'PingFang SC', 'Microsoft YaHei', 'Noto Sans CJK SC', sans-serif"
+
+// SVG 输出为 SVG 文档
+func (c *Canvas) SVG() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s">`,
+		c.Width, c.Height, c.Width, c.Height, svgFontFamily)
+	buf.WriteByte('\n')
+
+	for _, s := range c.shapes {
+		switch s.kind {
+		case shapeRect:
+			fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s"`, coord(s.x), coord(s.y), coord(s.w), coord(s.h))
+			if s.radius > 0 {
+				fmt.Fprintf(&buf, ` rx="%s"`, coord(s.radius))
+			}
+			fmt.Fprintf(&buf, ` fill="%s"/>`, hexColor(s.color))
+		case shapeLine:
+			fmt.Fprintf(&buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="1"/>`,
+				coord(s.x), coord(s.y), coord(s.w), coord(s.h), hexColor(s.color))
+		case shapeText:
+			fmt.Fprintf(&buf, `<text x="%s" y="%s" font-size="%d" fill="%s" text-anchor="%s">`,
+				coord(s.x), coord(s.y), s.size, hexColor(s.color), s.anchor)
+			xml.EscapeText(&buf, []byte(s.text))
+			buf.WriteString("</text>")
+		}
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
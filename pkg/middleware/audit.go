@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditBodyLimit 审计日志保存的请求体最大字节数
+const auditBodyLimit = 4096
+
+// AuditEntry 一次请求的审计信息
+type AuditEntry struct {
+	ActorID uint
+	Method  string
+	Route   string            // 路由模板，如 /api/admin/users/:id
+	Path    string            // 实际请求路径
+	Params  map[string]string // 路径参数
+	Status  int
+	IP      string
+	Body    string // 修改类请求的请求体，超出 auditBodyLimit 的部分被截断
+}
+
+// AuditRecorder 保存审计信息
+type AuditRecorder func(entry AuditEntry) error
+
+// AuditMiddleware 在请求处理完成后记录审计信息 (含被拒绝的请求)，需在 AuthMiddleware 之后使用
+//
+// 保存失败只记录日志，不影响请求本身。
+func AuditMiddleware(record AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body string
+		if c.Request.Method != http.MethodGet && c.Request.Body != nil {
+			raw, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+			if len(raw) > auditBodyLimit {
+				raw = raw[:auditBodyLimit]
+			}
+			body = string(raw)
+		}
+
+		c.Next()
+
+		entry := AuditEntry{
+			ActorID: c.GetUint("userID"),
+			Method:  c.Request.Method,
+			Route:   c.FullPath(),
+			Path:    c.Request.URL.Path,
+			Params:  make(map[string]string, len(c.Params)),
+			Status:  c.Writer.Status(),
+			IP:      c.ClientIP(),
+			Body:    body,
+		}
+		for _, param := range c.Params {
+			entry.Params[param.Key] = param.Value
+		}
+		if err := record(entry); err != nil {
+			log.Printf("Failed to record audit log for %s %s: %v", entry.Method, entry.Path, err)
+		}
+	}
+}
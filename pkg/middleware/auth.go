@@ -28,21 +28,87 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token := parts[1]
+		authenticate(c, parts[1], "")
+	}
+}
+
+// StreamAuthMiddleware 长连接 (SSE) 认证中间件
+//
+// 浏览器的 EventSource 不能设置请求头，未提供 Authorization 时允许使用 access_token 查询参数。
+// 查询参数会写入访问日志，只接受 /auth/stream-token 签发的短期长连接令牌，不接受登录令牌。
+func StreamAuthMiddleware() gin.HandlerFunc {
+	header := AuthMiddleware()
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if c.GetHeader("Authorization") != "" || token == "" {
+			header(c)
+			return
+		}
+		authenticate(c, token, utils.StreamTokenPurpose)
+	}
+}
+
+// authenticate 验证用途为 purpose 的令牌并将用户信息存储到上下文中
+func authenticate(c *gin.Context, token, purpose string) {
+	// 验证JWT令牌
+	claims, err := utils.ValidateJWT(token)
+	if err != nil || claims.Purpose != purpose {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
+		c.Abort()
+		return
+	}
+
+	// 将用户信息存储到上下文中
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+
+	c.Next()
+}
+
+// AccessResolver 根据用户ID读取当前的角色和权限
+type AccessResolver func(userID uint) (roles, permissions []string, err error)
 
-		// 验证JWT令牌
-		claims, err := utils.ValidateJWT(token)
+// AccessMiddleware 将当前用户的角色和权限存入上下文，需在 AuthMiddleware 之后使用
+//
+// 令牌中的角色和权限只是签发时的快照，鉴权时每次请求从数据库读取，撤销角色后立即生效。
+func AccessMiddleware(resolve AccessResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		// 读取失败时拒绝请求，不能退化为默认权限
+		roles, permissions, err := resolve(userID.(uint))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "读取用户权限失败"})
 			c.Abort()
 			return
 		}
 
-		// 将用户信息存储到上下文中
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
+		c.Set("roles", roles)
+		c.Set("permissions", permissions)
+		c.Next()
+	}
+}
 
+// RequirePermission 要求当前用户拥有权限 permission，需在 AccessMiddleware 之后使用
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// HasPermission 当前用户是否拥有权限 permission
+func HasPermission(c *gin.Context, permission string) bool {
+	granted, _ := c.Get("permissions")
+	permissions, _ := granted.([]string)
+	return utils.HasPermission(permissions, permission)
+}
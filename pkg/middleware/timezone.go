@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"movePoint/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeSettingsResolver 根据用户ID读取时区和每周起始日设置
+type TimeSettingsResolver func(userID uint) (utils.TimeSettings, error)
+
+// TimeSettingsMiddleware 将当前用户的时间设置存入上下文，需在 AuthMiddleware 之后使用
+//
+// 查询参数 tz (IANA 时区名) 和 week_start (0 = 周日) 可临时覆盖用户设置。
+func TimeSettingsMiddleware(resolve TimeSettingsResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := utils.DefaultTimeSettings()
+		if userID, exists := c.Get("userID"); exists {
+			// 读取失败时使用默认设置，不影响请求本身
+			if resolved, err := resolve(userID.(uint)); err == nil {
+				settings = resolved
+			}
+		}
+
+		if tz := c.Query("tz"); tz != "" {
+			loc, err := utils.LoadLocation(tz)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区"})
+				c.Abort()
+				return
+			}
+			settings.Location = loc
+		}
+
+		if ws := c.Query("week_start"); ws != "" {
+			weekStart, err := strconv.Atoi(ws)
+			if err != nil || weekStart < 0 || weekStart > 6 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的每周起始日"})
+				c.Abort()
+				return
+			}
+			settings.WeekStart = time.Weekday(weekStart)
+		}
+
+		c.Set("timeSettings", settings)
+		c.Next()
+	}
+}
@@ -13,25 +13,55 @@ type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Purpose  string `json:"purpose,omitempty"` // 为空表示普通登录令牌
+
+	// 签发时的角色和权限，供客户端展示；服务端鉴权每次请求从数据库读取，撤销后立即生效
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT 生成JWT令牌
-func GenerateJWT(userID uint, username, email string) (string, error) {
-	// 从环境变量获取JWT密钥
+// StreamTokenPurpose 长连接令牌的用途
+//
+// 浏览器的 EventSource 只能通过查询参数传递令牌，而查询参数会写入访问日志，
+// 因此查询参数只接受有效期很短、只能用于建立长连接的令牌。
+const StreamTokenPurpose = "stream"
+
+// StreamTokenTTL 长连接令牌的有效期，只在建立连接时验证
+const StreamTokenTTL = time.Minute
+
+// secretKey 从环境变量获取JWT密钥
+func secretKey() string {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
 		jwtSecret = "your-default-secret-key" // 生产环境不应使用默认值
 	}
+	return jwtSecret
+}
+
+// GenerateJWT 生成JWT令牌，携带签发时的角色和权限
+func GenerateJWT(userID uint, username, email string, roles, permissions []string) (string, error) {
+	claims := newClaims(userID, username, email, "", 24*time.Hour) // 24小时后过期
+	claims.Roles = roles
+	claims.Permissions = permissions
+	return signJWT(claims)
+}
+
+// GenerateStreamToken 生成只能用于建立长连接的短期令牌
+func GenerateStreamToken(userID uint, username, email string) (string, error) {
+	return signJWT(newClaims(userID, username, email, StreamTokenPurpose, StreamTokenTTL))
+}
 
+func newClaims(userID uint, username, email, purpose string, ttl time.Duration) *JWTClaims {
 	// 设置令牌过期时间
-	expirationTime := time.Now().Add(24 * time.Hour) // 24小时后过期
+	expirationTime := time.Now().Add(ttl)
 
 	// 创建声明
-	claims := &JWTClaims{
+	return &JWTClaims{
 		UserID:   userID,
 		Username: username,
 		Email:    email,
+		Purpose:  purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -39,6 +69,10 @@ func GenerateJWT(userID uint, username, email string) (string, error) {
 			Issuer:    "climbing-app",
 		},
 	}
+}
+
+func signJWT(claims *JWTClaims) (string, error) {
+	jwtSecret := secretKey()
 
 	// 创建令牌
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -54,11 +88,7 @@ func GenerateJWT(userID uint, username, email string) (string, error) {
 
 // ValidateJWT 验证JWT令牌
 func ValidateJWT(tokenString string) (*JWTClaims, error) {
-	// 从环境变量获取JWT密钥
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-default-secret-key" // 生产环境不应使用默认值
-	}
+	jwtSecret := secretKey()
 
 	// 解析令牌
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
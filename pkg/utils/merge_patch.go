@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidMergePatch 合并补丁不是 JSON 对象
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// MergePatch 按 RFC 7396 (JSON Merge Patch) 将 patch 应用到 target
+//
+// patch 中值为 null 的键从结果中删除，对象递归合并，其余值 (含数组) 整体替换。
+func MergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if object, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[key].(map[string]interface{})
+			target[key] = MergePatch(existing, object)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
+
+// ParseMergePatch 解析合并补丁，补丁必须是 JSON 对象
+func ParseMergePatch(data []byte) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil || patch == nil {
+		return nil, ErrInvalidMergePatch
+	}
+	return patch, nil
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// 用例取自 RFC 7396 附录 A 中补丁为对象的部分
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replace value", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add value", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null deletes key", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null keeps other keys", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"null on missing key", `{"a":"b"}`, `{"c":null}`, `{"a":"b"}`},
+		{"array replaces value", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"arrays are not merged", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"nested null deletes", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":null}}`, `{"a":{"d":"e"}}`},
+		{"object replaces scalar", `{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{"null inside new object is dropped", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+		{"nil target", `null`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target, patch, want map[string]interface{}
+			mustUnmarshal(t, tt.target, &target)
+			mustUnmarshal(t, tt.patch, &patch)
+			mustUnmarshal(t, tt.want, &want)
+
+			if got := MergePatch(target, patch); !reflect.DeepEqual(got, want) {
+				t.Errorf("MergePatch(%s, %s) = %v, want %v", tt.target, tt.patch, got, want)
+			}
+		})
+	}
+}
+
+func TestParseMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"object", `{"a":1}`, false},
+		{"empty object", `{}`, false},
+		{"null", `null`, true},
+		{"array", `[{"a":1}]`, true},
+		{"string", `"a"`, true},
+		{"number", `1`, true},
+		{"invalid json", `{"a":`, true},
+		{"empty", ``, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseMergePatch([]byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMergePatch) {
+					t.Errorf("ParseMergePatch(%q) error = %v, want ErrInvalidMergePatch", tt.data, err)
+				}
+				return
+			}
+			if err != nil || patch == nil {
+				t.Errorf("ParseMergePatch(%q) = %v, %v", tt.data, patch, err)
+			}
+		})
+	}
+}
+
+func mustUnmarshal(t *testing.T, data string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "strings"
+
+// HasPermission granted 中是否有权限覆盖 required
+//
+// 权限按冒号分段 (如 records:read:any)，* 匹配单个分段，末尾的 * 匹配其后全部分段。
+func HasPermission(granted []string, required string) bool {
+	for _, permission := range granted {
+		if permissionMatches(permission, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionMatches(pattern, required string) bool {
+	want := strings.Split(required, ":")
+	parts := strings.Split(pattern, ":")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			return true
+		}
+		if i >= len(want) || (part != "*" && part != want[i]) {
+			return false
+		}
+	}
+	return len(parts) == len(want)
+}
@@ -0,0 +1,40 @@
+package utils
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact match", []string{"records:read:own"}, "records:read:own", true},
+		{"own does not cover any", []string{"records:read:own"}, "records:read:any", false},
+		{"any does not cover own", []string{"records:read:any"}, "records:read:own", false},
+		{"different action", []string{"records:read:own"}, "records:write:own", false},
+		{"global wildcard", []string{"*"}, "records:read:any", true},
+		{"global wildcard single segment", []string{"*"}, "audit", true},
+		{"resource wildcard", []string{"records:*"}, "records:read:any", true},
+		{"resource wildcard two segments", []string{"records:*"}, "records:read", true},
+		{"resource wildcard other resource", []string{"records:*"}, "roles:read", false},
+		{"trailing wildcard matches no segments", []string{"records:*"}, "records", true},
+		{"middle wildcard", []string{"records:*:own"}, "records:write:own", true},
+		{"middle wildcard keeps scope", []string{"records:*:own"}, "records:write:any", false},
+		{"middle wildcard length mismatch", []string{"records:*:own"}, "records:write", false},
+		{"shorter pattern", []string{"records:read"}, "records:read:own", false},
+		{"longer pattern", []string{"records:read:own"}, "records:read", false},
+		{"prefix is not a segment", []string{"record:*"}, "records:read", false},
+		{"any of several", []string{"roles:read", "records:write:own"}, "records:write:own", true},
+		{"no permissions", nil, "records:read:own", false},
+		{"empty permission", []string{""}, "records:read:own", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
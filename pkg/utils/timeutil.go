@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// 用户时间设置默认值
+const (
+	DefaultTimezone  = "UTC"
+	DefaultWeekStart = time.Monday
+	DefaultLocale    = "zh-CN"
+)
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// TimeSettings 用户的时区、每周起始日和语言区域设置
+//
+// 所有按天/周/月的统计都应在 Location 时区内划分。
+type TimeSettings struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+	Locale    string
+}
+
+// DefaultTimeSettings 未设置时使用 UTC、周一为每周第一天
+func DefaultTimeSettings() TimeSettings {
+	return TimeSettings{Location: time.UTC, WeekStart: DefaultWeekStart, Locale: DefaultLocale}
+}
+
+// NewTimeSettings 根据用户保存的设置构造，无效值回退到默认值
+func NewTimeSettings(timezone string, weekStart int, locale string) TimeSettings {
+	settings := DefaultTimeSettings()
+	if loc, err := LoadLocation(timezone); err == nil {
+		settings.Location = loc
+	}
+	if weekStart >= 0 && weekStart <= 6 {
+		settings.WeekStart = time.Weekday(weekStart)
+	}
+	if ValidLocale(locale) {
+		settings.Locale = locale
+	}
+	return settings
+}
+
+// LoadLocation 加载 IANA 时区 (如 Asia/Shanghai)，不接受空字符串和 "Local"
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("无效的时区: %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// ValidLocale 检查是否为 BCP 47 形式的语言区域 (如 zh-CN、en-US)
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// StartOfDay 返回 t 在 loc 时区当天的零点
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOfWeek 返回 t 所在周 (以 weekStart 为起始日) 第一天的零点
+func StartOfWeek(t time.Time, loc *time.Location, weekStart time.Weekday) time.Time {
+	day := StartOfDay(t, loc)
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfMonth 返回 t 在 loc 时区当月第一天的零点
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// ParseDate 解析日期参数
+//
+// 支持 "2006-01-02" (按 loc 时区解释) 和带时区偏移的 RFC 3339 时间。
+// endOfDay 为 true 时，纯日期解析为当天最后一刻，便于作为闭区间的结束时间。
+func ParseDate(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+	}
+	return day, nil
+}
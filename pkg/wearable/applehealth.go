@@ -0,0 +1,266 @@
+package wearable
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	appleDateLayout = "2006-01-02 15:04:05 -0700"
+
+	appleClimbingType  = "HKWorkoutActivityTypeClimbing"
+	appleHeartRateType = "HKQuantityTypeIdentifierHeartRate"
+	appleEnergyType    = "HKQuantityTypeIdentifierActiveEnergyBurned"
+)
+
+// ErrAppleExportNotFound zip 包中没有 export.xml
+var ErrAppleExportNotFound = errors.New("export.xml not found in archive")
+
+// ParseAppleHealth 以流式方式解析 Apple 健康 export.xml
+//
+// 导出文件可能有数百 MB，其中绝大部分是与训练无关的心率 Record，且 Record 与 Workout
+// 在文件中的先后顺序不固定。因此分两遍读取: 第一遍只收集训练，第二遍只保留落在训练时间窗口内的心率。
+// 不支持 Seek 的输入先写入临时文件。
+func ParseAppleHealth(r io.Reader) ([]Workout, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		tmp, err := os.CreateTemp("", "apple-health-*.xml")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(tmp, r); err != nil {
+			return nil, err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		rs = tmp
+	}
+
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := appleWorkouts(rs)
+	if err != nil || len(workouts) == 0 {
+		return workouts, err
+	}
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := appleHeartRate(rs, workouts); err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+// ParseAppleHealthZip 解析 Apple 健康导出的 export.zip
+//
+// export.xml 在包内分两次打开读取，不需要解压到内存或磁盘。
+func ParseAppleHealthZip(r io.ReaderAt, size int64, opts ParseOptions) ([]Workout, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range archive.File {
+		if path.Base(f.Name) != "export.xml" {
+			continue
+		}
+		workouts, err := readZipFile(f, appleWorkouts)
+		if err != nil {
+			return nil, err
+		}
+		if len(workouts) > 0 {
+			if _, err := readZipFile(f, func(r io.Reader) ([]Workout, error) {
+				return nil, appleHeartRate(r, workouts)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return filterClimbing(workouts, opts), nil
+	}
+
+	return nil, ErrAppleExportNotFound
+}
+
+func readZipFile(f *zip.File, read func(io.Reader) ([]Workout, error)) ([]Workout, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return read(rc)
+}
+
+// appleWorkouts 第一遍: 收集开始时间有效的训练及其统计数据，跳过 Record
+func appleWorkouts(r io.Reader) ([]Workout, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+
+	var workouts []Workout
+	var current *Workout
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return workouts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "Workout":
+				current = appleWorkout(xmlAttrs(el))
+			case "WorkoutStatistics":
+				if current != nil {
+					applyAppleStatistics(current, xmlAttrs(el))
+				}
+			}
+		case xml.EndElement:
+			if el.Name.Local == "Workout" && current != nil {
+				if !current.StartTime.IsZero() {
+					workouts = append(workouts, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// appleHeartRate 第二遍: 将心率 Record 分配到所在时间窗口的训练，窗口外的直接丢弃
+func appleHeartRate(r io.Reader, workouts []Workout) error {
+	// 按开始时间排序的训练下标，maxEnd[i] 为前 i+1 个训练中最晚的结束时间，用于处理重叠的窗口
+	order := make([]int, len(workouts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return workouts[order[i]].StartTime.Before(workouts[order[j]].StartTime)
+	})
+	maxEnd := make([]time.Time, len(order))
+	for i, idx := range order {
+		maxEnd[i] = workouts[idx].EndTime
+		if i > 0 && maxEnd[i-1].After(maxEnd[i]) {
+			maxEnd[i] = maxEnd[i-1]
+		}
+	}
+
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		el, ok := tok.(xml.StartElement)
+		if !ok || el.Name.Local != "Record" {
+			continue
+		}
+		attrs := xmlAttrs(el)
+		if attrs["type"] != appleHeartRateType {
+			continue
+		}
+		t, err := time.Parse(appleDateLayout, attrs["startDate"])
+		if err != nil {
+			continue
+		}
+		hi := sort.Search(len(order), func(k int) bool {
+			return workouts[order[k]].StartTime.After(t)
+		})
+		if hi == 0 || maxEnd[hi-1].Before(t) {
+			continue
+		}
+		bpm, err := strconv.ParseFloat(attrs["value"], 64)
+		if err != nil || bpm <= 0 {
+			continue
+		}
+		sample := HeartRateSample{Time: t, BPM: int(bpm + 0.5)}
+		for k := hi - 1; k >= 0 && !maxEnd[k].Before(t); k-- {
+			if w := &workouts[order[k]]; !t.After(w.EndTime) {
+				w.HeartRate = append(w.HeartRate, sample)
+			}
+		}
+	}
+
+	for i := range workouts {
+		hr := workouts[i].HeartRate
+		sort.Slice(hr, func(a, b int) bool {
+			return hr[a].Time.Before(hr[b].Time)
+		})
+	}
+	return nil
+}
+
+func appleWorkout(attrs map[string]string) *Workout {
+	activityType := attrs["workoutActivityType"]
+	w := &Workout{
+		Format:   FormatAppleHealth,
+		Sport:    strings.TrimPrefix(activityType, "HKWorkoutActivityType"),
+		Climbing: activityType == appleClimbingType,
+	}
+
+	w.StartTime, _ = time.Parse(appleDateLayout, attrs["startDate"])
+	w.EndTime, _ = time.Parse(appleDateLayout, attrs["endDate"])
+	if !w.StartTime.IsZero() {
+		w.ExternalID = "apple-" + attrs["sourceName"] + "-" + strconv.FormatInt(w.StartTime.Unix(), 10)
+	}
+
+	// 旧版导出直接在 Workout 上给出总能量
+	if energy, err := strconv.ParseFloat(attrs["totalEnergyBurned"], 64); err == nil {
+		w.Calories = toKilocalories(energy, attrs["totalEnergyBurnedUnit"])
+	}
+	return w
+}
+
+func applyAppleStatistics(w *Workout, attrs map[string]string) {
+	switch attrs["type"] {
+	case appleHeartRateType:
+		if avg, err := strconv.ParseFloat(attrs["average"], 64); err == nil {
+			w.AvgHeartRate = int(avg + 0.5)
+		}
+		if maximum, err := strconv.ParseFloat(attrs["maximum"], 64); err == nil {
+			w.MaxHeartRate = int(maximum + 0.5)
+		}
+	case appleEnergyType:
+		if w.Calories > 0 {
+			return
+		}
+		if sum, err := strconv.ParseFloat(attrs["sum"], 64); err == nil {
+			w.Calories = toKilocalories(sum, attrs["unit"])
+		}
+	}
+}
+
+func toKilocalories(value float64, unit string) float64 {
+	if strings.EqualFold(unit, "kJ") {
+		return value / 4.184
+	}
+	return value
+}
+
+func xmlAttrs(el xml.StartElement) map[string]string {
+	attrs := make(map[string]string, len(el.Attr))
+	for _, a := range el.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+	return attrs
+}
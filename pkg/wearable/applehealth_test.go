@@ -0,0 +1,215 @@
+package wearable
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAppleHealth(t *testing.T) {
+	cst := time.FixedZone("", 8*3600)
+	start := time.Date(2026, 10, 18, 17, 0, 0, 0, cst)
+
+	workouts, err := ParseAppleHealth(bytes.NewReader(fixture(t, "export.xml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 开始时间无效的训练被跳过
+	if len(workouts) != 3 {
+		t.Fatalf("got %d workouts, want 3", len(workouts))
+	}
+
+	w := workouts[0]
+	if w.Sport != "Climbing" || !w.Climbing || w.ExternalID != "apple-Watch-1792314000" {
+		t.Errorf("workout = %+v", w)
+	}
+	if !w.StartTime.Equal(start) || !w.EndTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("time = %v ~ %v", w.StartTime, w.EndTime)
+	}
+	// WorkoutStatistics 中的热量以 kJ 给出，心率四舍五入
+	if math.Abs(w.Calories-300) > 0.01 || w.AvgHeartRate != 125 || w.MaxHeartRate != 171 {
+		t.Errorf("calories %.2f avg %d max %d", w.Calories, w.AvgHeartRate, w.MaxHeartRate)
+	}
+	// Record 在文件中的顺序不固定；窗口外、非心率和数值无效的记录被跳过
+	wantSamples := []HeartRateSample{
+		{Time: start.Add(10 * time.Minute), BPM: 118},
+		{Time: start.Add(20 * time.Minute), BPM: 132},
+		{Time: start.Add(50 * time.Minute), BPM: 150},
+	}
+	if !samplesEqual(w.HeartRate, wantSamples) {
+		t.Errorf("heart rate = %v, want %v", w.HeartRate, wantSamples)
+	}
+
+	// 旧版导出在 Workout 上给出总能量，优先于 WorkoutStatistics
+	old := workouts[1]
+	if old.Calories != 210 || old.ExternalID != "apple-Old Phone-1792234800" || len(old.HeartRate) != 0 {
+		t.Errorf("old workout = %+v", old)
+	}
+
+	if workouts[2].Climbing || workouts[2].Sport != "Running" {
+		t.Errorf("workouts[2] = %+v, want a non-climbing run", workouts[2])
+	}
+}
+
+func TestParseAppleHealthHeartRateWindows(t *testing.T) {
+	// 心率记录在训练之前出现，窗口重叠时同时分配给两次训练
+	const export = `<HealthData>
+		<Record type="HKQuantityTypeIdentifierHeartRate" startDate="2026-10-18 17:40:00 +0800" value="140"/>
+		<Record type="HKQuantityTypeIdentifierHeartRate" startDate="2026-10-18 17:10:00 +0800" value="120"/>
+		<Record type="HKQuantityTypeIdentifierHeartRate" startDate="2026-10-18 19:00:00 +0800" value="90"/>
+		<Workout workoutActivityType="HKWorkoutActivityTypeClimbing" sourceName="A" startDate="2026-10-18 17:00:00 +0800" endDate="2026-10-18 18:00:00 +0800"/>
+		<Workout workoutActivityType="HKWorkoutActivityTypeClimbing" sourceName="B" startDate="2026-10-18 17:30:00 +0800" endDate="2026-10-18 17:50:00 +0800"/>
+	</HealthData>`
+
+	tests := []struct {
+		name string
+		r    io.Reader
+	}{
+		{"seekable", strings.NewReader(export)},
+		// 不支持 Seek 的输入经临时文件读取两遍
+		{"non seekable", struct{ io.Reader }{strings.NewReader(export)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := ParseAppleHealth(tt.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(workouts) != 2 {
+				t.Fatalf("got %d workouts, want 2", len(workouts))
+			}
+			want := [][]int{{120, 140}, {140}}
+			for i, w := range workouts {
+				var bpm []int
+				for _, s := range w.HeartRate {
+					bpm = append(bpm, s.BPM)
+				}
+				if !reflect.DeepEqual(bpm, want[i]) {
+					t.Errorf("workouts[%d] heart rate = %v, want %v", i, bpm, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAppleHealthEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    int
+		wantErr bool
+	}{
+		{"empty export", `<HealthData/>`, 0, false},
+		{"unclosed workout", `<HealthData><Workout workoutActivityType="HKWorkoutActivityTypeClimbing" startDate="2026-10-18 17:00:00 +0800">`, 0, true},
+		{"statistics outside workout", `<HealthData><WorkoutStatistics type="HKQuantityTypeIdentifierHeartRate" average="120"/></HealthData>`, 0, false},
+		{"missing end date", `<HealthData><Workout workoutActivityType="HKWorkoutActivityTypeClimbing" startDate="2026-10-18 17:00:00 +0800"/></HealthData>`, 1, false},
+		{"non strict entities", `<HealthData><Workout workoutActivityType="HKWorkoutActivityTypeClimbing" sourceName="A &amp; B &nbsp;" startDate="2026-10-18 17:00:00 +0800"/></HealthData>`, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := ParseAppleHealth(strings.NewReader(tt.xml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAppleHealth error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(workouts) != tt.want {
+				t.Errorf("got %d workouts, want %d", len(workouts), tt.want)
+			}
+		})
+	}
+
+	// 缺少结束时间时 finalize 保证结束时间不早于开始时间
+	workouts, err := Parse(FormatAppleHealth, strings.NewReader(tests[3].xml), ParseOptions{})
+	if err != nil || len(workouts) != 1 || !workouts[0].EndTime.Equal(workouts[0].StartTime) {
+		t.Errorf("Parse = %+v, %v", workouts, err)
+	}
+}
+
+func TestParseAppleHealthZip(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		want    int
+		wantErr error
+	}{
+		{"export in folder", map[string][]byte{
+			"apple_health_export/export_cda.xml": []byte("<HealthData/>"),
+			"apple_health_export/export.xml":     fixture(t, "export.xml"),
+		}, 2, nil},
+		{"export at root", map[string][]byte{"export.xml": fixture(t, "export.xml")}, 2, nil},
+		{"missing export", map[string][]byte{"apple_health_export/export_cda.xml": []byte("<HealthData/>")}, 0, ErrAppleExportNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := zipArchive(t, tt.files)
+			workouts, err := ParseAppleHealthZip(bytes.NewReader(archive), int64(len(archive)), ParseOptions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseAppleHealthZip error = %v, want %v", err, tt.wantErr)
+			}
+			if len(workouts) != tt.want {
+				t.Errorf("got %d workouts, want %d", len(workouts), tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseAppleHealthZip(bytes.NewReader([]byte("not a zip")), 9, ParseOptions{}); err == nil {
+		t.Error("ParseAppleHealthZip accepted a non-zip file")
+	}
+}
+
+func TestToKilocalories(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  string
+		want  float64
+	}{
+		{418.4, "kJ", 100},
+		{418.4, "KJ", 100},
+		{100, "kcal", 100},
+		{100, "Cal", 100},
+		{100, "", 100},
+	}
+	for _, tt := range tests {
+		if got := toKilocalories(tt.value, tt.unit); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("toKilocalories(%v, %q) = %v, want %v", tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func samplesEqual(got, want []HeartRateSample) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !got[i].Time.Equal(want[i].Time) || got[i].BPM != want[i].BPM {
+			return false
+		}
+	}
+	return true
+}
+
+func zipArchive(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
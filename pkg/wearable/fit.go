@@ -0,0 +1,383 @@
+package wearable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FIT 协议相关常量，参见 Garmin FIT SDK Profile
+const (
+	fitEpochOffset = 631065600 // 1989-12-31T00:00:00Z 的 Unix 时间戳
+
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+
+	fitFieldTimestamp = 253
+
+	// session 消息字段
+	fitSessionStartTime        = 2
+	fitSessionSport            = 5
+	fitSessionSubSport         = 6
+	fitSessionTotalElapsedTime = 7
+	fitSessionTotalCalories    = 11
+	fitSessionAvgHeartRate     = 16
+	fitSessionMaxHeartRate     = 17
+
+	// record 消息字段
+	fitRecordHeartRate = 3
+
+	fitSportRockClimbing      = 31
+	fitSubSportIndoorClimbing = 68
+	fitSubSportBouldering     = 69
+)
+
+// ErrInvalidFIT FIT 文件格式错误
+var ErrInvalidFIT = errors.New("invalid FIT file")
+
+type fitFieldDef struct {
+	num      byte
+	size     int
+	baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitFieldDef
+	devSize   int
+}
+
+type fitSession struct {
+	start     uint32
+	timestamp uint32
+	elapsed   uint32 // 毫秒
+	sport     uint64
+	subSport  uint64
+	calories  uint64
+	avgHR     uint64
+	maxHR     uint64
+}
+
+type fitParser struct {
+	buf           []byte
+	pos           int
+	defs          map[byte]*fitDefinition
+	lastTimestamp uint32
+	sessions      []fitSession
+	samples       []HeartRateSample
+}
+
+// ParseFIT 解析 Garmin FIT 文件，每个 session 消息对应一次训练
+func ParseFIT(r io.Reader) ([]Workout, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 12 {
+		return nil, ErrInvalidFIT
+	}
+	headerSize := int(data[0])
+	if headerSize < 12 || len(data) < headerSize || string(data[8:12]) != ".FIT" {
+		return nil, ErrInvalidFIT
+	}
+	// 14 字节的文件头末尾是文件头 CRC，为 0 表示未计算
+	if headerSize >= 14 {
+		if crc := binary.LittleEndian.Uint16(data[12:14]); crc != 0 && crc != fitCRC(data[:12]) {
+			return nil, fmt.Errorf("%w: header CRC mismatch", ErrInvalidFIT)
+		}
+	}
+
+	// 数据区之后是 2 字节文件 CRC (覆盖文件头和数据区)，截断的文件没有 CRC，按实际长度解析
+	end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+	if end+2 <= len(data) {
+		if binary.LittleEndian.Uint16(data[end:end+2]) != fitCRC(data[:end]) {
+			return nil, fmt.Errorf("%w: file CRC mismatch", ErrInvalidFIT)
+		}
+	}
+	if end > len(data) {
+		end = len(data)
+	}
+
+	p := &fitParser{
+		buf:  data[headerSize:end],
+		defs: make(map[byte]*fitDefinition),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	return p.workouts(), nil
+}
+
+func (p *fitParser) parse() error {
+	for p.pos < len(p.buf) {
+		header := p.buf[p.pos]
+		p.pos++
+
+		// 压缩时间戳头: bit7=1, bit5-6 为本地消息类型, bit0-4 为时间偏移
+		if header&0x80 != 0 {
+			local := (header >> 5) & 0x03
+			offset := uint32(header & 0x1F)
+			timestamp := (p.lastTimestamp &^ 0x1F) + offset
+			if offset < p.lastTimestamp&0x1F {
+				timestamp += 0x20
+			}
+			p.lastTimestamp = timestamp
+
+			if err := p.readData(local, timestamp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		local := header & 0x0F
+		if header&0x40 != 0 {
+			if err := p.readDefinition(local, header&0x20 != 0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.readData(local, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *fitParser) take(n int) ([]byte, error) {
+	if n < 0 || p.pos+n > len(p.buf) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidFIT)
+	}
+	b := p.buf[p.pos : p.pos+n]
+	p.pos += n
+	return b, nil
+}
+
+func (p *fitParser) readDefinition(local byte, hasDevFields bool) error {
+	head, err := p.take(5)
+	if err != nil {
+		return err
+	}
+
+	def := &fitDefinition{bigEndian: head[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(head[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(head[2:4])
+	}
+
+	fields, err := p.take(int(head[4]) * 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitFieldDef{
+			num:      fields[i],
+			size:     int(fields[i+1]),
+			baseType: fields[i+2],
+		})
+	}
+
+	// 开发者字段只需跳过
+	if hasDevFields {
+		count, err := p.take(1)
+		if err != nil {
+			return err
+		}
+		devFields, err := p.take(int(count[0]) * 3)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devSize += int(devFields[i+1])
+		}
+	}
+
+	p.defs[local] = def
+	return nil
+}
+
+func (p *fitParser) readData(local byte, compressedTimestamp uint32) error {
+	def, ok := p.defs[local]
+	if !ok {
+		return fmt.Errorf("%w: data message without definition", ErrInvalidFIT)
+	}
+
+	values := make(map[byte]uint64, len(def.fields))
+	for _, field := range def.fields {
+		raw, err := p.take(field.size)
+		if err != nil {
+			return err
+		}
+		if v, ok := fitValue(raw, field.baseType, def.bigEndian); ok {
+			values[field.num] = v
+		}
+	}
+	if _, err := p.take(def.devSize); err != nil {
+		return err
+	}
+
+	timestamp := compressedTimestamp
+	if v, ok := values[fitFieldTimestamp]; ok {
+		timestamp = uint32(v)
+		p.lastTimestamp = timestamp
+	}
+
+	switch def.global {
+	case fitMesgSession:
+		p.sessions = append(p.sessions, fitSession{
+			start:     uint32(values[fitSessionStartTime]),
+			timestamp: timestamp,
+			elapsed:   uint32(values[fitSessionTotalElapsedTime]),
+			sport:     values[fitSessionSport],
+			subSport:  values[fitSessionSubSport],
+			calories:  values[fitSessionTotalCalories],
+			avgHR:     values[fitSessionAvgHeartRate],
+			maxHR:     values[fitSessionMaxHeartRate],
+		})
+	case fitMesgRecord:
+		if hr, ok := values[fitRecordHeartRate]; ok && timestamp != 0 {
+			p.samples = append(p.samples, HeartRateSample{
+				Time: fitTime(timestamp),
+				BPM:  int(hr),
+			})
+		}
+	}
+	return nil
+}
+
+func (p *fitParser) workouts() []Workout {
+	var workouts []Workout
+	for _, s := range p.sessions {
+		startTS := s.start
+		if startTS == 0 {
+			// 缺少 start_time 时 session 的 timestamp 为结束时间，按总时长倒推开始时间
+			startTS = s.timestamp - min(s.timestamp, s.elapsed/1000)
+		}
+		start := fitTime(startTS)
+		end := fitTime(s.timestamp)
+		if s.elapsed > 0 {
+			end = start.Add(time.Duration(s.elapsed) * time.Millisecond)
+		}
+
+		w := Workout{
+			Format:       FormatFIT,
+			ExternalID:   fmt.Sprintf("fit-%d", startTS),
+			Sport:        fitSportName(s.sport, s.subSport),
+			Climbing:     s.sport == fitSportRockClimbing,
+			Bouldering:   s.subSport == fitSubSportBouldering,
+			StartTime:    start,
+			EndTime:      end,
+			AvgHeartRate: int(s.avgHR),
+			MaxHeartRate: int(s.maxHR),
+			Calories:     float64(s.calories),
+		}
+		w.HeartRate = samplesWithin(p.samples, w.StartTime, w.EndTime)
+		workouts = append(workouts, w)
+	}
+	return workouts
+}
+
+// fitCRCTable FIT SDK 中按半字节计算 CRC-16 的查找表
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC 计算 FIT 文件使用的 CRC-16
+func fitCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc ^= tmp ^ fitCRCTable[b&0xF]
+
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc ^= tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+func fitTime(ts uint32) time.Time {
+	return time.Unix(int64(ts)+fitEpochOffset, 0).UTC()
+}
+
+func fitSportName(sport, subSport uint64) string {
+	if sport != fitSportRockClimbing {
+		return fmt.Sprintf("fit_sport_%d", sport)
+	}
+	switch subSport {
+	case fitSubSportBouldering:
+		return "bouldering"
+	case fitSubSportIndoorClimbing:
+		return "indoor_climbing"
+	}
+	return "rock_climbing"
+}
+
+// fitValue 按基础类型解码字段值，无效值返回 false
+func fitValue(raw []byte, baseType byte, bigEndian bool) (uint64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var v, invalid uint64
+	switch baseType & 0x1F {
+	case 0x00, 0x02, 0x0D: // enum, uint8, byte
+		if len(raw) != 1 {
+			return 0, false
+		}
+		v, invalid = uint64(raw[0]), 0xFF
+	case 0x01: // sint8
+		if len(raw) != 1 {
+			return 0, false
+		}
+		v, invalid = uint64(raw[0]), 0x7F
+	case 0x0A: // uint8z
+		if len(raw) != 1 {
+			return 0, false
+		}
+		v, invalid = uint64(raw[0]), 0
+	case 0x03: // sint16
+		if len(raw) != 2 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint16(raw)), 0x7FFF
+	case 0x04: // uint16
+		if len(raw) != 2 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint16(raw)), 0xFFFF
+	case 0x0B: // uint16z
+		if len(raw) != 2 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint16(raw)), 0
+	case 0x05: // sint32
+		if len(raw) != 4 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint32(raw)), 0x7FFFFFFF
+	case 0x06: // uint32
+		if len(raw) != 4 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint32(raw)), 0xFFFFFFFF
+	case 0x0C: // uint32z
+		if len(raw) != 4 {
+			return 0, false
+		}
+		v, invalid = uint64(order.Uint32(raw)), 0
+	default:
+		return 0, false
+	}
+
+	return v, v != invalid
+}
@@ -0,0 +1,294 @@
+package wearable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixture 读取 testdata 中的样例文件
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// withFileCRC 重新计算修改后的 FIT 文件的文件 CRC
+func withFileCRC(data []byte) []byte {
+	out := append([]byte(nil), data...)
+	end := len(out) - 2
+	binary.LittleEndian.PutUint16(out[end:], fitCRC(out[:end]))
+	return out
+}
+
+func TestFitCRC(t *testing.T) {
+	// CRC-16/ARC 的标准校验值
+	if got := fitCRC([]byte("123456789")); got != 0xBB3D {
+		t.Errorf("fitCRC = %#04x, want 0xbb3d", got)
+	}
+	if got := fitCRC(nil); got != 0 {
+		t.Errorf("fitCRC(nil) = %#04x, want 0", got)
+	}
+}
+
+func TestParseFIT(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	workouts, err := ParseFIT(bytes.NewReader(fixture(t, "climbing.fit")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 2 {
+		t.Fatalf("got %d workouts, want 2", len(workouts))
+	}
+
+	want := Workout{
+		Format:       FormatFIT,
+		ExternalID:   "fit-1161248400",
+		Sport:        "bouldering",
+		Climbing:     true,
+		Bouldering:   true,
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+		AvgHeartRate: 130,
+		MaxHeartRate: 160,
+		Calories:     350,
+		HeartRate: []HeartRateSample{
+			{Time: start, BPM: 110},
+			// 0xFF 为无效心率，该记录被跳过
+			{Time: start.Add(60 * time.Second), BPM: 140},
+			// 压缩时间戳相对上一条记录回绕
+			{Time: start.Add(90 * time.Second), BPM: 150},
+		},
+	}
+	if !reflect.DeepEqual(workouts[0], want) {
+		t.Errorf("workouts[0] = %+v\nwant %+v", workouts[0], want)
+	}
+
+	running := workouts[1]
+	if running.Climbing || running.Sport != "fit_sport_1" || len(running.HeartRate) != 0 {
+		t.Errorf("workouts[1] = %+v, want a non-climbing session without samples", running)
+	}
+}
+
+func TestParseFITDeveloperFields(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	workouts, err := Parse(FormatFIT, bytes.NewReader(fixture(t, "developer_fields.fit")), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 1 {
+		t.Fatalf("got %d workouts, want 1", len(workouts))
+	}
+
+	w := workouts[0]
+	// 大端序的 session，无效的热量和心率值被忽略，心率由采样补全
+	want := Workout{
+		Format:       FormatFIT,
+		ExternalID:   "fit-1161248400",
+		Sport:        "indoor_climbing",
+		Climbing:     true,
+		StartTime:    start,
+		EndTime:      start.Add(20 * time.Minute),
+		AvgHeartRate: 120,
+		MaxHeartRate: 120,
+		HeartRate:    []HeartRateSample{{Time: start.Add(10 * time.Second), BPM: 120}},
+	}
+	if !reflect.DeepEqual(w, want) {
+		t.Errorf("workout = %+v\nwant %+v", w, want)
+	}
+}
+
+func TestParseFITWithoutStartTime(t *testing.T) {
+	// session 定义: timestamp、total_elapsed_time、sport，没有 start_time
+	body := []byte{0x40, 0, 0, fitMesgSession, 0, 3,
+		fitFieldTimestamp, 4, 0x86,
+		fitSessionTotalElapsedTime, 4, 0x86,
+		fitSessionSport, 1, 0x00,
+	}
+	session := func(timestamp, elapsed uint32) []byte {
+		data := []byte{0x00}
+		data = binary.LittleEndian.AppendUint32(data, timestamp)
+		data = binary.LittleEndian.AppendUint32(data, elapsed)
+		return append(data, fitSportRockClimbing)
+	}
+	body = append(body, session(1161252000, 3600000)...)
+	body = append(body, session(1161259200, 1800000)...)
+
+	workouts, err := ParseFIT(bytes.NewReader(fitFile(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 2 {
+		t.Fatalf("got %d workouts, want 2", len(workouts))
+	}
+
+	// 开始时间由结束时间和总时长倒推，外部标识使用推算出的开始时间，两次训练不会重复
+	tests := []struct {
+		id    string
+		start time.Time
+		end   time.Time
+	}{
+		{"fit-1161248400", time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)},
+		{"fit-1161257400", time.Date(2026, 10, 18, 11, 30, 0, 0, time.UTC), time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+	}
+	for i, tt := range tests {
+		w := workouts[i]
+		if w.ExternalID != tt.id || !w.StartTime.Equal(tt.start) || !w.EndTime.Equal(tt.end) {
+			t.Errorf("workouts[%d] = %s %v ~ %v, want %s %v ~ %v", i, w.ExternalID, w.StartTime, w.EndTime, tt.id, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParseFITInvalid(t *testing.T) {
+	valid := fixture(t, "climbing.fit")
+	headerSize := int(valid[0])
+
+	tests := []struct {
+		name string
+		data func() []byte
+	}{
+		{"empty", func() []byte { return nil }},
+		{"shorter than header", func() []byte { return valid[:11] }},
+		{"header size too small", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[0] = 10
+			return data
+		}},
+		{"header size beyond file", func() []byte { return append([]byte{40}, valid[1:20]...) }},
+		{"wrong signature", func() []byte {
+			data := append([]byte(nil), valid...)
+			copy(data[8:12], "FIT.")
+			return data
+		}},
+		{"wrong header CRC", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[12] ^= 0xFF
+			return withFileCRC(data)
+		}},
+		{"wrong file CRC", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[len(data)-1] ^= 0xFF
+			return data
+		}},
+		{"corrupted data", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[headerSize+10] ^= 0x01
+			return data
+		}},
+		{"truncated inside definition", func() []byte { return valid[:headerSize+3] }},
+		{"truncated inside data message", func() []byte {
+			// 第一个定义消息共 12 字节，之后截断在数据消息中间
+			return valid[:headerSize+14]
+		}},
+		{"data message without definition", func() []byte {
+			body := []byte{0x00, 0x01, 0x02}
+			return fitFile(body)
+		}},
+		{"compressed timestamp without definition", func() []byte {
+			return fitFile([]byte{0x80 | 3<<5})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFIT(bytes.NewReader(tt.data()))
+			if !errors.Is(err, ErrInvalidFIT) {
+				t.Errorf("ParseFIT error = %v, want ErrInvalidFIT", err)
+			}
+		})
+	}
+}
+
+func TestParseFITLenient(t *testing.T) {
+	valid := fixture(t, "climbing.fit")
+
+	tests := []struct {
+		name     string
+		data     func() []byte
+		sessions int
+	}{
+		{"zero header CRC is not checked", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[12], data[13] = 0, 0
+			return withFileCRC(data)
+		}, 2},
+		{"12 byte header without header CRC", func() []byte {
+			data := append([]byte{12}, valid[1:12]...)
+			data = append(data, valid[14:len(valid)-2]...)
+			return withFileCRC(append(data, 0, 0))
+		}, 2},
+		{"missing file CRC", func() []byte { return valid[:len(valid)-2] }, 2},
+		{"truncated at message boundary", func() []byte {
+			// 去掉最后一个 session 消息 (1 字节头 + 18 字节数据) 和文件 CRC
+			return valid[:len(valid)-2-19]
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := ParseFIT(bytes.NewReader(tt.data()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(workouts) != tt.sessions {
+				t.Errorf("got %d workouts, want %d", len(workouts), tt.sessions)
+			}
+		})
+	}
+}
+
+func TestFitValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       []byte
+		baseType  byte
+		bigEndian bool
+		want      uint64
+		wantOK    bool
+	}{
+		{"uint8", []byte{42}, 0x02, false, 42, true},
+		{"uint8 invalid", []byte{0xFF}, 0x02, false, 0, false},
+		{"enum", []byte{31}, 0x00, false, 31, true},
+		{"uint8z invalid", []byte{0}, 0x0A, false, 0, false},
+		{"uint16 little endian", []byte{0x34, 0x12}, 0x84, false, 0x1234, true},
+		{"uint16 big endian", []byte{0x12, 0x34}, 0x84, true, 0x1234, true},
+		{"uint16 invalid", []byte{0xFF, 0xFF}, 0x84, false, 0, false},
+		{"uint32", []byte{1, 0, 0, 0}, 0x86, false, 1, true},
+		{"uint32 invalid", []byte{0xFF, 0xFF, 0xFF, 0xFF}, 0x86, false, 0, false},
+		{"sint32 invalid", []byte{0xFF, 0xFF, 0xFF, 0x7F}, 0x85, false, 0, false},
+		{"size mismatch", []byte{1, 2}, 0x02, false, 0, false},
+		{"array of uint16", []byte{1, 0, 2, 0}, 0x84, false, 0, false},
+		{"unsupported string", []byte("ab"), 0x07, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := fitValue(tt.raw, tt.baseType, tt.bigEndian)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("fitValue(%v, %#x) = %d, %v; want %d, %v", tt.raw, tt.baseType, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// fitFile 用给定的数据区构造带 CRC 的 FIT 文件
+func fitFile(body []byte) []byte {
+	header := make([]byte, 14)
+	header[0] = 14
+	header[1] = 0x20
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(body)))
+	copy(header[8:12], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:14], fitCRC(header[:12]))
+
+	data := append(header, body...)
+	return binary.LittleEndian.AppendUint16(data, fitCRC(data))
+}
@@ -0,0 +1,106 @@
+package wearable
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type tcxDatabase struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Notes string   `xml:"Notes"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	Calories         float64         `xml:"Calories"`
+	AvgHeartRate     *tcxValue       `xml:"AverageHeartRateBpm"`
+	MaxHeartRate     *tcxValue       `xml:"MaximumHeartRateBpm"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxValue struct {
+	Value int `xml:"Value"`
+}
+
+type tcxTrackpoint struct {
+	Time      string    `xml:"Time"`
+	HeartRate *tcxValue `xml:"HeartRateBpm"`
+}
+
+// ParseTCX 解析 Training Center XML 文件，每个 Activity 对应一次训练
+//
+// TCX 的 Sport 属性只有 Running/Biking/Other，攀岩通常导出为 Other，
+// 因此同时根据备注判断是否为攀岩。
+func ParseTCX(r io.Reader) ([]Workout, error) {
+	var db tcxDatabase
+	if err := xml.NewDecoder(r).Decode(&db); err != nil {
+		return nil, err
+	}
+
+	var workouts []Workout
+	for _, activity := range db.Activities {
+		w := Workout{
+			Format:     FormatTCX,
+			Sport:      activity.Sport,
+			Climbing:   isClimbingName(activity.Sport) || isClimbingName(activity.Notes),
+			Bouldering: isBoulderingName(activity.Sport) || isBoulderingName(activity.Notes),
+		}
+		if activity.ID != "" {
+			w.ExternalID = "tcx-" + activity.ID
+		}
+
+		var weightedHR, timedSeconds float64
+		for _, lap := range activity.Laps {
+			start, err := time.Parse(time.RFC3339, lap.StartTime)
+			if err != nil {
+				continue
+			}
+			end := start.Add(time.Duration(lap.TotalTimeSeconds * float64(time.Second)))
+
+			if w.StartTime.IsZero() || start.Before(w.StartTime) {
+				w.StartTime = start
+			}
+			if end.After(w.EndTime) {
+				w.EndTime = end
+			}
+			w.Calories += lap.Calories
+
+			if lap.AvgHeartRate != nil && lap.AvgHeartRate.Value > 0 {
+				weightedHR += float64(lap.AvgHeartRate.Value) * lap.TotalTimeSeconds
+				timedSeconds += lap.TotalTimeSeconds
+			}
+			if lap.MaxHeartRate != nil && lap.MaxHeartRate.Value > w.MaxHeartRate {
+				w.MaxHeartRate = lap.MaxHeartRate.Value
+			}
+
+			for _, tp := range lap.Trackpoints {
+				if tp.HeartRate == nil || tp.HeartRate.Value <= 0 {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, tp.Time)
+				if err != nil {
+					continue
+				}
+				w.HeartRate = append(w.HeartRate, HeartRateSample{Time: t, BPM: tp.HeartRate.Value})
+			}
+		}
+
+		if timedSeconds > 0 {
+			w.AvgHeartRate = int(weightedHR/timedSeconds + 0.5)
+		}
+		if w.StartTime.IsZero() {
+			continue
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, nil
+}
@@ -0,0 +1,135 @@
+package wearable
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTCX(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	workouts, err := ParseTCX(bytes.NewReader(fixture(t, "activities.tcx")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有可解析圈的 Activity 被跳过
+	if len(workouts) != 2 {
+		t.Fatalf("got %d workouts, want 2", len(workouts))
+	}
+
+	want := Workout{
+		Format:     FormatTCX,
+		ExternalID: "tcx-2026-10-18T09:00:00Z",
+		Sport:      "Other",
+		// 运动类型为 Other 时按备注判断攀岩和抱石
+		Climbing:   true,
+		Bouldering: true,
+		StartTime:  start,
+		EndTime:    start.Add(40 * time.Minute),
+		// 平均心率按圈时长加权: (120*1800 + 140*600) / 2400
+		AvgHeartRate: 125,
+		MaxHeartRate: 165,
+		// 开始时间无效的圈不计入
+		Calories: 250.5,
+		// 没有心率或时间无效的采样点被跳过
+		HeartRate: []HeartRateSample{
+			{Time: start.Add(10 * time.Minute), BPM: 125},
+			{Time: start.Add(35 * time.Minute), BPM: 160},
+		},
+	}
+	if !reflect.DeepEqual(workouts[0], want) {
+		t.Errorf("workouts[0] = %+v\nwant %+v", workouts[0], want)
+	}
+
+	running := workouts[1]
+	if running.Climbing || running.Sport != "Running" || running.AvgHeartRate != 0 {
+		t.Errorf("workouts[1] = %+v, want a non-climbing run without heart rate", running)
+	}
+}
+
+func TestParseTCXFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ParseOptions
+		want []string
+	}{
+		{"climbing only", ParseOptions{}, []string{"tcx-2026-10-18T09:00:00Z"}},
+		{"assume climbing", ParseOptions{AssumeClimbing: true}, []string{"tcx-2026-10-18T09:00:00Z", "tcx-2026-10-18T18:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := Parse(FormatTCX, bytes.NewReader(fixture(t, "activities.tcx")), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, w := range workouts {
+				if !w.Climbing {
+					t.Errorf("%s: Climbing = false after filtering", w.ExternalID)
+				}
+				ids = append(ids, w.ExternalID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("external IDs = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTCXEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    int
+		wantErr bool
+	}{
+		{"malformed xml", `<TrainingCenterDatabase><Activities><Activity>`, 0, true},
+		{"not xml", `{"activities": []}`, 0, true},
+		{"no activities", `<TrainingCenterDatabase><Activities/></TrainingCenterDatabase>`, 0, false},
+		{"activity without laps", `<TrainingCenterDatabase><Activities><Activity Sport="Other"><Id>x</Id></Activity></Activities></TrainingCenterDatabase>`, 0, false},
+		{"lap without heart rate", `<TrainingCenterDatabase><Activities><Activity Sport="Other">
+			<Lap StartTime="2026-10-18T09:00:00+08:00"><TotalTimeSeconds>60</TotalTimeSeconds></Lap>
+			</Activity></Activities></TrainingCenterDatabase>`, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := ParseTCX(strings.NewReader(tt.xml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTCX error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(workouts) != tt.want {
+				t.Errorf("got %d workouts, want %d", len(workouts), tt.want)
+			}
+		})
+	}
+}
+
+func TestFinalizeWithoutID(t *testing.T) {
+	// 没有 Id 的 TCX 训练按开始时间生成外部标识，结束时间不早于最后一个采样
+	start := time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC)
+	w := Workout{
+		Format:    FormatTCX,
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		HeartRate: []HeartRateSample{
+			{Time: start.Add(5 * time.Minute), BPM: 150},
+			{Time: start.Add(2 * time.Minute), BPM: 110},
+		},
+	}
+	w.finalize()
+
+	if w.ExternalID != "tcx-1792285200" {
+		t.Errorf("ExternalID = %q", w.ExternalID)
+	}
+	if !w.EndTime.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("EndTime = %v, want last sample time", w.EndTime)
+	}
+	if w.HeartRate[0].BPM != 110 || w.AvgHeartRate != 130 || w.MaxHeartRate != 150 {
+		t.Errorf("heart rate = %+v avg %d max %d", w.HeartRate, w.AvgHeartRate, w.MaxHeartRate)
+	}
+}
@@ -0,0 +1,185 @@
+package wearable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format 穿戴设备导出文件格式
+type Format string
+
+const (
+	FormatFIT            Format = "fit"              // Garmin FIT 二进制文件
+	FormatTCX            Format = "tcx"              // Training Center XML
+	FormatAppleHealth    Format = "apple_health"     // Apple 健康 export.xml
+	FormatAppleHealthZip Format = "apple_health_zip" // Apple 健康导出的 zip 压缩包
+)
+
+// ErrUnsupportedFormat 无法识别的文件格式
+var ErrUnsupportedFormat = errors.New("unsupported workout file format")
+
+// HeartRateSample 单个心率采样点
+type HeartRateSample struct {
+	Time time.Time `json:"time"`
+	BPM  int       `json:"bpm"`
+}
+
+// Workout 从穿戴设备文件中解析出的一次训练
+type Workout struct {
+	Format       Format            `json:"format"`
+	ExternalID   string            `json:"external_id"` // 设备侧的训练标识
+	Sport        string            `json:"sport"`       // 原始运动类型描述
+	Climbing     bool              `json:"climbing"`    // 是否为攀岩训练
+	Bouldering   bool              `json:"bouldering"`  // 是否可确认为抱石
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	AvgHeartRate int               `json:"avg_heart_rate"`
+	MaxHeartRate int               `json:"max_heart_rate"`
+	Calories     float64           `json:"calories"` // 实测热量消耗 (kcal)
+	HeartRate    []HeartRateSample `json:"heart_rate,omitempty"`
+}
+
+// ParseOptions 解析选项
+type ParseOptions struct {
+	// AssumeClimbing 将文件中所有训练都视为攀岩
+	// (TCX 等格式无法表达攀岩运动类型时使用)
+	AssumeClimbing bool
+}
+
+// DetectFormat 根据文件名推断文件格式
+func DetectFormat(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fit":
+		return FormatFIT, nil
+	case ".tcx":
+		return FormatTCX, nil
+	case ".xml":
+		return FormatAppleHealth, nil
+	case ".zip":
+		return FormatAppleHealthZip, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ParseFormat 解析用户传入的格式名称
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatFIT:
+		return FormatFIT, nil
+	case FormatTCX:
+		return FormatTCX, nil
+	case FormatAppleHealth:
+		return FormatAppleHealth, nil
+	case FormatAppleHealthZip:
+		return FormatAppleHealthZip, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Parse 按指定格式解析训练文件，只返回攀岩训练
+func Parse(format Format, r io.Reader, opts ParseOptions) ([]Workout, error) {
+	var workouts []Workout
+	var err error
+
+	switch format {
+	case FormatFIT:
+		workouts, err = ParseFIT(r)
+	case FormatTCX:
+		workouts, err = ParseTCX(r)
+	case FormatAppleHealth:
+		workouts, err = ParseAppleHealth(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return filterClimbing(workouts, opts), nil
+}
+
+// filterClimbing 过滤出攀岩训练并补全派生字段
+func filterClimbing(workouts []Workout, opts ParseOptions) []Workout {
+	var result []Workout
+	for _, w := range workouts {
+		if !w.Climbing && !opts.AssumeClimbing {
+			continue
+		}
+		w.Climbing = true
+		w.finalize()
+		result = append(result, w)
+	}
+	return result
+}
+
+// finalize 根据心率采样补全平均/最大心率，并保证结束时间有效
+func (w *Workout) finalize() {
+	sort.Slice(w.HeartRate, func(i, j int) bool {
+		return w.HeartRate[i].Time.Before(w.HeartRate[j].Time)
+	})
+
+	if len(w.HeartRate) > 0 {
+		if w.EndTime.IsZero() || w.EndTime.Before(w.HeartRate[len(w.HeartRate)-1].Time) {
+			w.EndTime = w.HeartRate[len(w.HeartRate)-1].Time
+		}
+
+		sum, maxBPM := 0, 0
+		for _, s := range w.HeartRate {
+			sum += s.BPM
+			if s.BPM > maxBPM {
+				maxBPM = s.BPM
+			}
+		}
+		if w.AvgHeartRate == 0 {
+			w.AvgHeartRate = sum / len(w.HeartRate)
+		}
+		if w.MaxHeartRate == 0 {
+			w.MaxHeartRate = maxBPM
+		}
+	}
+
+	if w.EndTime.Before(w.StartTime) {
+		w.EndTime = w.StartTime
+	}
+	if w.ExternalID == "" {
+		w.ExternalID = fmt.Sprintf("%s-%d", w.Format, w.StartTime.Unix())
+	}
+}
+
+// SamplesBetween 返回 [from, to] 时间窗口内的心率采样
+func (w *Workout) SamplesBetween(from, to time.Time) []HeartRateSample {
+	return samplesWithin(w.HeartRate, from, to)
+}
+
+// samplesWithin 挑出 [from, to] 时间窗口内的采样
+func samplesWithin(samples []HeartRateSample, from, to time.Time) []HeartRateSample {
+	var result []HeartRateSample
+	for _, s := range samples {
+		if !s.Time.Before(from) && !s.Time.After(to) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// isClimbingName 判断运动名称或备注是否描述了攀岩
+func isClimbingName(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range []string{"climb", "boulder", "攀岩", "抱石"} {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBoulderingName 判断运动名称或备注是否描述了抱石
+func isBoulderingName(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "boulder") || strings.Contains(name, "抱石")
+}